@@ -6,6 +6,7 @@ import (
 	"backend/internal/config"
 	"backend/internal/database"
 	"backend/internal/handler"
+	"backend/internal/jobs"
 	"backend/internal/logger"
 	"backend/internal/server"
 )
@@ -21,6 +22,7 @@ func main() {
 
 	handler.InitDatabase(cfg)
 	database.RunMigrations()
+	jobs.Start(cfg)
 	app := server.New(cfg)
 
 	logger.Info.Printf("server started on port: %s", cfg.AppPort)
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"backend/internal/config"
+	"backend/internal/handler"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/services"
+)
+
+// Импорт участников и проектов из CSV-выгрузок реестра. По умолчанию
+// только показывает изменения; -apply применяет их одной транзакцией.
+func main() {
+	usersPath := flag.String("users", "", "CSV-файл реестра участников")
+	projectsPaths := flag.String("projects", "", "CSV-файлы листов проектов через запятую")
+	apply := flag.Bool("apply", false, "применить изменения (без флага — только показать)")
+	prune := flag.Bool("prune", false, "отключить участников и архивировать проекты, которых нет в реестре")
+	flag.Parse()
+
+	if *usersPath == "" && *projectsPaths == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	if err := logger.Init("logs/import.log"); err != nil {
+		log.Fatalf("failed to init logger: %v", err)
+	}
+	handler.InitDatabase(cfg)
+
+	var (
+		users    []model.UserProfile
+		projects []model.Project
+		issues   []model.ImportIssue
+	)
+
+	if *usersPath != "" {
+		file, err := os.Open(*usersPath)
+		if err != nil {
+			log.Fatalf("failed to open users file: %v", err)
+		}
+		parsed, parseIssues := services.ParseUsersCSV(*usersPath, file)
+		file.Close()
+		users = parsed
+		issues = append(issues, parseIssues...)
+	}
+
+	if *projectsPaths != "" {
+		projects = make([]model.Project, 0)
+		for _, path := range strings.Split(*projectsPaths, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				log.Fatalf("failed to open projects file: %v", err)
+			}
+			parsed, parseIssues := services.ParseProjectsCSV(path, file)
+			file.Close()
+			projects = append(projects, parsed...)
+			issues = append(issues, parseIssues...)
+		}
+	}
+
+	plan, err := services.PlanImport(cfg, users, projects, *prune)
+	if err != nil {
+		log.Fatalf("failed to build import plan: %v", err)
+	}
+	plan.Issues = append(issues, plan.Issues...)
+
+	printPlan(plan)
+
+	if len(plan.Issues) > 0 {
+		fmt.Printf("\n%d issue(s) found, nothing applied\n", len(plan.Issues))
+		os.Exit(1)
+	}
+	if len(plan.Users) == 0 && len(plan.Projects) == 0 {
+		fmt.Println("\nnothing to change")
+		return
+	}
+	if !*apply {
+		fmt.Println("\ndry run, rerun with -apply to write changes")
+		return
+	}
+
+	if err := services.ApplyImport(cfg, plan); err != nil {
+		log.Fatalf("failed to apply import: %v", err)
+	}
+	fmt.Println("\nimport applied")
+}
+
+func printPlan(plan *model.ImportPlan) {
+	marks := map[string]string{
+		model.ImportCreate: "+",
+		model.ImportUpdate: "~",
+		model.ImportRemove: "-",
+	}
+
+	for _, change := range plan.Users {
+		user := change.User
+		fmt.Printf("%s user %s @%s %s\n", marks[change.Action], user.TelegramID, user.Username, user.FullName)
+		printChanges(change.Changes)
+	}
+	for _, change := range plan.Projects {
+		fmt.Printf("%s project %q\n", marks[change.Action], change.Project.Title)
+		printChanges(change.Changes)
+	}
+
+	fmt.Printf("\nusers: %s\nprojects: %s\n", summarize(plan.Users), summarizeProjects(plan.Projects))
+
+	for _, issue := range plan.Issues {
+		switch {
+		case issue.Line > 0:
+			fmt.Printf("! %s:%d: %s\n", issue.File, issue.Line, issue.Message)
+		case issue.File != "":
+			fmt.Printf("! %s: %s\n", issue.File, issue.Message)
+		default:
+			fmt.Printf("! %s\n", issue.Message)
+		}
+	}
+}
+
+func printChanges(changes []model.FieldChange) {
+	for _, change := range changes {
+		fmt.Printf("    %s: %q → %q\n", change.Field, change.Old, change.New)
+	}
+}
+
+func summarize(changes []model.UserImportChange) string {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	return formatCounts(counts)
+}
+
+func summarizeProjects(changes []model.ProjectImportChange) string {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	return formatCounts(counts)
+}
+
+func formatCounts(counts map[string]int) string {
+	return fmt.Sprintf("%d to create, %d to update, %d to remove",
+		counts[model.ImportCreate], counts[model.ImportUpdate], counts[model.ImportRemove])
+}
@@ -0,0 +1,83 @@
+package calendar
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	prodID      = "-//TaskManagerITC//Calendar//RU"
+	maxLineSize = 75
+)
+
+type Entry struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+}
+
+// Render собирает календарь в формате iCalendar (RFC 5545). UID записей
+// должны быть стабильными, чтобы клиенты обновляли события, а не дублировали их.
+func Render(name string, entries []Entry) string {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+prodID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:PUBLISH")
+	writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+
+	for _, entry := range entries {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+entry.UID)
+		writeLine(&b, "DTSTAMP:"+stamp)
+		if entry.AllDay {
+			end := entry.End
+			if !end.After(entry.Start) {
+				end = entry.Start.AddDate(0, 0, 1)
+			}
+			writeLine(&b, "DTSTART;VALUE=DATE:"+entry.Start.Format("20060102"))
+			writeLine(&b, "DTEND;VALUE=DATE:"+end.Format("20060102"))
+		} else {
+			writeLine(&b, "DTSTART:"+entry.Start.UTC().Format("20060102T150405Z"))
+			writeLine(&b, "DTEND:"+entry.End.UTC().Format("20060102T150405Z"))
+		}
+		writeLine(&b, "SUMMARY:"+escapeText(entry.Summary))
+		if entry.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(entry.Description))
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+func escapeText(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, ";", "\\;")
+	value = strings.ReplaceAll(value, ",", "\\,")
+	value = strings.ReplaceAll(value, "\r\n", "\\n")
+	value = strings.ReplaceAll(value, "\n", "\\n")
+	return value
+}
+
+// writeLine пишет строку, перенося её по 75 октетов без разрыва UTF-8 символов.
+func writeLine(b *strings.Builder, line string) {
+	size := 0
+	for _, r := range line {
+		runeSize := len(string(r))
+		if size+runeSize > maxLineSize {
+			b.WriteString("\r\n ")
+			size = 1
+		}
+		b.WriteRune(r)
+		size += runeSize
+	}
+	b.WriteString("\r\n")
+}
@@ -3,6 +3,10 @@ package config
 import (
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"backend/internal/model"
 	"github.com/joho/godotenv"
@@ -21,6 +25,14 @@ func LoadConfig() *model.Config {
 		DBDSN:            getEnv("DBDSN", ""),
 		NAME_OF_DATABASE: getEnv("NAME_OF_DATABASE", ""),
 		DATABASE:         getEnv("DATABASE", ""),
+		Timezone:         getEnv("TIMEZONE", "Europe/Moscow"),
+		PublicURL:        getEnv("PUBLIC_URL", ""),
+		EventReminders:   getEnv("EVENT_REMINDERS", "1440,60"),
+		ProfileApproval:  getEnv("PROFILE_APPROVAL", "true") == "true",
+		BirthdayChatID:   getEnv("BIRTHDAY_CHAT_ID", ""),
+		BirthdayHour:     getEnv("BIRTHDAY_GREETING_HOUR", "9"),
+		EncryptionKeys:   getEnv("FIELD_ENCRYPTION_KEYS", ""),
+		OutboxWorkers:    getEnv("OUTBOX_WORKERS", "4"),
 	}
 
 	return cfg
@@ -32,3 +44,48 @@ func getEnv(key, def string) string {
 	}
 	return def
 }
+
+// Location возвращает часовой пояс сообщества. Если базы tzdata нет,
+// используется фиксированное московское смещение.
+func Location(cfg *model.Config) *time.Location {
+	if loc, err := time.LoadLocation(cfg.Timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone("MSK", 3*60*60)
+}
+
+// EventReminderOffsets возвращает, за сколько до начала события напоминать
+// участникам. EVENT_REMINDERS — минуты через запятую, например "1440,60".
+func EventReminderOffsets(cfg *model.Config) []time.Duration {
+	offsets := make([]time.Duration, 0)
+	for _, part := range strings.Split(cfg.EventReminders, ",") {
+		minutes, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || minutes <= 0 {
+			continue
+		}
+		offsets = append(offsets, time.Duration(minutes)*time.Minute)
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+// BirthdayGreetingHour возвращает час (по времени сообщества), начиная с
+// которого отправляются поздравления с днём рождения.
+func BirthdayGreetingHour(cfg *model.Config) int {
+	hour, err := strconv.Atoi(strings.TrimSpace(cfg.BirthdayHour))
+	if err != nil || hour < 0 || hour > 23 {
+		return 9
+	}
+	return hour
+}
+
+// OutboxWorkerCount возвращает число параллельных обработчиков очереди
+// уведомлений.
+func OutboxWorkerCount(cfg *model.Config) int {
+	workers, err := strconv.Atoi(strings.TrimSpace(cfg.OutboxWorkers))
+	if err != nil || workers < 1 {
+		return 4
+	}
+	return workers
+}
@@ -0,0 +1,159 @@
+// Package fieldcrypt шифрует отдельные поля базы (AES-256-GCM) с поддержкой
+// нескольких версий ключа для ротации.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// prefix отличает зашифрованные значения от старых открытых:
+// "enc:v2:<base64(nonce|ciphertext)>".
+const prefix = "enc:v"
+
+var ErrUnknownKey = errors.New("no encryption key for this value")
+
+// Keyring — набор ключей по версиям. Новые значения шифруются текущим
+// ключом, старые расшифровываются ключом своей версии.
+type Keyring struct {
+	current int
+	keys    map[int]cipher.AEAD
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*Keyring{}
+)
+
+// Load разбирает строку ключей вида "2:<base64>,1:<base64>", где первый ключ
+// текущий. Каждый ключ — 32 байта в base64. Для пустой строки возвращает nil:
+// шифрование выключено. Результат кешируется.
+func Load(spec string) (*Keyring, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if keyring, ok := cache[spec]; ok {
+		return keyring, nil
+	}
+
+	keyring := &Keyring{keys: make(map[int]cipher.AEAD)}
+	for i, part := range strings.Split(spec, ",") {
+		versionText, encoded, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid encryption key %d: expected version:base64", i+1)
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(versionText))
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid encryption key version %q", versionText)
+		}
+		if _, exists := keyring.keys[version]; exists {
+			return nil, fmt.Errorf("duplicate encryption key version %d", version)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("encryption key version %d must be 32 bytes in base64", version)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.keys[version] = aead
+		if i == 0 {
+			keyring.current = version
+		}
+	}
+
+	cache[spec] = keyring
+	return keyring, nil
+}
+
+// Encrypt шифрует значение текущим ключом. Пустая строка и выключенное
+// шифрование (nil) оставляют значение как есть.
+func (k *Keyring) Encrypt(value string) (string, error) {
+	if k == nil || value == "" || IsEncrypted(value) {
+		return value, nil
+	}
+
+	aead := k.keys[k.current]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+
+	return fmt.Sprintf("%s%d:%s", prefix, k.current, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt возвращает открытое значение. Незашифрованные значения
+// возвращаются без изменений.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	version, payload, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	if k == nil {
+		return "", ErrUnknownKey
+	}
+	aead, ok := k.keys[version]
+	if !ok {
+		return "", fmt.Errorf("%w: version %d", ErrUnknownKey, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plain), nil
+}
+
+// NeedsReencrypt сообщает, что значение открыто или зашифровано не текущим
+// ключом.
+func (k *Keyring) NeedsReencrypt(value string) bool {
+	if k == nil || value == "" {
+		return false
+	}
+	version, _, ok := parse(value)
+	return !ok || version != k.current
+}
+
+func IsEncrypted(value string) bool {
+	_, _, ok := parse(value)
+	return ok
+}
+
+func parse(value string) (int, string, bool) {
+	if !strings.HasPrefix(value, prefix) {
+		return 0, "", false
+	}
+	versionText, payload, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(versionText)
+	if err != nil {
+		return 0, "", false
+	}
+	return version, payload, true
+}
@@ -0,0 +1,131 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, 32))
+}
+
+func mustLoad(t *testing.T, spec string) *Keyring {
+	t.Helper()
+	keyring, err := Load(spec)
+	if err != nil {
+		t.Fatalf("Load(%q): %v", spec, err)
+	}
+	return keyring
+}
+
+func TestRoundTrip(t *testing.T) {
+	keyring := mustLoad(t, "1:"+testKey(1))
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"phone", "+79991234567"},
+		{"birthday", "01.02.1990"},
+		{"unicode", "Иванов Иван"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := keyring.Encrypt(tt.value)
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if tt.value != "" && (encrypted == tt.value || !strings.HasPrefix(encrypted, "enc:v1:")) {
+				t.Fatalf("Encrypt(%q) = %q, want enc:v1: value", tt.value, encrypted)
+			}
+
+			// повторное шифрование не оборачивает значение второй раз
+			again, err := keyring.Encrypt(encrypted)
+			if err != nil || again != encrypted {
+				t.Fatalf("Encrypt(encrypted) = %q, %v, want unchanged", again, err)
+			}
+
+			decrypted, err := keyring.Decrypt(encrypted)
+			if err != nil {
+				t.Fatalf("Decrypt: %v", err)
+			}
+			if decrypted != tt.value {
+				t.Errorf("Decrypt() = %q, want %q", decrypted, tt.value)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := mustLoad(t, "1:"+testKey(1))
+	rotated := mustLoad(t, "2:"+testKey(2)+",1:"+testKey(1))
+	withoutOld := mustLoad(t, "2:"+testKey(2))
+
+	legacy, err := old.Encrypt("+79991234567")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	current, err := rotated.Encrypt("+79991234567")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(current, "enc:v2:") {
+		t.Fatalf("rotated keyring encrypted with %q, want version 2", current)
+	}
+
+	tests := []struct {
+		name      string
+		keyring   *Keyring
+		value     string
+		want      string
+		wantErr   error
+		reencrypt bool
+	}{
+		{"old value with old key kept", rotated, legacy, "+79991234567", nil, true},
+		{"current value", rotated, current, "+79991234567", nil, false},
+		{"plain value", rotated, "+79991234567", "+79991234567", nil, true},
+		{"old key removed", withoutOld, legacy, "", ErrUnknownKey, true},
+		{"encryption disabled", nil, current, "", ErrUnknownKey, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.keyring.Decrypt(tt.value)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Decrypt() error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || got != tt.want {
+				t.Fatalf("Decrypt() = %q, %v, want %q", got, err, tt.want)
+			}
+			if reencrypt := tt.keyring.NeedsReencrypt(tt.value); reencrypt != tt.reencrypt {
+				t.Errorf("NeedsReencrypt() = %v, want %v", reencrypt, tt.reencrypt)
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"missing version", testKey(1)},
+		{"zero version", "0:" + testKey(1)},
+		{"short key", "1:" + base64.StdEncoding.EncodeToString([]byte("short"))},
+		{"duplicate version", "1:" + testKey(1) + ",1:" + testKey(2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.spec); err == nil {
+				t.Errorf("Load(%q) succeeded, want error", tt.spec)
+			}
+		})
+	}
+}
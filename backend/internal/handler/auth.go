@@ -24,25 +24,7 @@ func TelegramAuthHandler(cfg *model.Config) http.HandlerFunc {
 		}
 		logger.Info.Printf("Request decoded: %+v\n", req)
 
-		dataMap := map[string]string{
-			"id":         fmt.Sprintf("%d", req.ID),
-			"first_name": req.FirstName,
-			"auth_date":  fmt.Sprintf("%d", req.AuthDate),
-			"hash":       req.Hash,
-		}
-		if req.LastName != "" {
-			dataMap["last_name"] = req.LastName
-		}
-		if req.Username != "" {
-			dataMap["username"] = req.Username
-		}
-		if req.PhotoURL != "" {
-			dataMap["photo_url"] = req.PhotoURL
-		}
-
-		logger.Info.Printf("Data map for Telegram auth: %+v\n", dataMap)
-
-		if err := telegram.CheckTelegramAuth(dataMap, cfg.TelegramBotToken); err != nil {
+		if err := checkAuthRequest(&req, cfg.TelegramBotToken); err != nil {
 			http.Error(w, "unauthorized", http.StatusUnauthorized)
 			logger.Error.Println(w, "unauthorized", http.StatusUnauthorized)
 			return
@@ -53,10 +35,22 @@ func TelegramAuthHandler(cfg *model.Config) http.HandlerFunc {
 		resp, err := services.TelegramAuth(&req, cfg)
 		if err != nil {
 			if errors.Is(err, services.ErrUserNotFound) {
-				http.Error(w, "user not found", http.StatusNotFound)
+				// незнакомому пользователю подсказываем, что можно подать заявку
+				status, _ := services.GetJoinRequestStatus(cfg, fmt.Sprintf("%d", req.ID))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":               "user not found",
+					"join_request_status": status,
+				})
 				logger.Error.Println(w, "user not found", http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, services.ErrUserDeactivated) {
+				http.Error(w, "account is deactivated", http.StatusForbidden)
+				logger.Error.Println(w, "account is deactivated", http.StatusForbidden)
+				return
+			}
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			logger.Error.Println(w, "internal error", http.StatusInternalServerError)
 			return
@@ -67,3 +61,26 @@ func TelegramAuthHandler(cfg *model.Config) http.HandlerFunc {
 		json.NewEncoder(w).Encode(resp)
 	}
 }
+
+// checkAuthRequest проверяет подпись данных виджета входа Telegram.
+func checkAuthRequest(req *model.AuthRequest, botToken string) error {
+	dataMap := map[string]string{
+		"id":         fmt.Sprintf("%d", req.ID),
+		"first_name": req.FirstName,
+		"auth_date":  fmt.Sprintf("%d", req.AuthDate),
+		"hash":       req.Hash,
+	}
+	if req.LastName != "" {
+		dataMap["last_name"] = req.LastName
+	}
+	if req.Username != "" {
+		dataMap["username"] = req.Username
+	}
+	if req.PhotoURL != "" {
+		dataMap["photo_url"] = req.PhotoURL
+	}
+
+	logger.Info.Printf("Data map for Telegram auth: %+v\n", dataMap)
+
+	return telegram.CheckTelegramAuth(dataMap, botToken)
+}
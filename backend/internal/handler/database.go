@@ -5,9 +5,13 @@ import (
 	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"path/filepath"
+	"time"
 
+	"backend/internal/config"
+	"backend/internal/fieldcrypt"
 	"backend/internal/model"
 	"backend/internal/logger"
+	"backend/internal/services"
 )
 
 var DB *sql.DB
@@ -30,6 +34,9 @@ func InitDatabase(cfg *model.Config) {
 	logger.Info.Println("Database opened successfully")
 
 	createTables()
+	migrateLegacyEventTimes(config.Location(cfg))
+	encryptSensitiveFields(cfg)
+	redactOutboxErrors(cfg)
 }
 
 func createTables() {
@@ -55,6 +62,15 @@ func createTables() {
 		logger.Info.Println("'users' table ensured")
 	}
 
+	ensureColumns("users", map[string]string{
+		"IsActive":        "INTEGER DEFAULT 1",
+		"DeactivatedAt":   "TEXT",
+		"DeactivatedBy":   "TEXT",
+		"BirthdayOptOut":  "INTEGER DEFAULT 0",
+		"PhoneVisibility": "TEXT",
+		"CapacityHours":   "REAL",
+	})
+
 	projectTable := `
 	CREATE TABLE IF NOT EXISTS projects (
 		id     			INTEGER PRIMARY KEY,
@@ -70,6 +86,12 @@ func createTables() {
 		logger.Info.Println("'projects' table ensured")
 	}
 
+	ensureColumns("projects", map[string]string{
+		"status_locked": "BOOLEAN DEFAULT 0",
+		"archived":      "BOOLEAN DEFAULT 0",
+		"visibility":    "TEXT DEFAULT 'public'",
+	})
+
 	taskTable := `
 	CREATE TABLE IF NOT EXISTS tasks (
 		id    INTEGER PRIMARY KEY, 
@@ -98,6 +120,12 @@ func createTables() {
 		"review_message":     "TEXT",
 		"reviewed_by":        "TEXT",
 		"reviewed_at":        "TEXT",
+		"start_date":         "TEXT",
+		"created_at":         "TEXT",
+		"updated_at":         "TEXT",
+		"review_count":       "INTEGER DEFAULT 0",
+		"rejection_count":    "INTEGER DEFAULT 0",
+		"estimate_hours":     "REAL",
 	})
 
 	eventTable := `
@@ -116,9 +144,418 @@ func createTables() {
 		logger.Info.Println("'events' table ensured")
 	}
 
+	ensureColumns("events", map[string]string{
+		"start_at":                "TEXT",
+		"end_at":                  "TEXT",
+		"updated_at":              "TEXT",
+		"checkin_code":            "TEXT",
+		"checkin_code_expires_at": "TEXT",
+		"checkin_code_occurrence": "TEXT",
+		"recurrence_freq":         "TEXT",
+		"recurrence_until":        "TEXT",
+		"recurrence_count":        "INTEGER",
+		"project_id":              "INTEGER",
+	})
+
+	reminderTable := `
+	CREATE TABLE IF NOT EXISTS event_reminders (
+		event_id INTEGER,
+		starts_at TEXT,
+		offset_minutes INTEGER,
+		sent_at TEXT,
+		PRIMARY KEY (event_id, starts_at, offset_minutes)
+	);
+	`
+	if _, err := DB.Exec(reminderTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_reminders' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_reminders' table ensured")
+	}
+
+	occurrenceTable := `
+	CREATE TABLE IF NOT EXISTS event_occurrences (
+		event_id INTEGER,
+		occurrence_date TEXT,
+		cancelled INTEGER DEFAULT 0,
+		title TEXT,
+		start_at TEXT,
+		end_at TEXT,
+		description TEXT,
+		PRIMARY KEY (event_id, occurrence_date)
+	);
+	`
+	if _, err := DB.Exec(occurrenceTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_occurrences' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_occurrences' table ensured")
+	}
+
+	invitationTable := `
+	CREATE TABLE IF NOT EXISTS event_invitations (
+		event_id INTEGER,
+		scope TEXT,
+		value TEXT,
+		PRIMARY KEY (event_id, scope, value)
+	);
+	`
+	if _, err := DB.Exec(invitationTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_invitations' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_invitations' table ensured")
+	}
+
+	// occurrence_date — дата повторения серии; у разовых событий пустая
+	attendanceTable := `
+	CREATE TABLE IF NOT EXISTS event_attendance (
+		event_id INTEGER,
+		occurrence_date TEXT NOT NULL DEFAULT '',
+		telegram_id TEXT,
+		rsvp TEXT,
+		rsvp_at TEXT,
+		checked_in_at TEXT,
+		checked_in_by TEXT,
+		PRIMARY KEY (event_id, occurrence_date, telegram_id)
+	);
+	`
+	if _, err := DB.Exec(attendanceTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_attendance' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_attendance' table ensured")
+	}
+	migrateAttendanceOccurrences()
+
+	checkInFailureTable := `
+	CREATE TABLE IF NOT EXISTS event_checkin_failures (
+		event_id INTEGER,
+		telegram_id TEXT,
+		attempted_at TEXT
+	);
+	`
+	if _, err := DB.Exec(checkInFailureTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_checkin_failures' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_checkin_failures' table ensured")
+	}
+
+	resourceTable := `
+	CREATE TABLE IF NOT EXISTS resources (
+		id INTEGER PRIMARY KEY,
+		name TEXT,
+		kind TEXT,
+		description TEXT,
+		created_at TEXT
+	);
+	`
+	if _, err := DB.Exec(resourceTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'resources' table: %v\n", err)
+	} else {
+		logger.Info.Println("'resources' table ensured")
+	}
+
+	eventResourceTable := `
+	CREATE TABLE IF NOT EXISTS event_resources (
+		event_id INTEGER,
+		resource_id INTEGER,
+		PRIMARY KEY (event_id, resource_id)
+	);
+	`
+	if _, err := DB.Exec(eventResourceTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'event_resources' table: %v\n", err)
+	} else {
+		logger.Info.Println("'event_resources' table ensured")
+	}
+
+	profileChangeTable := `
+	CREATE TABLE IF NOT EXISTS profile_changes (
+		id INTEGER PRIMARY KEY,
+		telegram_id TEXT,
+		field TEXT,
+		old_value TEXT,
+		value TEXT,
+		status TEXT,
+		created_at TEXT,
+		reviewed_by TEXT,
+		reviewed_at TEXT
+	);
+	`
+	if _, err := DB.Exec(profileChangeTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'profile_changes' table: %v\n", err)
+	} else {
+		logger.Info.Println("'profile_changes' table ensured")
+	}
+
+	joinRequestTable := `
+	CREATE TABLE IF NOT EXISTS join_requests (
+		id INTEGER PRIMARY KEY,
+		telegram_id TEXT UNIQUE,
+		username TEXT,
+		first_name TEXT,
+		last_name TEXT,
+		photo_url TEXT,
+		full_name TEXT,
+		number_of_phone TEXT,
+		motivation TEXT,
+		status TEXT,
+		role TEXT,
+		reject_reason TEXT,
+		created_at TEXT,
+		reviewed_by TEXT,
+		reviewed_at TEXT
+	);
+	`
+	if _, err := DB.Exec(joinRequestTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'join_requests' table: %v\n", err)
+	} else {
+		logger.Info.Println("'join_requests' table ensured")
+	}
+
+	birthdayNotificationTable := `
+	CREATE TABLE IF NOT EXISTS birthday_notifications (
+		telegram_id TEXT,
+		kind TEXT,
+		date TEXT,
+		sent_at TEXT,
+		PRIMARY KEY (telegram_id, kind, date)
+	);
+	`
+	if _, err := DB.Exec(birthdayNotificationTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'birthday_notifications' table: %v\n", err)
+	} else {
+		logger.Info.Println("'birthday_notifications' table ensured")
+	}
+
+	skillTable := `
+	CREATE TABLE IF NOT EXISTS user_skills (
+		telegram_id TEXT,
+		skill TEXT,
+		created_at TEXT,
+		PRIMARY KEY (telegram_id, skill)
+	);
+	`
+	if _, err := DB.Exec(skillTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'user_skills' table: %v\n", err)
+	} else {
+		logger.Info.Println("'user_skills' table ensured")
+	}
+
+	endorsementTable := `
+	CREATE TABLE IF NOT EXISTS skill_endorsements (
+		telegram_id TEXT,
+		skill TEXT,
+		endorsed_by TEXT,
+		endorsed_at TEXT,
+		PRIMARY KEY (telegram_id, skill, endorsed_by)
+	);
+	`
+	if _, err := DB.Exec(endorsementTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'skill_endorsements' table: %v\n", err)
+	} else {
+		logger.Info.Println("'skill_endorsements' table ensured")
+	}
+
+	absenceTable := `
+	CREATE TABLE IF NOT EXISTS user_absences (
+		telegram_id TEXT PRIMARY KEY,
+		away_from TEXT,
+		away_until TEXT,
+		delegate_id TEXT,
+		note TEXT,
+		updated_at TEXT
+	);
+	`
+	if _, err := DB.Exec(absenceTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'user_absences' table: %v\n", err)
+	} else {
+		logger.Info.Println("'user_absences' table ensured")
+	}
+
+	taskHistoryTable := `
+	CREATE TABLE IF NOT EXISTS task_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER,
+		action TEXT,
+		actor_id TEXT,
+		on_behalf_of TEXT,
+		details TEXT,
+		created_at TEXT
+	);
+	`
+	if _, err := DB.Exec(taskHistoryTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'task_history' table: %v\n", err)
+	} else {
+		logger.Info.Println("'task_history' table ensured")
+	}
+
+	notificationSettingsTable := `
+	CREATE TABLE IF NOT EXISTS notification_settings (
+		telegram_id TEXT PRIMARY KEY,
+		events TEXT,
+		quiet_start TEXT,
+		quiet_end TEXT,
+		timezone TEXT,
+		updated_at TEXT
+	);
+	`
+	if _, err := DB.Exec(notificationSettingsTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'notification_settings' table: %v\n", err)
+	} else {
+		logger.Info.Println("'notification_settings' table ensured")
+	}
+	ensureColumns("notification_settings", map[string]string{
+		"digest_frequency": "TEXT",
+		"digest_hour":      "INTEGER",
+		"digest_weekday":   "TEXT",
+	})
+
+	digestTable := `
+	CREATE TABLE IF NOT EXISTS digest_deliveries (
+		telegram_id TEXT,
+		period TEXT,
+		items INTEGER,
+		sent_at TEXT,
+		PRIMARY KEY (telegram_id, period)
+	);
+	`
+	if _, err := DB.Exec(digestTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'digest_deliveries' table: %v\n", err)
+	} else {
+		logger.Info.Println("'digest_deliveries' table ensured")
+	}
+
+	outboxTable := `
+	CREATE TABLE IF NOT EXISTS notification_outbox (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chat_id INTEGER,
+		event_type TEXT,
+		text TEXT,
+		status TEXT,
+		attempts INTEGER,
+		next_attempt_at TEXT,
+		last_error TEXT,
+		created_at TEXT,
+		updated_at TEXT,
+		sent_at TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_notification_outbox_due ON notification_outbox (status, next_attempt_at);
+	`
+	if _, err := DB.Exec(outboxTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'notification_outbox' table: %v\n", err)
+	} else {
+		logger.Info.Println("'notification_outbox' table ensured")
+	}
+	migrateDeferredNotifications()
+
+	templateTable := `
+	CREATE TABLE IF NOT EXISTS project_templates (
+		id INTEGER PRIMARY KEY,
+		title TEXT,
+		description TEXT,
+		tasks TEXT,
+		created_by TEXT,
+		created_at TEXT
+	);
+	`
+	if _, err := DB.Exec(templateTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'project_templates' table: %v\n", err)
+	} else {
+		logger.Info.Println("'project_templates' table ensured")
+	}
+
+	milestoneTable := `
+	CREATE TABLE IF NOT EXISTS milestones (
+		id INTEGER PRIMARY KEY,
+		id_project INTEGER,
+		title TEXT,
+		description TEXT,
+		due_date TEXT,
+		created_at TEXT
+	);
+	`
+	if _, err := DB.Exec(milestoneTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'milestones' table: %v\n", err)
+	} else {
+		logger.Info.Println("'milestones' table ensured")
+	}
+
+	milestoneTasksTable := `
+	CREATE TABLE IF NOT EXISTS milestone_tasks (
+		milestone_id INTEGER,
+		task_id INTEGER,
+		PRIMARY KEY (milestone_id, task_id)
+	);
+	`
+	if _, err := DB.Exec(milestoneTasksTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'milestone_tasks' table: %v\n", err)
+	} else {
+		logger.Info.Println("'milestone_tasks' table ensured")
+	}
+
+	dependencyTable := `
+	CREATE TABLE IF NOT EXISTS task_dependencies (
+		task_id INTEGER,
+		depends_on INTEGER,
+		PRIMARY KEY (task_id, depends_on)
+	);
+	`
+	if _, err := DB.Exec(dependencyTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'task_dependencies' table: %v\n", err)
+	} else {
+		logger.Info.Println("'task_dependencies' table ensured")
+	}
+
+	calendarTokenTable := `
+	CREATE TABLE IF NOT EXISTS calendar_tokens (
+		telegram_id TEXT PRIMARY KEY,
+		token TEXT UNIQUE,
+		created_at TEXT
+	);
+	`
+	if _, err := DB.Exec(calendarTokenTable); err != nil {
+		logger.Fatal.Fatalf("Failed to create 'calendar_tokens' table: %v\n", err)
+	} else {
+		logger.Info.Println("'calendar_tokens' table ensured")
+	}
+
 	seedEvents()
 }
 
+// migrateDeferredNotifications переносит уведомления, отложенные на тихие
+// часы до появления очереди отправки, в notification_outbox.
+func migrateDeferredNotifications() {
+	var name string
+	err := DB.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'deferred_notifications'`).Scan(&name)
+	if err == sql.ErrNoRows {
+		return
+	}
+	if err != nil {
+		logger.Fatal.Fatalf("Failed to check 'deferred_notifications' table: %v\n", err)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		logger.Fatal.Fatalf("Failed to migrate 'deferred_notifications': %v\n", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO notification_outbox (chat_id, event_type, text, status, attempts, next_attempt_at, last_error, created_at, updated_at, sent_at)
+		SELECT CAST(telegram_id AS INTEGER), event_type, message, 'pending', 0, deliver_at, '', created_at, created_at, ''
+		FROM deferred_notifications
+	`)
+	if err == nil {
+		_, err = tx.Exec(`DROP TABLE deferred_notifications`)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		logger.Fatal.Fatalf("Failed to migrate 'deferred_notifications': %v\n", err)
+	}
+
+	moved, _ := result.RowsAffected()
+	logger.Info.Printf("Moved %d deferred notifications to 'notification_outbox'\n", moved)
+}
+
 func ensureColumns(table string, columns map[string]string) {
 	for name, columnType := range columns {
 		if columnExists(table, name) {
@@ -191,3 +628,214 @@ func seedEvents() {
 		logger.Info.Println("Events table seeded successfully")
 	}
 }
+
+// migrateAttendanceOccurrences пересобирает event_attendance со старым
+// ключом (event_id, telegram_id): SQLite не умеет менять первичный ключ.
+// Прежние отметки переносятся с пустой датой повторения.
+func migrateAttendanceOccurrences() {
+	if columnExists("event_attendance", "occurrence_date") {
+		return
+	}
+	logger.Info.Println("Migrating event_attendance to per-occurrence keys...")
+
+	tx, err := DB.Begin()
+	if err != nil {
+		logger.Fatal.Fatalf("Failed to begin event_attendance migration: %v\n", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`ALTER TABLE event_attendance RENAME TO event_attendance_old`,
+		`CREATE TABLE event_attendance (
+			event_id INTEGER,
+			occurrence_date TEXT NOT NULL DEFAULT '',
+			telegram_id TEXT,
+			rsvp TEXT,
+			rsvp_at TEXT,
+			checked_in_at TEXT,
+			checked_in_by TEXT,
+			PRIMARY KEY (event_id, occurrence_date, telegram_id)
+		)`,
+		`INSERT INTO event_attendance (event_id, occurrence_date, telegram_id, rsvp, rsvp_at, checked_in_at, checked_in_by)
+			SELECT event_id, '', telegram_id, rsvp, rsvp_at, checked_in_at, checked_in_by FROM event_attendance_old`,
+		`DROP TABLE event_attendance_old`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			logger.Fatal.Fatalf("Failed to migrate event_attendance: %v\n", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		logger.Fatal.Fatalf("Failed to commit event_attendance migration: %v\n", err)
+	}
+	logger.Info.Println("'event_attendance' migrated to per-occurrence keys")
+}
+
+// migrateLegacyEventTimes переносит старые строковые date ("18.01.26") и
+// time_range ("17:00-18:30") в start_at/end_at, хранящиеся в UTC (RFC3339).
+func migrateLegacyEventTimes(loc *time.Location) {
+	logger.Info.Println("Migrating legacy event dates...")
+
+	rows, err := DB.Query(`
+		SELECT id, COALESCE(date, ''), COALESCE(time_range, '')
+		FROM events
+		WHERE COALESCE(start_at, '') = ''
+	`)
+	if err != nil {
+		logger.Fatal.Fatalf("Failed to query legacy events: %v\n", err)
+	}
+
+	type legacyEvent struct {
+		id        int
+		date      string
+		timeRange string
+	}
+	var legacy []legacyEvent
+	for rows.Next() {
+		var e legacyEvent
+		if err := rows.Scan(&e.id, &e.date, &e.timeRange); err != nil {
+			rows.Close()
+			logger.Fatal.Fatalf("Failed to scan legacy event: %v\n", err)
+		}
+		legacy = append(legacy, e)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Fatal.Fatalf("Error iterating legacy events: %v\n", err)
+	}
+	rows.Close()
+
+	for _, e := range legacy {
+		start, end, err := services.ParseLegacyEventTime(e.date, e.timeRange, loc)
+		if err != nil {
+			logger.Error.Printf("Skipping event %d with unparseable date '%s' '%s': %v\n", e.id, e.date, e.timeRange, err)
+			continue
+		}
+
+		_, err = DB.Exec(
+			`UPDATE events SET start_at = ?, end_at = ? WHERE id = ?`,
+			start.UTC().Format(time.RFC3339),
+			end.UTC().Format(time.RFC3339),
+			e.id,
+		)
+		if err != nil {
+			logger.Fatal.Fatalf("Failed to migrate event %d: %v\n", e.id, err)
+		}
+		logger.Info.Printf("Migrated event %d to %s - %s\n", e.id, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+}
+
+// encryptSensitiveFields шифрует открытые телефоны и даты рождения и
+// перешифровывает значения, зашифрованные старыми версиями ключа. Повторный
+// запуск ничего не меняет.
+func encryptSensitiveFields(cfg *model.Config) {
+	keyring, err := fieldcrypt.Load(cfg.EncryptionKeys)
+	if err != nil {
+		logger.Fatal.Fatalf("Invalid FIELD_ENCRYPTION_KEYS: %v\n", err)
+	}
+	if keyring == nil {
+		// без ключей зашифрованные значения прочитать нельзя, а вход и
+		// профили перестанут работать
+		var encrypted int
+		if err := DB.QueryRow(`
+			SELECT COUNT(*) FROM users
+			WHERE DateOfBirthday LIKE 'enc:v%' OR NumberOfPhone LIKE 'enc:v%'
+		`).Scan(&encrypted); err != nil {
+			logger.Fatal.Fatalf("Failed to check encrypted fields: %v\n", err)
+		}
+		if encrypted > 0 {
+			logger.Fatal.Fatalf("Database contains encrypted fields but FIELD_ENCRYPTION_KEYS is not set\n")
+		}
+		logger.Info.Println("Field encryption is disabled, FIELD_ENCRYPTION_KEYS is not set")
+		return
+	}
+
+	logger.Info.Println("Encrypting sensitive fields...")
+
+	targets := []struct {
+		table   string
+		key     string
+		columns []string
+	}{
+		{"users", "TelegramID", []string{"DateOfBirthday", "NumberOfPhone"}},
+		{"join_requests", "id", []string{"number_of_phone"}},
+	}
+
+	for _, target := range targets {
+		for _, column := range target.columns {
+			rows, err := DB.Query(fmt.Sprintf(
+				"SELECT %s, COALESCE(%s, '') FROM %s WHERE COALESCE(%s, '') != ''",
+				target.key, column, target.table, column,
+			))
+			if err != nil {
+				logger.Fatal.Fatalf("Failed to query %s.%s: %v\n", target.table, column, err)
+			}
+
+			updates := make(map[string]string)
+			for rows.Next() {
+				var key, value string
+				if err := rows.Scan(&key, &value); err != nil {
+					rows.Close()
+					logger.Fatal.Fatalf("Failed to scan %s.%s: %v\n", target.table, column, err)
+				}
+				if !keyring.NeedsReencrypt(value) {
+					continue
+				}
+				plain, err := keyring.Decrypt(value)
+				if err != nil {
+					rows.Close()
+					logger.Fatal.Fatalf("Failed to decrypt %s.%s for %s: %v\n", target.table, column, key, err)
+				}
+				encrypted, err := keyring.Encrypt(plain)
+				if err != nil {
+					rows.Close()
+					logger.Fatal.Fatalf("Failed to encrypt %s.%s for %s: %v\n", target.table, column, key, err)
+				}
+				updates[key] = encrypted
+			}
+			if err := rows.Err(); err != nil {
+				logger.Fatal.Fatalf("Error iterating %s.%s: %v\n", target.table, column, err)
+			}
+			rows.Close()
+
+			if len(updates) == 0 {
+				continue
+			}
+
+			tx, err := DB.Begin()
+			if err != nil {
+				logger.Fatal.Fatalf("Failed to begin transaction: %v\n", err)
+			}
+			query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", target.table, column, target.key)
+			for key, value := range updates {
+				if _, err := tx.Exec(query, value, key); err != nil {
+					tx.Rollback()
+					logger.Fatal.Fatalf("Failed to update %s.%s for %s: %v\n", target.table, column, key, err)
+				}
+			}
+			if err := tx.Commit(); err != nil {
+				logger.Fatal.Fatalf("Failed to commit %s.%s: %v\n", target.table, column, err)
+			}
+			logger.Info.Printf("Encrypted %d value(s) in %s.%s\n", len(updates), target.table, column)
+		}
+	}
+}
+
+// redactOutboxErrors убирает токен бота из ошибок доставки, сохранённых до
+// того, как адрес запроса перестал попадать в текст ошибки.
+func redactOutboxErrors(cfg *model.Config) {
+	if cfg.TelegramBotToken == "" {
+		return
+	}
+	result, err := DB.Exec(`
+		UPDATE notification_outbox
+		SET last_error = REPLACE(last_error, ?, '<redacted>')
+		WHERE INSTR(last_error, ?) > 0
+	`, cfg.TelegramBotToken, cfg.TelegramBotToken)
+	if err != nil {
+		logger.Error.Printf("Failed to redact outbox errors: %v\n", err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		logger.Info.Printf("Redacted bot token from %d outbox errors\n", affected)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/services"
+)
+
+// JoinRequestHandler принимает анкету от пользователя, которого ещё нет в
+// сообществе. JWT у него нет, поэтому личность подтверждается подписью Telegram.
+func JoinRequestHandler(cfg *model.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var form model.JoinRequestForm
+		if err := json.NewDecoder(r.Body).Decode(&form); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if form.Auth.ID == 0 || form.Auth.Hash == "" {
+			http.Error(w, "invalid auth request", http.StatusBadRequest)
+			return
+		}
+		if err := checkAuthRequest(&form.Auth, cfg.TelegramBotToken); err != nil {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		request, err := services.SubmitJoinRequest(cfg, &form)
+		if err != nil {
+			if errors.Is(err, services.ErrAlreadyMember) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if errors.Is(err, services.ErrJoinRequestCooldown) {
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			logger.Error.Printf("JoinRequestHandler: %v\n", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(request)
+	}
+}
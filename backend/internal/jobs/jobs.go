@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"time"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/services"
+)
+
+const (
+	reminderInterval    = time.Minute
+	birthdayInterval    = 10 * time.Minute
+	outboxInterval      = 5 * time.Second
+	outboxPruneInterval = time.Hour
+	digestInterval      = 10 * time.Minute
+)
+
+// Start запускает фоновые задачи приложения.
+func Start(cfg *model.Config) {
+	go every(reminderInterval, "event reminders", func(now time.Time) {
+		services.SendEventReminders(cfg, now)
+	})
+	go every(birthdayInterval, "birthday greetings", func(now time.Time) {
+		services.SendBirthdayGreetings(cfg, now)
+	})
+	go every(outboxInterval, "notification outbox", func(now time.Time) {
+		services.DeliverOutbox(cfg, now)
+	})
+	go every(outboxPruneInterval, "outbox retention", func(now time.Time) {
+		services.PruneOutbox(cfg, now)
+	})
+	go every(digestInterval, "digests", func(now time.Time) {
+		services.SendDigests(cfg, now)
+	})
+}
+
+// every вызывает run с заданным интервалом. Паника в задаче логируется и
+// не останавливает ни задачу, ни сервер.
+func every(interval time.Duration, name string, run func(now time.Time)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.Error.Printf("job %s panicked: %v\n", name, r)
+				}
+			}()
+			run(now)
+		}()
+	}
+}
@@ -10,6 +10,11 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// UserActive сообщает, не отключён ли аккаунт владельца токена. Задаётся при
+// сборке сервера; если не задана, проверка пропускается. Ошибка проверки
+// отклоняет запрос.
+var UserActive func(userID int64) (bool, error)
+
 func JWTMiddleware(secret string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func (w http.ResponseWriter, r *http.Request)  {
@@ -33,6 +38,20 @@ func JWTMiddleware(secret string) func(http.Handler) http.Handler {
 				return
 			}
 
+			if UserActive != nil {
+				active, err := UserActive(claims.UserId)
+				if err != nil {
+					logger.Error.Printf("JWTMiddleware: failed to check user %d: %v\n", claims.UserId, err)
+					http.Error(w, "failed to check account status", http.StatusInternalServerError)
+					return
+				}
+				if !active {
+					logger.Error.Printf("JWTMiddleware: deactivated user %d\n", claims.UserId)
+					http.Error(w, "account is deactivated", http.StatusUnauthorized)
+					return
+				}
+			}
+
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserId)
 			ctx = context.WithValue(ctx, "role", claims.Role)
 
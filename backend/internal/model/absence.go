@@ -0,0 +1,23 @@
+package model
+
+// Absence — период отсутствия участника. From и Until — даты "2006-01-02"
+// включительно; на это время запросы на проверку и напоминания уходят
+// заместителю, а он получает права проверки в проектах отсутствующего.
+type Absence struct {
+	TelegramID string `json:"telegram_id"`
+	From       string `json:"from"`
+	Until      string `json:"until"`
+	DelegateID string `json:"delegate_id"`
+	Delegate   string `json:"delegate,omitempty"`
+	Note       string `json:"note,omitempty"`
+	Active     bool   `json:"active"`
+	UpdatedAt  string `json:"updated_at,omitempty"`
+}
+
+// AbsenceRequest — тело PUT /me/absence. Delegate — Telegram ID или username.
+type AbsenceRequest struct {
+	From     string `json:"from"`
+	Until    string `json:"until"`
+	Delegate string `json:"delegate"`
+	Note     string `json:"note"`
+}
@@ -0,0 +1,64 @@
+package model
+
+const (
+	InvitationScopeAll     = "all"
+	InvitationScopeProject = "project"
+	InvitationScopeRole    = "role"
+
+	RSVPGoing = "going"
+	RSVPMaybe = "maybe"
+	RSVPNo    = "no"
+)
+
+type EventInvitation struct {
+	Scope string `json:"scope"`
+	Value string `json:"value,omitempty"`
+}
+
+type AttendanceRecord struct {
+	EventID        int    `json:"event_id"`
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+	TelegramID     string `json:"telegram_id"`
+	RSVP           string `json:"rsvp,omitempty"`
+	RSVPAt         string `json:"rsvp_at,omitempty"`
+	CheckedInAt    string `json:"checked_in_at,omitempty"`
+	CheckedInBy    string `json:"checked_in_by,omitempty"`
+}
+
+type EventAttendee struct {
+	TelegramID  string `json:"telegram_id"`
+	Username    string `json:"username,omitempty"`
+	FullName    string `json:"full_name"`
+	Invited     bool   `json:"invited"`
+	RSVP        string `json:"rsvp,omitempty"`
+	CheckedIn   bool   `json:"checked_in"`
+	CheckedInAt string `json:"checked_in_at,omitempty"`
+}
+
+type EventAttendance struct {
+	EventID        int             `json:"event_id"`
+	OccurrenceDate string          `json:"occurrence_date,omitempty"`
+	Invited        int             `json:"invited"`
+	Going          int             `json:"going"`
+	Maybe          int             `json:"maybe"`
+	Declined       int             `json:"declined"`
+	CheckedIn      int             `json:"checked_in"`
+	Attendees      []EventAttendee `json:"attendees"`
+}
+
+// CheckInCode — код самостоятельной отметки. У повторяющегося события он
+// действует только для повторения OccurrenceDate.
+type CheckInCode struct {
+	Code           string `json:"code"`
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+	ExpiresAt      string `json:"expires_at"`
+}
+
+type AttendanceReportRow struct {
+	TelegramID string  `json:"telegram_id"`
+	Username   string  `json:"username,omitempty"`
+	FullName   string  `json:"full_name"`
+	Invited    int     `json:"invited"`
+	Attended   int     `json:"attended"`
+	Rate       float64 `json:"rate"`
+}
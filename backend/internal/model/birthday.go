@@ -0,0 +1,11 @@
+package model
+
+// Birthday — ближайший день рождения участника в запрошенном периоде.
+// Date в формате "2006-01-02"; Age не заполняется, если год рождения неизвестен.
+type Birthday struct {
+	TelegramID string `json:"telegram_id"`
+	Username   string `json:"username,omitempty"`
+	FullName   string `json:"full_name"`
+	Date       string `json:"date"`
+	Age        int    `json:"age,omitempty"`
+}
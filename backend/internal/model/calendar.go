@@ -0,0 +1,8 @@
+package model
+
+type CalendarFeeds struct {
+	Token       string `json:"token"`
+	PersonalURL string `json:"personal_url"`
+	EventsURL   string `json:"events_url"`
+	ProjectURL  string `json:"project_url"`
+}
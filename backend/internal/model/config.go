@@ -8,4 +8,12 @@ type Config struct {
 	DBDSN            string
 	NAME_OF_DATABASE string
 	DATABASE         string
+	Timezone         string
+	PublicURL        string
+	EventReminders   string
+	ProfileApproval  bool
+	BirthdayChatID   string
+	BirthdayHour     string
+	EncryptionKeys   string
+	OutboxWorkers    string
 }
@@ -1,10 +1,40 @@
 package model
 
+const (
+	RecurrenceWeekly   = "weekly"
+	RecurrenceBiweekly = "biweekly"
+	RecurrenceMonthly  = "monthly"
+)
+
 type Event struct {
-	ID          int    `json:"id"`
-	Title       string `json:"title"`
-	Date        string `json:"date"`
-	TimeRange   string `json:"time_range"`
-	CreatedBy   string `json:"created_by"`
-	Description string `json:"description,omitempty"`
+	ID             int         `json:"id"`
+	Title          string      `json:"title"`
+	StartAt        string      `json:"start_at"`
+	EndAt          string      `json:"end_at"`
+	Date           string      `json:"date"`
+	TimeRange      string      `json:"time_range"`
+	CreatedBy      string      `json:"created_by"`
+	Description    string      `json:"description,omitempty"`
+	ProjectID      int         `json:"project_id,omitempty"`
+	Recurrence     *Recurrence `json:"recurrence,omitempty"`
+	OccurrenceDate string      `json:"occurrence_date,omitempty"`
+}
+
+// Recurrence — правило повторения события. Until — последняя дата серии
+// в формате "2006-01-02", Count — число повторений; достаточно одного из них.
+type Recurrence struct {
+	Frequency string `json:"frequency"`
+	Until     string `json:"until,omitempty"`
+	Count     int    `json:"count,omitempty"`
+}
+
+// EventOccurrence — изменённое или отменённое повторение серии.
+type EventOccurrence struct {
+	EventID        int    `json:"event_id"`
+	OccurrenceDate string `json:"occurrence_date"`
+	Cancelled      bool   `json:"cancelled"`
+	Title          string `json:"title,omitempty"`
+	StartAt        string `json:"start_at,omitempty"`
+	EndAt          string `json:"end_at,omitempty"`
+	Description    string `json:"description,omitempty"`
 }
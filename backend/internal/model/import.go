@@ -0,0 +1,39 @@
+package model
+
+const (
+	ImportCreate = "create"
+	ImportUpdate = "update"
+	ImportRemove = "remove"
+)
+
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type UserImportChange struct {
+	Action  string        `json:"action"`
+	User    UserProfile   `json:"user"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+type ProjectImportChange struct {
+	Action  string        `json:"action"`
+	Project Project       `json:"project"`
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// ImportIssue — ошибка в строке файла импорта. Пока есть ошибки, план не применяется.
+type ImportIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// ImportPlan — разница между файлами реестра и базой.
+type ImportPlan struct {
+	Users    []UserImportChange    `json:"users"`
+	Projects []ProjectImportChange `json:"projects"`
+	Issues   []ImportIssue         `json:"issues"`
+}
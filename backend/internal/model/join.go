@@ -0,0 +1,35 @@
+package model
+
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+// JoinRequest — заявка на вступление от пользователя, которого нет в users.
+// Данные Telegram берутся из проверенной подписи виджета входа.
+type JoinRequest struct {
+	ID            int    `json:"id"`
+	TelegramID    string `json:"telegram_id"`
+	Username      string `json:"username,omitempty"`
+	FirstName     string `json:"first_name,omitempty"`
+	LastName      string `json:"last_name,omitempty"`
+	PhotoURL      string `json:"photo_url,omitempty"`
+	FullName      string `json:"full_name"`
+	NumberOfPhone string `json:"number_of_phone"`
+	Motivation    string `json:"motivation"`
+	Status        string `json:"status"`
+	Role          string `json:"role,omitempty"`
+	RejectReason  string `json:"reject_reason,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	ReviewedBy    string `json:"reviewed_by,omitempty"`
+	ReviewedAt    string `json:"reviewed_at,omitempty"`
+}
+
+// JoinRequestForm — анкета заявителя вместе с данными входа через Telegram.
+type JoinRequestForm struct {
+	Auth          AuthRequest `json:"auth"`
+	FullName      string      `json:"full_name"`
+	NumberOfPhone string      `json:"number_of_phone"`
+	Motivation    string      `json:"motivation"`
+}
@@ -0,0 +1,42 @@
+package model
+
+type Milestone struct {
+	ID          int     `json:"id"`
+	IdProject   int     `json:"id_project"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	DueDate     string  `json:"due_date"`
+	TaskIDs     []int   `json:"task_ids"`
+	Completed   bool    `json:"completed"`
+	Progress    float64 `json:"progress"`
+}
+
+type TaskDependency struct {
+	TaskID    int `json:"task_id"`
+	DependsOn int `json:"depends_on"`
+}
+
+type TimelineItem struct {
+	ID        string  `json:"id"`
+	Type      string  `json:"type"`
+	RefID     int     `json:"ref_id"`
+	Title     string  `json:"title"`
+	Start     string  `json:"start"`
+	End       string  `json:"end"`
+	Status    string  `json:"status,omitempty"`
+	Assignee  string  `json:"assignee,omitempty"`
+	Progress  float64 `json:"progress"`
+	Completed bool    `json:"completed"`
+}
+
+type TimelineEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+}
+
+type Timeline struct {
+	ProjectID int            `json:"project_id"`
+	Items     []TimelineItem `json:"items"`
+	Edges     []TimelineEdge `json:"edges"`
+}
@@ -0,0 +1,66 @@
+package model
+
+// Типы уведомлений, которые пользователь может настроить. Служебные
+// сообщения (заявки, статусы проектов, уведомления админам) не настраиваются.
+const (
+	NotifyNewTask = "new_task"
+	// NotifyReviewRequest — исполнитель отправил решение на проверку.
+	NotifyReviewRequest = "review_request"
+	NotifyReviewVerdict = "review_verdict"
+	// NotifyComment — комментарий к задаче.
+	NotifyComment  = "comment"
+	NotifyReminder = "reminder"
+)
+
+// ChannelDigest — не присылать сообщение сразу, событие попадёт в сводку.
+const (
+	ChannelTelegram = "telegram"
+	ChannelDigest   = "digest"
+	ChannelNone     = "none"
+)
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// NotificationEvents — все настраиваемые типы уведомлений.
+var NotificationEvents = []string{NotifyNewTask, NotifyReviewRequest, NotifyReviewVerdict, NotifyComment, NotifyReminder}
+
+// DigestEvents — типы, которые можно перенести в сводку: она сама перечисляет
+// новые задачи, решения на проверке и ближайшие события.
+var DigestEvents = []string{NotifyNewTask, NotifyReviewRequest, NotifyReminder}
+
+// NotificationSettings — настройки уведомлений пользователя. Events задаёт
+// канал для каждого типа; в тихие часы (по Timezone) сообщения
+// откладываются до их окончания.
+type NotificationSettings struct {
+	Events     map[string]string `json:"events"`
+	QuietHours *QuietHours       `json:"quiet_hours"`
+	Digest     *DigestSettings   `json:"digest"`
+	Timezone   string            `json:"timezone"`
+	UpdatedAt  string            `json:"updated_at,omitempty"`
+}
+
+// QuietHours — интервал "15:04"–"15:04"; может переходить через полночь.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DigestSettings — сводка раз в день или раз в неделю в час Hour по
+// часовому поясу пользователя. Weekday ("monday"…"sunday") — только для weekly.
+type DigestSettings struct {
+	Frequency string `json:"frequency"`
+	Hour      int    `json:"hour"`
+	Weekday   string `json:"weekday,omitempty"`
+}
+
+// DigestDelivery — отметка о сводке за период: дата для ежедневной,
+// "2006-W01" для еженедельной. Items = 0 — сводка была пустой и не отправлялась.
+type DigestDelivery struct {
+	Period string `json:"period"`
+	Items  int    `json:"items"`
+	SentAt string `json:"sent_at"`
+}
@@ -0,0 +1,25 @@
+package model
+
+// OffboardingRequest задаёт, кому передать открытые задачи уходящего участника.
+// Ключ Tasks — ID задачи, ключ Projects — ID проекта (для всех его задач);
+// значение — username нового исполнителя, пустая строка снимает исполнителя.
+// Задачи, не попавшие ни в одну из карт, остаются без исполнителя.
+type OffboardingRequest struct {
+	Tasks    map[int]string `json:"tasks"`
+	Projects map[int]string `json:"projects"`
+}
+
+type TaskReassignment struct {
+	TaskID    int    `json:"task_id"`
+	Title     string `json:"title"`
+	ProjectID int    `json:"project_id"`
+	To        string `json:"to,omitempty"`
+}
+
+type OffboardingReport struct {
+	TelegramID          string             `json:"telegram_id"`
+	FullName            string             `json:"full_name"`
+	RemovedFromProjects []int              `json:"removed_from_projects"`
+	Reassigned          []TaskReassignment `json:"reassigned"`
+	Unassigned          []TaskReassignment `json:"unassigned"`
+}
@@ -0,0 +1,45 @@
+package model
+
+// Состояния сообщения в очереди исходящих уведомлений. sending — сообщение
+// взято обработчиком; если он не отчитался до next_attempt_at, сообщение
+// возвращается в pending. dead — попытки исчерпаны или Telegram отказал
+// окончательно, сообщение ждёт ручного повтора.
+const (
+	OutboxPending = "pending"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// Типы служебных сообщений в очереди; настраиваемые типы перечислены в
+// NotificationEvents.
+const (
+	NotifyAdmin         = "admin"
+	NotifyBirthday      = "birthday"
+	NotifyDigest        = "digest"
+	NotifyJoinRequest   = "join_request"
+	NotifyOffboarding   = "offboarding"
+	NotifyProfileChange = "profile_change"
+	NotifyProjectStatus = "project_status"
+)
+
+// OutboxMessage — уведомление в очереди на отправку в Telegram. EventType —
+// тип настраиваемого уведомления или служебный тип (admin, birthday, digest…).
+type OutboxMessage struct {
+	ID            int    `json:"id"`
+	ChatID        int64  `json:"chat_id"`
+	EventType     string `json:"event_type"`
+	Text          string `json:"text"`
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	NextAttemptAt string `json:"next_attempt_at"`
+	LastError     string `json:"last_error,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+	SentAt        string `json:"sent_at,omitempty"`
+}
+
+// OutboxReplay — результат повторной постановки в очередь.
+type OutboxReplay struct {
+	Replayed int `json:"replayed"`
+}
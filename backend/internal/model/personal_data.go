@@ -0,0 +1,39 @@
+package model
+
+// TaskComment — сообщение пользователя в задаче: отчёт о выполнении или
+// комментарий при проверке.
+type TaskComment struct {
+	TaskID    int    `json:"task_id"`
+	TaskTitle string `json:"task_title"`
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+	At        string `json:"at,omitempty"`
+}
+
+type NotificationRecord struct {
+	Kind   string `json:"kind"`
+	Date   string `json:"date"`
+	SentAt string `json:"sent_at"`
+}
+
+// OutboxExportRecord — уведомление из очереди в выгрузке персональных данных:
+// только то, что было адресовано пользователю, без служебных полей доставки.
+type OutboxExportRecord struct {
+	EventType string `json:"event_type"`
+	Text      string `json:"text"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	SentAt    string `json:"sent_at,omitempty"`
+}
+
+// UserErasure описывает обезличивание пользователя: старые имена, по
+// которым он упоминается в задачах и проектах, и замещающие их псевдонимы.
+type UserErasure struct {
+	TelegramID      string `json:"telegram_id"`
+	OldUsername     string `json:"-"`
+	OldFullName     string `json:"-"`
+	Username        string `json:"username"`
+	FullName        string `json:"full_name"`
+	TasksUpdated    int    `json:"tasks_updated"`
+	ProjectsUpdated int    `json:"projects_updated"`
+}
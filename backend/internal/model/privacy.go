@@ -0,0 +1,8 @@
+package model
+
+// Уровни видимости полей профиля. Сам пользователь и админы видят все поля.
+const (
+	PrivacyAdmins   = "admins"
+	PrivacyProject  = "project"
+	PrivacyEveryone = "everyone"
+)
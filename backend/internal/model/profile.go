@@ -0,0 +1,37 @@
+package model
+
+const (
+	ProfileChangePending  = "pending"
+	ProfileChangeApproved = "approved"
+	ProfileChangeRejected = "rejected"
+)
+
+// ProfilePatch — поля, которые пользователь может менять сам через PATCH /me.
+// Роль и доступ (MayToOpen) сюда намеренно не входят.
+type ProfilePatch struct {
+	FullName        *string `json:"full_name"`
+	DateOfBirthday  *string `json:"date_of_birthday"`
+	NumberOfPhone   *string `json:"number_of_phone"`
+	BirthdayOptOut  *bool   `json:"birthday_opt_out"`
+	PhoneVisibility *string `json:"phone_visibility"`
+}
+
+// ProfileChange — заявка на изменение поля профиля, ожидающая решения админа.
+type ProfileChange struct {
+	ID         int    `json:"id"`
+	TelegramID string `json:"telegram_id"`
+	Field      string `json:"field"`
+	OldValue   string `json:"old_value"`
+	Value      string `json:"value"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+	ReviewedAt string `json:"reviewed_at,omitempty"`
+}
+
+type MeProfile struct {
+	UserProfile
+	BirthdayOptOut  bool            `json:"birthday_opt_out"`
+	PhoneVisibility string          `json:"phone_visibility"`
+	PendingChanges  []ProfileChange `json:"pending_changes"`
+}
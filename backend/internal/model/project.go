@@ -7,10 +7,19 @@ type ProjectMember struct {
 	TelegramID string `json:"telegram_id,omitempty"`
 }
 
+const (
+	VisibilityPublic  = "public"
+	VisibilityMembers = "members"
+	VisibilityAdmins  = "admins"
+)
+
 type Project struct {
-	ID          int             `json:"id"`
-	Title       string          `json:"title"`
-	Description string          `json:"description"`
-	Status      string          `json:"status"`
-	Members     []ProjectMember `json:"members"`
+	ID           int             `json:"id"`
+	Title        string          `json:"title"`
+	Description  string          `json:"description"`
+	Status       string          `json:"status"`
+	StatusLocked bool            `json:"status_locked"`
+	Archived     bool            `json:"archived"`
+	Visibility   string          `json:"visibility"`
+	Members      []ProjectMember `json:"members"`
 }
@@ -0,0 +1,34 @@
+package model
+
+const (
+	ResourceKindRoom      = "room"
+	ResourceKindEquipment = "equipment"
+)
+
+type Resource struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+}
+
+type BusySlot struct {
+	EventID        int    `json:"event_id"`
+	Title          string `json:"title"`
+	StartAt        string `json:"start_at"`
+	EndAt          string `json:"end_at"`
+	OccurrenceDate string `json:"occurrence_date,omitempty"`
+}
+
+type FreeSlot struct {
+	StartAt string `json:"start_at"`
+	EndAt   string `json:"end_at"`
+}
+
+type ResourceAvailability struct {
+	Resource Resource   `json:"resource"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Busy     []BusySlot `json:"busy"`
+	Free     []FreeSlot `json:"free"`
+}
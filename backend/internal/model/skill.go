@@ -0,0 +1,59 @@
+package model
+
+// UserSkill — навык участника: заявленный им самим и/или подтверждённый
+// руководителями.
+type UserSkill struct {
+	Skill        string   `json:"skill"`
+	SelfDeclared bool     `json:"self_declared"`
+	Endorsements int      `json:"endorsements"`
+	EndorsedBy   []string `json:"endorsed_by"`
+}
+
+type SkillSummary struct {
+	Skill string `json:"skill"`
+	Users int    `json:"users"`
+}
+
+type SkillEndorsement struct {
+	TelegramID string `json:"telegram_id"`
+	Skill      string `json:"skill"`
+	EndorsedBy string `json:"endorsed_by"`
+	EndorsedAt string `json:"endorsed_at"`
+}
+
+type SkillMatch struct {
+	Skill        string  `json:"skill"`
+	Endorsements int     `json:"endorsements"`
+	Weight       float64 `json:"weight"`
+}
+
+// AssigneeSuggestion — кандидат в исполнители с разбором оценки.
+type AssigneeSuggestion struct {
+	TelegramID    string       `json:"telegram_id,omitempty"`
+	Username      string       `json:"username"`
+	FullName      string       `json:"full_name"`
+	ProjectRole   string       `json:"project_role,omitempty"`
+	Score         float64      `json:"score"`
+	SkillScore    float64      `json:"skill_score"`
+	LoadScore     float64      `json:"load_score"`
+	OpenTasks     int          `json:"open_tasks"`
+	MatchedSkills []SkillMatch `json:"matched_skills"`
+}
+
+// SuggestionWeights — параметры оценки кандидатов, возвращаются вместе с
+// результатом, чтобы её можно было проверить.
+type SuggestionWeights struct {
+	Skill            float64 `json:"skill"`
+	Load             float64 `json:"load"`
+	EndorsementBonus float64 `json:"endorsement_bonus"`
+	MaxEndorsements  int     `json:"max_endorsements"`
+	SkillSaturation  float64 `json:"skill_saturation"`
+}
+
+type AssigneeSuggestions struct {
+	ProjectID   int                  `json:"project_id"`
+	TaskID      int                  `json:"task_id,omitempty"`
+	Text        string               `json:"text"`
+	Weights     SuggestionWeights    `json:"weights"`
+	Suggestions []AssigneeSuggestion `json:"suggestions"`
+}
@@ -0,0 +1,24 @@
+package model
+
+type MemberStats struct {
+	User     string `json:"user"`
+	Total    int    `json:"total"`
+	Done     int    `json:"done"`
+	InReview int    `json:"in_review"`
+	Overdue  int    `json:"overdue"`
+}
+
+// ProjectStats — агрегаты по задачам проекта. DaysSinceLastUpdate = nil —
+// ни у одной задачи нет даты создания или обновления.
+type ProjectStats struct {
+	ProjectID           int            `json:"project_id"`
+	Total               int            `json:"total"`
+	ByStatus            map[string]int `json:"by_status"`
+	PercentComplete     float64        `json:"percent_complete"`
+	Overdue             int            `json:"overdue"`
+	AvgHoursToApproval  float64        `json:"avg_hours_to_approval"`
+	ReviewRejectionRate float64        `json:"review_rejection_rate"`
+	DaysSinceLastUpdate *float64       `json:"days_since_last_update"`
+	HealthScore         float64        `json:"health_score"`
+	Members             []MemberStats  `json:"members"`
+}
@@ -1,18 +1,29 @@
 package model
 
 type Task struct {
-	ID                int    `json:"id"`
-	Description       string `json:"description"`
-	Deadline          string `json:"deadline"`
-	Status            string `json:"status"`
-	CompletionMessage string `json:"completion_message,omitempty"`
-	ReviewMessage     string `json:"review_message,omitempty"`
-	ReviewedBy        string `json:"reviewed_by,omitempty"`
-	ReviewedAt        string `json:"reviewed_at,omitempty"`
-	User              string `json:"user"`
-	Title             string `json:"title"`
-	Author            string `json:"author"`
-	IdProject         int    `json:"id_project"`
-	IdUser            int64  `json:"id_user"`
-	ProjectTitle      string `json:"project_title,omitempty"`
+	ID                int     `json:"id"`
+	Description       string  `json:"description"`
+	StartDate         string  `json:"start_date,omitempty"`
+	Deadline          string  `json:"deadline"`
+	Status            string  `json:"status"`
+	CompletionMessage string  `json:"completion_message,omitempty"`
+	ReviewMessage     string  `json:"review_message,omitempty"`
+	ReviewedBy        string  `json:"reviewed_by,omitempty"`
+	ReviewedAt        string  `json:"reviewed_at,omitempty"`
+	User              string  `json:"user"`
+	Title             string  `json:"title"`
+	Author            string  `json:"author"`
+	IdProject         int     `json:"id_project"`
+	IdUser            int64   `json:"id_user"`
+	ProjectTitle      string  `json:"project_title,omitempty"`
+	CreatedAt         string  `json:"created_at,omitempty"`
+	EstimateHours     float64 `json:"estimate_hours,omitempty"`
+}
+
+// TaskUpdate — тело PUT /tasks/{id}. Указатели отличают поле, которое клиент
+// не передал, от явной очистки пустой строкой или нулём.
+type TaskUpdate struct {
+	Task
+	StartDate     *string  `json:"start_date"`
+	EstimateHours *float64 `json:"estimate_hours"`
 }
@@ -0,0 +1,22 @@
+package model
+
+const (
+	TaskHistorySubmitted    = "submitted"
+	TaskHistoryReviewRouted = "review_delegated"
+	TaskHistoryApproved     = "approved"
+	TaskHistoryRejected     = "rejected"
+)
+
+// TaskHistoryEntry — запись истории задачи. OnBehalfOf заполняется, когда
+// действие выполнено заместителем отсутствующего участника.
+type TaskHistoryEntry struct {
+	ID           int    `json:"id"`
+	TaskID       int    `json:"task_id"`
+	Action       string `json:"action"`
+	ActorID      string `json:"actor_id,omitempty"`
+	Actor        string `json:"actor,omitempty"`
+	OnBehalfOfID string `json:"on_behalf_of_id,omitempty"`
+	OnBehalfOf   string `json:"on_behalf_of,omitempty"`
+	Details      string `json:"details,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
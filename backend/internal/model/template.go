@@ -0,0 +1,27 @@
+package model
+
+type TemplateTask struct {
+	Title              string  `json:"title"`
+	Description        string  `json:"description"`
+	DeadlineOffsetDays *int    `json:"deadline_offset_days,omitempty"`
+	RolePlaceholder    string  `json:"role_placeholder,omitempty"`
+	EstimateHours      float64 `json:"estimate_hours,omitempty"`
+}
+
+type ProjectTemplate struct {
+	ID          int            `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Tasks       []TemplateTask `json:"tasks"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+type ProjectFromTemplateRequest struct {
+	TemplateID  int               `json:"template_id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	StartDate   string            `json:"start_date"`
+	Members     []ProjectMember   `json:"members"`
+	RoleMapping map[string]string `json:"role_mapping"`
+}
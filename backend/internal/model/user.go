@@ -11,4 +11,5 @@ type UserProfile struct {
 	NumberOfPhone  string `json:"number_of_phone"`
 	Role           string `json:"role"`
 	MayToOpen      bool   `json:"may_to_open"`
+	Active         bool   `json:"active"`
 }
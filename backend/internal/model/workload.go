@@ -0,0 +1,43 @@
+package model
+
+// WorkloadTask — открытая задача с ближайшим дедлайном.
+type WorkloadTask struct {
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	ProjectID     int     `json:"project_id"`
+	ProjectTitle  string  `json:"project_title,omitempty"`
+	Status        string  `json:"status"`
+	Deadline      string  `json:"deadline"`
+	EstimateHours float64 `json:"estimate_hours,omitempty"`
+	Overdue       bool    `json:"overdue"`
+}
+
+// Workload — загрузка участника по всем проектам. EffortHours включает
+// задачи без оценки по UnestimatedTaskHours за каждую.
+type Workload struct {
+	TelegramID           string         `json:"telegram_id,omitempty"`
+	Username             string         `json:"username"`
+	FullName             string         `json:"full_name"`
+	OpenTasks            int            `json:"open_tasks"`
+	ProjectOpenTasks     int            `json:"project_open_tasks,omitempty"`
+	OverdueTasks         int            `json:"overdue_tasks"`
+	EstimatedHours       float64        `json:"estimated_hours"`
+	UnestimatedTasks     int            `json:"unestimated_tasks"`
+	UnestimatedTaskHours float64        `json:"unestimated_task_hours"`
+	EffortHours          float64        `json:"effort_hours"`
+	CapacityHours        float64        `json:"capacity_hours"`
+	DefaultCapacity      bool           `json:"default_capacity"`
+	Utilization          float64        `json:"utilization"`
+	OverCapacity         bool           `json:"over_capacity"`
+	UpcomingDeadlines    []WorkloadTask `json:"upcoming_deadlines"`
+}
+
+type ProjectWorkload struct {
+	ProjectID int        `json:"project_id"`
+	Title     string     `json:"title"`
+	Members   []Workload `json:"members"`
+}
+
+type CapacityUpdate struct {
+	CapacityHours float64 `json:"capacity_hours"`
+}
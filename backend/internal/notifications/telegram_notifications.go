@@ -1,25 +1,72 @@
 package notifications
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"io"
+	"strconv"
+	"time"
 
-	"fmt"
-	"backend/internal/model"
 	"backend/internal/logger"
+	"backend/internal/model"
 )
 
-func SendTelegramNotification(cfg *model.Config, id int64, text string) error{
-	msg := url.QueryEscape(text)
-	apiUrl := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage?chat_id=%d&text=%s&parse_mode=HTML", cfg.TelegramBotToken, id, msg)
+var client = &http.Client{Timeout: 15 * time.Second}
+
+// TelegramError — отказ Bot API. RetryAfter задаётся при 429: Telegram
+// просит не повторять запрос раньше указанного времени.
+type TelegramError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  time.Duration
+}
+
+func (e *TelegramError) Error() string {
+	return fmt.Sprintf("telegram api error %d: %s", e.StatusCode, e.Description)
+}
 
-	resp, err := http.Get(apiUrl)
+// Permanent сообщает, что повтор не поможет: сообщение некорректно, чат не
+// найден или пользователь заблокировал бота.
+func (e *TelegramError) Permanent() bool {
+	return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusForbidden
+}
+
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// SendTelegramNotification отправляет сообщение в чат id. Отказы Bot API
+// возвращаются как *TelegramError. В ошибках сети нет адреса запроса: он
+// содержит токен бота, а текст ошибки сохраняется в очереди.
+func SendTelegramNotification(cfg *model.Config, id int64, text string) error {
+	apiUrl := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", cfg.TelegramBotToken)
+
+	resp, err := client.PostForm(apiUrl, url.Values{
+		"chat_id":    {strconv.FormatInt(id, 10)},
+		"text":       {text},
+		"parse_mode": {"HTML"},
+	})
 	if err != nil {
-		fmt.Printf("Error: %v", err)
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("telegram sendMessage: %w", urlErr.Err)
+		}
+		return err
 	}
+	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
 
 	logger.Info.Printf(
 		"Telegram response: status=%d body=%s",
@@ -27,9 +74,25 @@ func SendTelegramNotification(cfg *model.Config, id int64, text string) error{
 		string(body),
 	)
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram api error: %s", resp.Status)
+	if resp.StatusCode == http.StatusOK {
+		return nil
 	}
 
-	return nil
-}
\ No newline at end of file
+	apiErr := &TelegramError{StatusCode: resp.StatusCode, Description: resp.Status}
+	var parsed telegramResponse
+	if json.Unmarshal(body, &parsed) == nil && !parsed.OK {
+		if parsed.ErrorCode != 0 {
+			apiErr.StatusCode = parsed.ErrorCode
+		}
+		if parsed.Description != "" {
+			apiErr.Description = parsed.Description
+		}
+		apiErr.RetryAfter = time.Duration(parsed.Parameters.RetryAfter) * time.Second
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests && apiErr.RetryAfter == 0 {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+	}
+	return apiErr
+}
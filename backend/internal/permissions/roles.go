@@ -23,6 +23,34 @@ func ParseRoles(role string) RoleSet {
 	return roles
 }
 
+// knownRoles — роли, которые понимают проверки прав и интерфейс.
+var knownRoles = map[string]bool{
+	"админ":        true,
+	"admin":        true,
+	"владелец":     true,
+	"owner":        true,
+	"модератор":    true,
+	"moderator":    true,
+	"руководитель": true,
+	"разработчик":  true,
+	"участник":     true,
+	"member":       true,
+}
+
+// IsKnownRole проверяет, что роль непустая и состоит только из известных ролей.
+func IsKnownRole(role string) bool {
+	roles := ParseRoles(role)
+	if len(roles) == 0 {
+		return false
+	}
+	for name := range roles {
+		if !knownRoles[name] {
+			return false
+		}
+	}
+	return true
+}
+
 func IsAdmin(role string) bool {
 	roles := ParseRoles(role)
 	return roles["админ"] || roles["admin"] || roles["владелец"] || roles["owner"]
@@ -54,6 +82,20 @@ func MustIncludeModerator(role string) bool {
 	return IsLeader(role)
 }
 
+// NormalizeMemberRole дополняет роль участника проекта модератором, если
+// она этого требует.
+func NormalizeMemberRole(role string) string {
+	role = strings.TrimSpace(role)
+	if role == "" {
+		return role
+	}
+
+	if MustIncludeModerator(role) && !IsModerator(role) {
+		return role + ", Модератор"
+	}
+	return role
+}
+
 func IsDeveloper(role string) bool {
 	roles := ParseRoles(role)
 	return roles["разработчик"]
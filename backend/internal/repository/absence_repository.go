@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+const absenceColumns = `telegram_id,
+	COALESCE(away_from, ''),
+	COALESCE(away_until, ''),
+	COALESCE(delegate_id, ''),
+	COALESCE(note, ''),
+	COALESCE(updated_at, '')`
+
+func scanAbsence(row rowScanner) (model.Absence, error) {
+	var a model.Absence
+	err := row.Scan(&a.TelegramID, &a.From, &a.Until, &a.DelegateID, &a.Note, &a.UpdatedAt)
+	return a, err
+}
+
+func GetAbsence(cfg *model.Config, telegramID string) (*model.Absence, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	a, err := scanAbsence(db.QueryRow(`SELECT `+absenceColumns+` FROM user_absences WHERE telegram_id = ?`, telegramID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// GetAbsencesOn возвращает отсутствия, которые действуют в день date
+// ("2006-01-02").
+func GetAbsencesOn(cfg *model.Config, date string) ([]model.Absence, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+absenceColumns+`
+		FROM user_absences
+		WHERE away_from <= ? AND away_until >= ?
+		ORDER BY away_from
+	`, date, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	absences := make([]model.Absence, 0)
+	for rows.Next() {
+		a, err := scanAbsence(rows)
+		if err != nil {
+			return nil, err
+		}
+		absences = append(absences, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return absences, nil
+}
+
+// SaveAbsence задаёт период отсутствия; прежний период пользователя заменяется.
+func SaveAbsence(cfg *model.Config, a *model.Absence) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	a.UpdatedAt = time.Now().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO user_absences (telegram_id, away_from, away_until, delegate_id, note, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET
+			away_from = excluded.away_from,
+			away_until = excluded.away_until,
+			delegate_id = excluded.delegate_id,
+			note = excluded.note,
+			updated_at = excluded.updated_at
+	`, a.TelegramID, a.From, a.Until, a.DelegateID, a.Note, a.UpdatedAt)
+	return err
+}
+
+func DeleteAbsence(cfg *model.Config, telegramID string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM user_absences WHERE telegram_id = ?`, telegramID)
+	return err
+}
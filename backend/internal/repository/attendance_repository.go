@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+func GetEventInvitations(cfg *model.Config, eventID int) ([]model.EventInvitation, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT COALESCE(scope, ''), COALESCE(value, '')
+		FROM event_invitations
+		WHERE event_id = ?
+		ORDER BY scope, value
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]model.EventInvitation, 0)
+	for rows.Next() {
+		var invitation model.EventInvitation
+		if err := rows.Scan(&invitation.Scope, &invitation.Value); err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func ReplaceEventInvitations(cfg *model.Config, eventID int, invitations []model.EventInvitation) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM event_invitations WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	for _, invitation := range invitations {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO event_invitations (event_id, scope, value) VALUES (?, ?, ?)`,
+			eventID,
+			invitation.Scope,
+			invitation.Value,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetEventAttendance возвращает отметки одного события; у повторяющихся
+// событий — одного повторения с датой occurrenceDate.
+func GetEventAttendance(cfg *model.Config, eventID int, occurrenceDate string) ([]model.AttendanceRecord, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT event_id, occurrence_date, telegram_id, COALESCE(rsvp, ''), COALESCE(rsvp_at, ''),
+			COALESCE(checked_in_at, ''), COALESCE(checked_in_by, '')
+		FROM event_attendance
+		WHERE event_id = ? AND occurrence_date = ?
+	`, eventID, occurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttendanceRecords(rows)
+}
+
+// GetAttendanceForEvents возвращает отметки по событиям, начавшимся в [from, to),
+// и по всем повторяющимся сериям, начавшимся до to: нужные повторения
+// отбирает сервис.
+func GetAttendanceForEvents(cfg *model.Config, from string, to string) ([]model.AttendanceRecord, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT a.event_id, a.occurrence_date, a.telegram_id, COALESCE(a.rsvp, ''), COALESCE(a.rsvp_at, ''),
+			COALESCE(a.checked_in_at, ''), COALESCE(a.checked_in_by, '')
+		FROM event_attendance a
+		JOIN events e ON e.id = a.event_id
+		WHERE (? = '' OR COALESCE(e.start_at, '') >= ? OR COALESCE(e.recurrence_freq, '') != '')
+			AND (? = '' OR COALESCE(e.start_at, '') < ?)
+	`, from, from, to, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttendanceRecords(rows)
+}
+
+func scanAttendanceRecords(rows *sql.Rows) ([]model.AttendanceRecord, error) {
+	records := make([]model.AttendanceRecord, 0)
+	for rows.Next() {
+		var record model.AttendanceRecord
+		if err := rows.Scan(
+			&record.EventID,
+			&record.OccurrenceDate,
+			&record.TelegramID,
+			&record.RSVP,
+			&record.RSVPAt,
+			&record.CheckedInAt,
+			&record.CheckedInBy,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func SaveRSVP(cfg *model.Config, eventID int, occurrenceDate string, telegramID string, rsvp string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO event_attendance (event_id, occurrence_date, telegram_id, rsvp, rsvp_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, occurrence_date, telegram_id) DO UPDATE SET rsvp = excluded.rsvp, rsvp_at = excluded.rsvp_at
+	`, eventID, occurrenceDate, telegramID, rsvp, time.Now().Format(time.RFC3339))
+	return err
+}
+
+func SaveCheckIn(cfg *model.Config, eventID int, occurrenceDate string, telegramID string, checkedInBy string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO event_attendance (event_id, occurrence_date, telegram_id, checked_in_at, checked_in_by)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, occurrence_date, telegram_id) DO UPDATE SET
+			checked_in_at = COALESCE(NULLIF(event_attendance.checked_in_at, ''), excluded.checked_in_at),
+			checked_in_by = COALESCE(NULLIF(event_attendance.checked_in_by, ''), excluded.checked_in_by)
+	`, eventID, occurrenceDate, telegramID, time.Now().Format(time.RFC3339), checkedInBy)
+	return err
+}
+
+func SaveCheckInCode(cfg *model.Config, eventID int, code *model.CheckInCode) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		UPDATE events SET checkin_code = ?, checkin_code_occurrence = ?, checkin_code_expires_at = ? WHERE id = ?
+	`, code.Code, code.OccurrenceDate, code.ExpiresAt, eventID)
+	return err
+}
+
+// GetCheckInCode возвращает текущий код события или nil, если события нет.
+func GetCheckInCode(cfg *model.Config, eventID int) (*model.CheckInCode, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var code model.CheckInCode
+	err = db.QueryRow(`
+		SELECT COALESCE(checkin_code, ''), COALESCE(checkin_code_occurrence, ''), COALESCE(checkin_code_expires_at, '')
+		FROM events
+		WHERE id = ?
+	`, eventID).Scan(&code.Code, &code.OccurrenceDate, &code.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+// RecordCheckInFailure запоминает неверный код, введённый участником.
+func RecordCheckInFailure(cfg *model.Config, eventID int, telegramID string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT INTO event_checkin_failures (event_id, telegram_id, attempted_at)
+		VALUES (?, ?, ?)
+	`, eventID, telegramID, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// CountCheckInFailures считает неверные коды участника начиная с since
+// (RFC3339 в UTC).
+func CountCheckInFailures(cfg *model.Config, eventID int, telegramID string, since string) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count int
+	err = db.QueryRow(`
+		SELECT COUNT(*) FROM event_checkin_failures
+		WHERE event_id = ? AND telegram_id = ? AND attempted_at >= ?
+	`, eventID, telegramID, since).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+// GetBirthdayOptOuts возвращает Telegram ID участников, отказавшихся от
+// поздравлений и показа дня рождения.
+func GetBirthdayOptOuts(cfg *model.Config) (map[string]bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT TelegramID FROM users WHERE COALESCE(BirthdayOptOut, 0) = 1`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	optOuts := make(map[string]bool)
+	for rows.Next() {
+		var telegramID string
+		if err := rows.Scan(&telegramID); err != nil {
+			return nil, err
+		}
+		optOuts[telegramID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return optOuts, nil
+}
+
+func GetBirthdayOptOut(cfg *model.Config, telegramID string) (bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	var optOut bool
+	err = db.QueryRow(`SELECT COALESCE(BirthdayOptOut, 0) FROM users WHERE TelegramID = ?`, telegramID).Scan(&optOut)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return optOut, err
+}
+
+func SetBirthdayOptOut(cfg *model.Config, telegramID string, optOut bool) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE users SET BirthdayOptOut = ? WHERE TelegramID = ?`, optOut, telegramID)
+	return err
+}
+
+// ClaimBirthdayNotification отмечает уведомление kind о дне рождения date как
+// отправленное и ставит notifications в очередь в той же транзакции.
+// Возвращает false, если оно уже было отправлено.
+func ClaimBirthdayNotification(cfg *model.Config, telegramID string, kind string, date string, notifications []model.OutboxMessage) (bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO birthday_notifications (telegram_id, kind, date, sent_at)
+		VALUES (?, ?, ?, ?)
+	`, telegramID, kind, date, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
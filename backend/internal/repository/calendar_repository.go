@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+func GetCalendarToken(cfg *model.Config, telegramID string) (string, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var token string
+	err = db.QueryRow(`SELECT token FROM calendar_tokens WHERE telegram_id = ?`, telegramID).Scan(&token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return token, nil
+}
+
+func SaveCalendarToken(cfg *model.Config, telegramID string, token string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT OR REPLACE INTO calendar_tokens (telegram_id, token, created_at)
+		VALUES (?, ?, ?)
+	`, telegramID, token, time.Now().Format(time.RFC3339))
+	return err
+}
+
+func DeleteCalendarToken(cfg *model.Config, telegramID string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM calendar_tokens WHERE telegram_id = ?`, telegramID)
+	return err
+}
+
+func GetTelegramIDByCalendarToken(cfg *model.Config, token string) (string, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var telegramID string
+	err = db.QueryRow(`SELECT telegram_id FROM calendar_tokens WHERE token = ?`, token).Scan(&telegramID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return telegramID, nil
+}
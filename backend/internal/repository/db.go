@@ -9,6 +9,12 @@ import (
 	"backend/internal/model"
 )
 
+// execer — *sql.DB или *sql.Tx: вставки можно выполнять как отдельно,
+// так и внутри общей транзакции.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 func openDB(cfg *model.Config) (*sql.DB, error) {
 	absPath, err := filepath.Abs(cfg.NAME_OF_DATABASE)
 	if err != nil {
@@ -22,3 +28,35 @@ func openDB(cfg *model.Config) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// writeLocked выполняет write в транзакции BEGIN IMMEDIATE. Блокировка записи
+// берётся до check, поэтому параллельные писатели ждут её снятия, а check
+// видит всё, что они успели сохранить. Ошибка check отменяет запись.
+func writeLocked(cfg *model.Config, check func() error, write func(tx *sql.Tx) error) error {
+	absPath, err := filepath.Abs(cfg.NAME_OF_DATABASE)
+	if err != nil {
+		return err
+	}
+
+	db, err := sql.Open(cfg.DATABASE, absPath+"?_txlock=immediate")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if check != nil {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"backend/internal/fieldcrypt"
+	"backend/internal/model"
+)
+
+// encryptField шифрует чувствительное поле ключом из конфигурации. Без
+// ключей значение сохраняется как есть.
+func encryptField(cfg *model.Config, value string) (string, error) {
+	keyring, err := fieldcrypt.Load(cfg.EncryptionKeys)
+	if err != nil {
+		return "", err
+	}
+	return keyring.Encrypt(value)
+}
+
+func decryptField(cfg *model.Config, value string) (string, error) {
+	keyring, err := fieldcrypt.Load(cfg.EncryptionKeys)
+	if err != nil {
+		return "", err
+	}
+	return keyring.Decrypt(value)
+}
+
+// decryptUser расшифровывает телефон и дату рождения после чтения из users.
+func decryptUser(cfg *model.Config, u *model.UserProfile) error {
+	var err error
+	if u.DateOfBirthday, err = decryptField(cfg, u.DateOfBirthday); err != nil {
+		return err
+	}
+	if u.NumberOfPhone, err = decryptField(cfg, u.NumberOfPhone); err != nil {
+		return err
+	}
+	return nil
+}
+
+// encryptedUser возвращает копию профиля с зашифрованными телефоном и датой
+// рождения для записи в users.
+func encryptedUser(cfg *model.Config, u *model.UserProfile) (model.UserProfile, error) {
+	encrypted := *u
+	var err error
+	if encrypted.DateOfBirthday, err = encryptField(cfg, u.DateOfBirthday); err != nil {
+		return encrypted, err
+	}
+	if encrypted.NumberOfPhone, err = encryptField(cfg, u.NumberOfPhone); err != nil {
+		return encrypted, err
+	}
+	return encrypted, nil
+}
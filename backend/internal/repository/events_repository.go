@@ -1,8 +1,61 @@
 package repository
 
-import "backend/internal/model"
+import (
+	"database/sql"
+	"time"
 
-func GetEvents(cfg *model.Config) ([]model.Event, error) {
+	"backend/internal/model"
+)
+
+const eventColumns = `id,
+	COALESCE(title, ''),
+	COALESCE(start_at, ''),
+	COALESCE(end_at, ''),
+	COALESCE(created_by, ''),
+	COALESCE(description, ''),
+	COALESCE(recurrence_freq, ''),
+	COALESCE(recurrence_until, ''),
+	COALESCE(recurrence_count, 0),
+	COALESCE(project_id, 0),
+	COALESCE(date, ''),
+	COALESCE(time_range, '')`
+
+func scanEvent(row rowScanner) (model.Event, error) {
+	var e model.Event
+	var recurrence model.Recurrence
+	err := row.Scan(
+		&e.ID,
+		&e.Title,
+		&e.StartAt,
+		&e.EndAt,
+		&e.CreatedBy,
+		&e.Description,
+		&recurrence.Frequency,
+		&recurrence.Until,
+		&recurrence.Count,
+		&e.ProjectID,
+		&e.Date,
+		&e.TimeRange,
+	)
+	if recurrence.Frequency != "" {
+		e.Recurrence = &recurrence
+	}
+	return e, err
+}
+
+func recurrenceValues(event *model.Event) (string, string, int) {
+	if event.Recurrence == nil {
+		return "", "", 0
+	}
+	return event.Recurrence.Frequency, event.Recurrence.Until, event.Recurrence.Count
+}
+
+// GetEvents возвращает события, пересекающиеся с интервалом [from, to), и все
+// повторяющиеся серии, начавшиеся до to: их повторения раскрывает сервис.
+// Не перенесённые со старых колонок события тоже попадают в выборку —
+// их время и пересечение с интервалом проверяет сервис.
+// Границы — строки RFC3339 в UTC, пустая строка означает отсутствие границы.
+func GetEvents(cfg *model.Config, from string, to string) ([]model.Event, error) {
 	db, err := openDB(cfg)
 	if err != nil {
 		return nil, err
@@ -10,10 +63,13 @@ func GetEvents(cfg *model.Config) ([]model.Event, error) {
 	defer db.Close()
 
 	rows, err := db.Query(`
-		SELECT id, title, date, time_range, created_by, COALESCE(description, '')
+		SELECT `+eventColumns+`
 		FROM events
-		ORDER BY id DESC
-	`)
+		WHERE (? = '' OR COALESCE(start_at, '') < ?)
+			AND (? = '' OR COALESCE(end_at, '') >= ? OR COALESCE(recurrence_freq, '') != ''
+				OR COALESCE(start_at, '') = '')
+		ORDER BY start_at, id
+	`, to, to, from, from)
 	if err != nil {
 		return nil, err
 	}
@@ -21,15 +77,8 @@ func GetEvents(cfg *model.Config) ([]model.Event, error) {
 
 	events := make([]model.Event, 0)
 	for rows.Next() {
-		var e model.Event
-		if err := rows.Scan(
-			&e.ID,
-			&e.Title,
-			&e.Date,
-			&e.TimeRange,
-			&e.CreatedBy,
-			&e.Description,
-		); err != nil {
+		e, err := scanEvent(rows)
+		if err != nil {
 			return nil, err
 		}
 		events = append(events, e)
@@ -41,3 +90,225 @@ func GetEvents(cfg *model.Config) ([]model.Event, error) {
 
 	return events, nil
 }
+
+func GetEventByID(cfg *model.Config, eventID int) (*model.Event, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	e, err := scanEvent(db.QueryRow(`SELECT `+eventColumns+` FROM events WHERE id = ?`, eventID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+func CreateEvent(cfg *model.Config, event *model.Event) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	freq, until, count := recurrenceValues(event)
+	result, err := db.Exec(`
+		INSERT INTO events (title, date, time_range, start_at, end_at, created_by, description,
+			recurrence_freq, recurrence_until, recurrence_count, project_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		event.Title,
+		event.Date,
+		event.TimeRange,
+		event.StartAt,
+		event.EndAt,
+		event.CreatedBy,
+		event.Description,
+		freq,
+		until,
+		count,
+		event.ProjectID,
+		time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// UpdateEvent сохраняет событие. check выполняется под блокировкой записи
+// (см. SetEventResources) и может быть nil.
+func UpdateEvent(cfg *model.Config, event *model.Event, check func() error) error {
+	freq, until, count := recurrenceValues(event)
+	return writeLocked(cfg, check, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			UPDATE events
+			SET title = ?, date = ?, time_range = ?, start_at = ?, end_at = ?, description = ?,
+				recurrence_freq = ?, recurrence_until = ?, recurrence_count = ?, project_id = ?, updated_at = ?
+			WHERE id = ?
+		`,
+			event.Title,
+			event.Date,
+			event.TimeRange,
+			event.StartAt,
+			event.EndAt,
+			event.Description,
+			freq,
+			until,
+			count,
+			event.ProjectID,
+			time.Now().Format(time.RFC3339),
+			event.ID,
+		)
+		return err
+	})
+}
+
+func DeleteEvent(cfg *model.Config, eventID int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM event_invitations WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_attendance WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_checkin_failures WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_occurrences WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_reminders WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM event_resources WHERE event_id = ?`, eventID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM events WHERE id = ?`, eventID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetEventOccurrences возвращает изменённые и отменённые повторения серии
+// по дате повторения.
+func GetEventOccurrences(cfg *model.Config, eventID int) (map[string]model.EventOccurrence, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT event_id, occurrence_date, COALESCE(cancelled, 0), COALESCE(title, ''),
+			COALESCE(start_at, ''), COALESCE(end_at, ''), COALESCE(description, '')
+		FROM event_occurrences
+		WHERE event_id = ?
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occurrences := make(map[string]model.EventOccurrence)
+	for rows.Next() {
+		var o model.EventOccurrence
+		if err := rows.Scan(
+			&o.EventID,
+			&o.OccurrenceDate,
+			&o.Cancelled,
+			&o.Title,
+			&o.StartAt,
+			&o.EndAt,
+			&o.Description,
+		); err != nil {
+			return nil, err
+		}
+		occurrences[o.OccurrenceDate] = o
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return occurrences, nil
+}
+
+// SaveEventOccurrence сохраняет изменение повторения. check выполняется под
+// блокировкой записи (см. SetEventResources) и может быть nil.
+func SaveEventOccurrence(cfg *model.Config, o *model.EventOccurrence, check func() error) error {
+	return writeLocked(cfg, check, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+			INSERT INTO event_occurrences (event_id, occurrence_date, cancelled, title, start_at, end_at, description)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (event_id, occurrence_date) DO UPDATE SET
+				cancelled = excluded.cancelled,
+				title = excluded.title,
+				start_at = excluded.start_at,
+				end_at = excluded.end_at,
+				description = excluded.description
+		`, o.EventID, o.OccurrenceDate, o.Cancelled, o.Title, o.StartAt, o.EndAt, o.Description)
+		return err
+	})
+}
+
+// ClaimEventReminder отмечает напоминание как отправленное и ставит
+// notifications в очередь в той же транзакции. Возвращает false, если оно
+// уже было отправлено раньше.
+func ClaimEventReminder(cfg *model.Config, eventID int, startsAt string, offsetMinutes int, notifications []model.OutboxMessage) (bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO event_reminders (event_id, starts_at, offset_minutes, sent_at)
+		VALUES (?, ?, ?, ?)
+	`, eventID, startsAt, offsetMinutes, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	// напоминание, отмеченное раньше, повторно не ставится в очередь
+	if affected == 0 {
+		return false, nil
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"encoding/json"
+	"time"
+
+	"backend/internal/model"
+)
+
+// ApplyImport применяет план импорта в одной транзакции. Удаление пользователя
+// отключает аккаунт, удаление проекта переносит его в архив.
+func ApplyImport(cfg *model.Config, plan *model.ImportPlan) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(time.RFC3339)
+
+	for _, change := range plan.Users {
+		u, err := encryptedUser(cfg, &change.User)
+		if err != nil {
+			return err
+		}
+		switch change.Action {
+		case model.ImportCreate:
+			_, err = tx.Exec(`
+				INSERT INTO users (TelegramID, FirstName, LastName, Username, PhotoURL, FullName,
+					DateOfBirthday, NumberOfPhone, Role, MayToOpen, IsActive)
+				VALUES (?, '', '', ?, '', ?, ?, ?, ?, ?, 1)
+			`, u.TelegramID, u.Username, u.FullName, u.DateOfBirthday, u.NumberOfPhone, u.Role, u.MayToOpen)
+		case model.ImportUpdate:
+			_, err = tx.Exec(`
+				UPDATE users
+				SET Username = ?, FullName = ?, DateOfBirthday = ?, NumberOfPhone = ?, Role = ?, MayToOpen = ?
+				WHERE TelegramID = ?
+			`, u.Username, u.FullName, u.DateOfBirthday, u.NumberOfPhone, u.Role, u.MayToOpen, u.TelegramID)
+		case model.ImportRemove:
+			_, err = tx.Exec(`
+				UPDATE users SET IsActive = 0, DeactivatedAt = ?, DeactivatedBy = 'import' WHERE TelegramID = ?
+			`, now, u.TelegramID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, change := range plan.Projects {
+		p := change.Project
+		members, err := json.Marshal(p.Members)
+		if err != nil {
+			return err
+		}
+
+		switch change.Action {
+		case model.ImportCreate:
+			_, err = tx.Exec(`
+				INSERT INTO projects (title, description, status, users, status_locked, archived, visibility)
+				VALUES (?, ?, ?, ?, 0, 0, ?)
+			`, p.Title, p.Description, p.Status, string(members), model.VisibilityPublic)
+		case model.ImportUpdate:
+			_, err = tx.Exec(`
+				UPDATE projects SET description = ?, users = ? WHERE id = ?
+			`, p.Description, string(members), p.ID)
+		case model.ImportRemove:
+			_, err = tx.Exec(`UPDATE projects SET archived = 1 WHERE id = ?`, p.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+const joinRequestColumns = `id, COALESCE(telegram_id, ''), COALESCE(username, ''), COALESCE(first_name, ''),
+	COALESCE(last_name, ''), COALESCE(photo_url, ''), COALESCE(full_name, ''), COALESCE(number_of_phone, ''),
+	COALESCE(motivation, ''), COALESCE(status, ''), COALESCE(role, ''), COALESCE(reject_reason, ''),
+	COALESCE(created_at, ''), COALESCE(reviewed_by, ''), COALESCE(reviewed_at, '')`
+
+func scanJoinRequest(row rowScanner) (model.JoinRequest, error) {
+	var j model.JoinRequest
+	err := row.Scan(
+		&j.ID,
+		&j.TelegramID,
+		&j.Username,
+		&j.FirstName,
+		&j.LastName,
+		&j.PhotoURL,
+		&j.FullName,
+		&j.NumberOfPhone,
+		&j.Motivation,
+		&j.Status,
+		&j.Role,
+		&j.RejectReason,
+		&j.CreatedAt,
+		&j.ReviewedBy,
+		&j.ReviewedAt,
+	)
+	return j, err
+}
+
+func GetJoinRequests(cfg *model.Config, status string) ([]model.JoinRequest, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+joinRequestColumns+`
+		FROM join_requests
+		WHERE ? = '' OR status = ?
+		ORDER BY id
+	`, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := make([]model.JoinRequest, 0)
+	for rows.Next() {
+		j, err := scanJoinRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		if j.NumberOfPhone, err = decryptField(cfg, j.NumberOfPhone); err != nil {
+			return nil, err
+		}
+		requests = append(requests, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func GetJoinRequestByID(cfg *model.Config, requestID int) (*model.JoinRequest, error) {
+	return getJoinRequest(cfg, `id = ?`, requestID)
+}
+
+func GetJoinRequestByTelegramID(cfg *model.Config, telegramID string) (*model.JoinRequest, error) {
+	return getJoinRequest(cfg, `telegram_id = ?`, telegramID)
+}
+
+func getJoinRequest(cfg *model.Config, where string, arg interface{}) (*model.JoinRequest, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	j, err := scanJoinRequest(db.QueryRow(`SELECT `+joinRequestColumns+` FROM join_requests WHERE `+where, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if j.NumberOfPhone, err = decryptField(cfg, j.NumberOfPhone); err != nil {
+		return nil, err
+	}
+
+	return &j, nil
+}
+
+// SaveJoinRequest создаёт заявку или заново открывает заявку того же
+// пользователя, сбрасывая прежнее решение. Уведомления notify(id) ставятся
+// в очередь в той же транзакции.
+func SaveJoinRequest(cfg *model.Config, j *model.JoinRequest, notify func(id int) []model.OutboxMessage) error {
+	phone, err := encryptField(cfg, j.NumberOfPhone)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO join_requests (telegram_id, username, first_name, last_name, photo_url,
+			full_name, number_of_phone, motivation, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (telegram_id) DO UPDATE SET
+			username = excluded.username,
+			first_name = excluded.first_name,
+			last_name = excluded.last_name,
+			photo_url = excluded.photo_url,
+			full_name = excluded.full_name,
+			number_of_phone = excluded.number_of_phone,
+			motivation = excluded.motivation,
+			status = excluded.status,
+			created_at = excluded.created_at,
+			role = NULL,
+			reject_reason = NULL,
+			reviewed_by = NULL,
+			reviewed_at = NULL
+	`,
+		j.TelegramID,
+		j.Username,
+		j.FirstName,
+		j.LastName,
+		j.PhotoURL,
+		j.FullName,
+		phone,
+		j.Motivation,
+		j.Status,
+		j.CreatedAt,
+	); err != nil {
+		return err
+	}
+
+	if notify != nil {
+		// при повторной заявке строка обновляется, и LastInsertId не годится
+		var id int
+		if err := tx.QueryRow(`SELECT id FROM join_requests WHERE telegram_id = ?`, j.TelegramID).Scan(&id); err != nil {
+			return err
+		}
+		if err := enqueueNotifications(tx, notify(id)); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ApproveJoinRequest создаёт пользователя по заявке, закрывает её и ставит
+// notifications в очередь в одной транзакции.
+func ApproveJoinRequest(cfg *model.Config, j *model.JoinRequest, user *model.UserProfile, notifications []model.OutboxMessage) error {
+	encrypted, err := encryptedUser(cfg, user)
+	if err != nil {
+		return err
+	}
+	user = &encrypted
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO users (TelegramID, FirstName, LastName, Username, PhotoURL, FullName,
+			DateOfBirthday, NumberOfPhone, Role, MayToOpen)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		user.TelegramID,
+		user.FirstName,
+		user.LastName,
+		user.Username,
+		user.PhotoURL,
+		user.FullName,
+		user.DateOfBirthday,
+		user.NumberOfPhone,
+		user.Role,
+		user.MayToOpen,
+	); err != nil {
+		return err
+	}
+
+	if err := reviewJoinRequest(tx, j); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func RejectJoinRequest(cfg *model.Config, j *model.JoinRequest, notifications []model.OutboxMessage) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := reviewJoinRequest(tx, j); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func reviewJoinRequest(tx *sql.Tx, j *model.JoinRequest) error {
+	j.ReviewedAt = time.Now().Format(time.RFC3339)
+	_, err := tx.Exec(`
+		UPDATE join_requests
+		SET status = ?, role = ?, reject_reason = ?, reviewed_by = ?, reviewed_at = ?
+		WHERE id = ?
+	`, j.Status, j.Role, j.RejectReason, j.ReviewedBy, j.ReviewedAt, j.ID)
+	return err
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+func GetMilestonesByProjectID(cfg *model.Config, projectID int) ([]model.Milestone, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, id_project, COALESCE(title, ''), COALESCE(description, ''), COALESCE(due_date, '')
+		FROM milestones
+		WHERE id_project = ?
+		ORDER BY due_date, id
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]model.Milestone, 0)
+	index := make(map[int]int)
+	for rows.Next() {
+		m := model.Milestone{TaskIDs: []int{}}
+		if err := rows.Scan(&m.ID, &m.IdProject, &m.Title, &m.Description, &m.DueDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[m.ID] = len(milestones)
+		milestones = append(milestones, m)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	linkRows, err := db.Query(`
+		SELECT mt.milestone_id, mt.task_id
+		FROM milestone_tasks mt
+		JOIN milestones m ON m.id = mt.milestone_id
+		WHERE m.id_project = ?
+		ORDER BY mt.task_id
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer linkRows.Close()
+
+	for linkRows.Next() {
+		var milestoneID, taskID int
+		if err := linkRows.Scan(&milestoneID, &taskID); err != nil {
+			return nil, err
+		}
+		if i, ok := index[milestoneID]; ok {
+			milestones[i].TaskIDs = append(milestones[i].TaskIDs, taskID)
+		}
+	}
+	if err := linkRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
+}
+
+func GetMilestoneByID(cfg *model.Config, milestoneID int) (*model.Milestone, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	m := &model.Milestone{TaskIDs: []int{}}
+	err = db.QueryRow(`
+		SELECT id, id_project, COALESCE(title, ''), COALESCE(description, ''), COALESCE(due_date, '')
+		FROM milestones
+		WHERE id = ?
+	`, milestoneID).Scan(&m.ID, &m.IdProject, &m.Title, &m.Description, &m.DueDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT task_id FROM milestone_tasks WHERE milestone_id = ? ORDER BY task_id`, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var taskID int
+		if err := rows.Scan(&taskID); err != nil {
+			return nil, err
+		}
+		m.TaskIDs = append(m.TaskIDs, taskID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func CreateMilestone(cfg *model.Config, m *model.Milestone) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO milestones (id_project, title, description, due_date, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, m.IdProject, m.Title, m.Description, m.DueDate, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := replaceMilestoneTasks(tx, int(id), m.TaskIDs); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func UpdateMilestone(cfg *model.Config, m *model.Milestone) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE milestones
+		SET title = ?, description = ?, due_date = ?
+		WHERE id = ?
+	`, m.Title, m.Description, m.DueDate, m.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceMilestoneTasks(tx, m.ID, m.TaskIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func DeleteMilestone(cfg *model.Config, milestoneID int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM milestone_tasks WHERE milestone_id = ?`, milestoneID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM milestones WHERE id = ?`, milestoneID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceMilestoneTasks(tx *sql.Tx, milestoneID int, taskIDs []int) error {
+	if _, err := tx.Exec(`DELETE FROM milestone_tasks WHERE milestone_id = ?`, milestoneID); err != nil {
+		return err
+	}
+	for _, taskID := range taskIDs {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO milestone_tasks (milestone_id, task_id) VALUES (?, ?)`,
+			milestoneID,
+			taskID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetTaskDependenciesByProjectID(cfg *model.Config, projectID int) ([]model.TaskDependency, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT d.task_id, d.depends_on
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE t.id_project = ?
+		ORDER BY d.task_id, d.depends_on
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependencies := make([]model.TaskDependency, 0)
+	for rows.Next() {
+		var d model.TaskDependency
+		if err := rows.Scan(&d.TaskID, &d.DependsOn); err != nil {
+			return nil, err
+		}
+		dependencies = append(dependencies, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
+
+func SetTaskDependencies(cfg *model.Config, taskID int, dependsOn []int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM task_dependencies WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	for _, dependency := range dependsOn {
+		if _, err := tx.Exec(
+			`INSERT OR IGNORE INTO task_dependencies (task_id, depends_on) VALUES (?, ?)`,
+			taskID,
+			dependency,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"backend/internal/model"
+)
+
+// GetNotificationSettings возвращает сохранённые настройки или nil, если
+// пользователь их не менял.
+func GetNotificationSettings(cfg *model.Config, telegramID string) (*model.NotificationSettings, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var (
+		settings   model.NotificationSettings
+		events     string
+		quietStart string
+		quietEnd   string
+		digest     model.DigestSettings
+	)
+	err = db.QueryRow(`
+		SELECT COALESCE(events, ''), COALESCE(quiet_start, ''), COALESCE(quiet_end, ''), COALESCE(timezone, ''), COALESCE(updated_at, ''),
+			COALESCE(digest_frequency, ''), COALESCE(digest_hour, 0), COALESCE(digest_weekday, '')
+		FROM notification_settings
+		WHERE telegram_id = ?
+	`, telegramID).Scan(
+		&events,
+		&quietStart,
+		&quietEnd,
+		&settings.Timezone,
+		&settings.UpdatedAt,
+		&digest.Frequency,
+		&digest.Hour,
+		&digest.Weekday,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	settings.Events = make(map[string]string)
+	if events != "" {
+		if err := json.Unmarshal([]byte(events), &settings.Events); err != nil {
+			return nil, err
+		}
+	}
+	if quietStart != "" && quietEnd != "" {
+		settings.QuietHours = &model.QuietHours{Start: quietStart, End: quietEnd}
+	}
+	if digest.Frequency != "" {
+		settings.Digest = &digest
+	}
+
+	return &settings, nil
+}
+
+func SaveNotificationSettings(cfg *model.Config, telegramID string, settings *model.NotificationSettings) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	events, err := json.Marshal(settings.Events)
+	if err != nil {
+		return err
+	}
+	var quietStart, quietEnd string
+	if settings.QuietHours != nil {
+		quietStart, quietEnd = settings.QuietHours.Start, settings.QuietHours.End
+	}
+	digest := model.DigestSettings{}
+	if settings.Digest != nil {
+		digest = *settings.Digest
+	}
+
+	settings.UpdatedAt = time.Now().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO notification_settings (
+			telegram_id, events, quiet_start, quiet_end, timezone, updated_at,
+			digest_frequency, digest_hour, digest_weekday
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(telegram_id) DO UPDATE SET
+			events = excluded.events,
+			quiet_start = excluded.quiet_start,
+			quiet_end = excluded.quiet_end,
+			timezone = excluded.timezone,
+			updated_at = excluded.updated_at,
+			digest_frequency = excluded.digest_frequency,
+			digest_hour = excluded.digest_hour,
+			digest_weekday = excluded.digest_weekday
+	`,
+		telegramID,
+		string(events),
+		quietStart,
+		quietEnd,
+		settings.Timezone,
+		settings.UpdatedAt,
+		digest.Frequency,
+		digest.Hour,
+		digest.Weekday,
+	)
+	return err
+}
+
+// GetDigestSubscribers возвращает Telegram ID пользователей, включивших сводку.
+func GetDigestSubscribers(cfg *model.Config) ([]string, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT telegram_id FROM notification_settings
+		WHERE COALESCE(digest_frequency, '') NOT IN ('', 'off')
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscribers := make([]string, 0)
+	for rows.Next() {
+		var telegramID string
+		if err := rows.Scan(&telegramID); err != nil {
+			return nil, err
+		}
+		subscribers = append(subscribers, telegramID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subscribers, nil
+}
+
+// DigestRecorded сообщает, собрана ли уже сводка пользователя за period.
+func DigestRecorded(cfg *model.Config, telegramID string, period string) (bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM digest_deliveries WHERE telegram_id = ? AND period = ?
+	`, telegramID, period).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RecordDigest в одной транзакции отмечает сводку за period и ставит её
+// сообщения в очередь. Возвращает false и ничего не ставит, если сводка за
+// этот период уже была отмечена.
+func RecordDigest(cfg *model.Config, telegramID string, period string, items int, messages []model.OutboxMessage) (bool, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO digest_deliveries (telegram_id, period, items, sent_at)
+		VALUES (?, ?, ?, ?)
+	`, telegramID, period, items, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	if err := enqueueNotifications(tx, messages); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetDigestDeliveries возвращает отметки о сводках пользователя, последние — первыми.
+func GetDigestDeliveries(cfg *model.Config, telegramID string) ([]model.DigestDelivery, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT period, COALESCE(items, 0), COALESCE(sent_at, '')
+		FROM digest_deliveries
+		WHERE telegram_id = ?
+		ORDER BY sent_at DESC
+	`, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]model.DigestDelivery, 0)
+	for rows.Next() {
+		var d model.DigestDelivery
+		if err := rows.Scan(&d.Period, &d.Items, &d.SentAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
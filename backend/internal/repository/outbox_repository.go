@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+const outboxColumns = `id,
+	COALESCE(chat_id, 0),
+	COALESCE(event_type, ''),
+	COALESCE(text, ''),
+	COALESCE(status, ''),
+	COALESCE(attempts, 0),
+	COALESCE(next_attempt_at, ''),
+	COALESCE(last_error, ''),
+	COALESCE(created_at, ''),
+	COALESCE(updated_at, ''),
+	COALESCE(sent_at, '')`
+
+func scanOutboxMessage(row rowScanner) (model.OutboxMessage, error) {
+	var m model.OutboxMessage
+	err := row.Scan(
+		&m.ID,
+		&m.ChatID,
+		&m.EventType,
+		&m.Text,
+		&m.Status,
+		&m.Attempts,
+		&m.NextAttemptAt,
+		&m.LastError,
+		&m.CreatedAt,
+		&m.UpdatedAt,
+		&m.SentAt,
+	)
+	return m, err
+}
+
+// enqueueNotifications записывает уведомления через ex — в той же
+// транзакции, что и изменение, о котором они сообщают.
+func enqueueNotifications(ex execer, messages []model.OutboxMessage) error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, m := range messages {
+		if m.ChatID == 0 || m.Text == "" {
+			continue
+		}
+		nextAttemptAt := m.NextAttemptAt
+		if nextAttemptAt == "" {
+			nextAttemptAt = now
+		}
+		if _, err := ex.Exec(`
+			INSERT INTO notification_outbox (chat_id, event_type, text, status, attempts, next_attempt_at, last_error, created_at, updated_at, sent_at)
+			VALUES (?, ?, ?, ?, 0, ?, '', ?, ?, '')
+		`, m.ChatID, m.EventType, m.Text, model.OutboxPending, nextAttemptAt, now, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnqueueNotifications ставит сообщения в очередь отправки. Пустой
+// NextAttemptAt — отправить как можно скорее.
+func EnqueueNotifications(cfg *model.Config, messages []model.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := enqueueNotifications(tx, messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimOutboxMessages забирает до limit сообщений, срок отправки которых
+// наступил, и помечает их sending до now+lease. Сообщения, чей обработчик
+// не отчитался до окончания аренды, снова становятся доступны.
+func ClaimOutboxMessages(cfg *model.Config, now time.Time, lease time.Duration, limit int) ([]model.OutboxMessage, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	nowStr := now.UTC().Format(time.RFC3339)
+	if _, err := tx.Exec(`
+		UPDATE notification_outbox SET status = ?, updated_at = ?
+		WHERE status = ? AND next_attempt_at <= ?
+	`, model.OutboxPending, nowStr, model.OutboxSending, nowStr); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+		SELECT `+outboxColumns+`
+		FROM notification_outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`, model.OutboxPending, nowStr, limit)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]model.OutboxMessage, 0)
+	for rows.Next() {
+		m, err := scanOutboxMessage(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	leaseUntil := now.Add(lease).UTC().Format(time.RFC3339)
+	claimed := make([]model.OutboxMessage, 0, len(due))
+	for _, m := range due {
+		result, err := tx.Exec(`
+			UPDATE notification_outbox SET status = ?, next_attempt_at = ?, updated_at = ?
+			WHERE id = ? AND status = ?
+		`, model.OutboxSending, leaseUntil, nowStr, m.ID, model.OutboxPending)
+		if err != nil {
+			return nil, err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return nil, err
+		} else if affected == 0 {
+			continue
+		}
+		m.Status = model.OutboxSending
+		claimed = append(claimed, m)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+func MarkOutboxSent(cfg *model.Config, id int, attempts int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = db.Exec(`
+		UPDATE notification_outbox SET status = ?, attempts = ?, last_error = '', sent_at = ?, updated_at = ?
+		WHERE id = ?
+	`, model.OutboxSent, attempts, now, now, id)
+	return err
+}
+
+// RescheduleOutboxMessage возвращает сообщение в очередь до nextAttemptAt.
+func RescheduleOutboxMessage(cfg *model.Config, id int, attempts int, nextAttemptAt time.Time, lastError string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		UPDATE notification_outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, model.OutboxPending, attempts, nextAttemptAt.UTC().Format(time.RFC3339), lastError, time.Now().UTC().Format(time.RFC3339), id)
+	return err
+}
+
+func MarkOutboxDead(cfg *model.Config, id int, attempts int, lastError string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		UPDATE notification_outbox SET status = ?, attempts = ?, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, model.OutboxDead, attempts, lastError, time.Now().UTC().Format(time.RFC3339), id)
+	return err
+}
+
+// DeleteSentOutboxMessages удаляет сообщения, отправленные раньше before.
+func DeleteSentOutboxMessages(cfg *model.Config, before time.Time) (int64, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	result, err := db.Exec(`
+		DELETE FROM notification_outbox WHERE status = ? AND sent_at != '' AND sent_at < ?
+	`, model.OutboxSent, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetOutboxMessages возвращает сообщения очереди, последние — первыми.
+// Пустой status — в любом состоянии, нулевой chatID — во все чаты, limit <= 0 —
+// без ограничения.
+func GetOutboxMessages(cfg *model.Config, status string, chatID int64, limit int) ([]model.OutboxMessage, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+outboxColumns+`
+		FROM notification_outbox
+		WHERE (? = '' OR status = ?) AND (? = 0 OR chat_id = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`, status, status, chatID, chatID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := make([]model.OutboxMessage, 0)
+	for rows.Next() {
+		m, err := scanOutboxMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// ReplayOutboxMessages возвращает в очередь недоставленные сообщения со
+// сбросом счётчика попыток: одно по id или все при id = 0.
+func ReplayOutboxMessages(cfg *model.Config, id int) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := db.Exec(`
+		UPDATE notification_outbox SET status = ?, attempts = 0, next_attempt_at = ?, last_error = '', updated_at = ?
+		WHERE status = ? AND (? = 0 OR id = ?)
+	`, model.OutboxPending, now, now, model.OutboxDead, id, id)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(affected), nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
+
+// GetTasksMentioning возвращает задачи, где пользователь указан автором или
+// проверяющим. names — его username (без @) и ФИО.
+func GetTasksMentioning(cfg *model.Config, names []string) ([]model.Task, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT ` + taskColumns + ` FROM tasks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		if mentionsAny(t.Author, names) || mentionsAny(t.ReviewedBy, names) {
+			tasks = append(tasks, t)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func GetAttendanceByUser(cfg *model.Config, telegramID string) ([]model.AttendanceRecord, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT event_id, COALESCE(occurrence_date, ''), telegram_id, COALESCE(rsvp, ''), COALESCE(rsvp_at, ''),
+			COALESCE(checked_in_at, ''), COALESCE(checked_in_by, '')
+		FROM event_attendance
+		WHERE telegram_id = ?
+		ORDER BY event_id, occurrence_date
+	`, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAttendanceRecords(rows)
+}
+
+func GetBirthdayNotifications(cfg *model.Config, telegramID string) ([]model.NotificationRecord, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT kind, date, COALESCE(sent_at, '')
+		FROM birthday_notifications
+		WHERE telegram_id = ?
+		ORDER BY date
+	`, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]model.NotificationRecord, 0)
+	for rows.Next() {
+		var record model.NotificationRecord
+		if err := rows.Scan(&record.Kind, &record.Date, &record.SentAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// EraseUser обезличивает пользователя в одной транзакции: очищает личные поля
+// в users, заменяет его имена в задачах, отметках и составах проектов на
+// псевдонимы и удаляет связанные с ним заявки и токены. Строки задач и
+// Telegram ID остаются, чтобы история и блокировка входа сохранились.
+func EraseUser(cfg *model.Config, e *model.UserErasure) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE users
+		SET FirstName = '', LastName = '', Username = ?, PhotoURL = '', FullName = ?,
+			DateOfBirthday = '', NumberOfPhone = '', IsActive = 0,
+			DeactivatedAt = COALESCE(NULLIF(DeactivatedAt, ''), ?), BirthdayOptOut = 1, PhoneVisibility = NULL
+		WHERE TelegramID = ?
+	`, e.Username, e.FullName, time.Now().Format(time.RFC3339), e.TelegramID); err != nil {
+		return err
+	}
+
+	oldUsername := strings.ToLower(e.OldUsername)
+	names := []string{oldUsername, e.OldFullName}
+
+	rows, err := tx.Query(`SELECT id, COALESCE(user, ''), COALESCE(author, ''), COALESCE(reviewed_by, ''), COALESCE(id_user, 0) FROM tasks`)
+	if err != nil {
+		return err
+	}
+	type taskNames struct {
+		id                       int
+		user, author, reviewedBy string
+	}
+	updates := make([]taskNames, 0)
+	for rows.Next() {
+		var (
+			t      taskNames
+			idUser string
+		)
+		if err := rows.Scan(&t.id, &t.user, &t.author, &t.reviewedBy, &idUser); err != nil {
+			rows.Close()
+			return err
+		}
+		changed := false
+		if idUser == e.TelegramID || (oldUsername != "" && mentionsAny(t.user, []string{oldUsername})) {
+			t.user = e.Username
+			changed = true
+		}
+		if mentionsAny(t.author, names) {
+			t.author = e.FullName
+			changed = true
+		}
+		if mentionsAny(t.reviewedBy, names) {
+			t.reviewedBy = e.FullName
+			changed = true
+		}
+		if changed {
+			updates = append(updates, t)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range updates {
+		if _, err := tx.Exec(
+			`UPDATE tasks SET user = ?, author = ?, reviewed_by = ? WHERE id = ?`,
+			t.user, t.author, t.reviewedBy, t.id,
+		); err != nil {
+			return err
+		}
+	}
+	e.TasksUpdated = len(updates)
+
+	if e.OldFullName != "" {
+		if _, err := tx.Exec(
+			`UPDATE event_attendance SET checked_in_by = ? WHERE checked_in_by = ?`,
+			e.FullName, e.OldFullName,
+		); err != nil {
+			return err
+		}
+	}
+
+	projectRows, err := tx.Query(`SELECT id, COALESCE(users, '') FROM projects`)
+	if err != nil {
+		return err
+	}
+	projectUpdates := make(map[int]string)
+	for projectRows.Next() {
+		var (
+			id  int
+			raw string
+		)
+		if err := projectRows.Scan(&id, &raw); err != nil {
+			projectRows.Close()
+			return err
+		}
+		if raw == "" {
+			continue
+		}
+		var members []model.ProjectMember
+		if err := json.Unmarshal([]byte(raw), &members); err != nil {
+			continue
+		}
+		changed := false
+		for i, member := range members {
+			if member.TelegramID == e.TelegramID || (oldUsername != "" && mentionsAny(member.Username, []string{oldUsername})) {
+				members[i].Username = e.Username
+				members[i].FullName = e.FullName
+				members[i].TelegramID = e.TelegramID
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		encoded, err := json.Marshal(members)
+		if err != nil {
+			projectRows.Close()
+			return err
+		}
+		projectUpdates[id] = string(encoded)
+	}
+	projectRows.Close()
+	if err := projectRows.Err(); err != nil {
+		return err
+	}
+
+	for id, raw := range projectUpdates {
+		if _, err := tx.Exec(`UPDATE projects SET users = ? WHERE id = ?`, raw, id); err != nil {
+			return err
+		}
+	}
+	e.ProjectsUpdated = len(projectUpdates)
+
+	for _, query := range []string{
+		`DELETE FROM profile_changes WHERE telegram_id = ?`,
+		`DELETE FROM join_requests WHERE telegram_id = ?`,
+		`DELETE FROM calendar_tokens WHERE telegram_id = ?`,
+		`DELETE FROM birthday_notifications WHERE telegram_id = ?`,
+		`DELETE FROM user_skills WHERE telegram_id = ?`,
+		`DELETE FROM skill_endorsements WHERE telegram_id = ?`,
+		`DELETE FROM user_absences WHERE telegram_id = ?`,
+		`UPDATE user_absences SET delegate_id = '' WHERE delegate_id = ?`,
+		`DELETE FROM notification_settings WHERE telegram_id = ?`,
+		`DELETE FROM notification_outbox WHERE chat_id = ?`,
+		`DELETE FROM digest_deliveries WHERE telegram_id = ?`,
+	} {
+		if _, err := tx.Exec(query, e.TelegramID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// mentionsAny сравнивает значение с именами без учёта регистра и ведущего @.
+func mentionsAny(value string, names []string) bool {
+	value = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "@"))
+	if value == "" {
+		return false
+	}
+	for _, name := range names {
+		if name != "" && value == strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"backend/internal/model"
+)
+
+// selfEditableColumns сопоставляет поля PATCH /me колонкам таблицы users.
+var selfEditableColumns = map[string]string{
+	"full_name":        "FullName",
+	"date_of_birthday": "DateOfBirthday",
+	"number_of_phone":  "NumberOfPhone",
+}
+
+// encryptedColumns — колонки users, которые хранятся зашифрованными.
+var encryptedColumns = map[string]bool{
+	"DateOfBirthday": true,
+	"NumberOfPhone":  true,
+}
+
+// UpdateUserField меняет одно поле профиля из списка selfEditableColumns.
+func UpdateUserField(cfg *model.Config, telegramID string, field string, value string) error {
+	column, ok := selfEditableColumns[field]
+	if !ok {
+		return fmt.Errorf("field %s is not editable", field)
+	}
+	if encryptedColumns[column] {
+		var err error
+		if value, err = encryptField(cfg, value); err != nil {
+			return err
+		}
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE users SET `+column+` = ? WHERE TelegramID = ?`, value, telegramID)
+	return err
+}
+
+const profileChangeColumns = `id, COALESCE(telegram_id, ''), COALESCE(field, ''), COALESCE(old_value, ''),
+	COALESCE(value, ''), COALESCE(status, ''), COALESCE(created_at, ''),
+	COALESCE(reviewed_by, ''), COALESCE(reviewed_at, '')`
+
+func scanProfileChange(row rowScanner) (model.ProfileChange, error) {
+	var c model.ProfileChange
+	err := row.Scan(
+		&c.ID,
+		&c.TelegramID,
+		&c.Field,
+		&c.OldValue,
+		&c.Value,
+		&c.Status,
+		&c.CreatedAt,
+		&c.ReviewedBy,
+		&c.ReviewedAt,
+	)
+	return c, err
+}
+
+// GetProfileChanges возвращает заявки с указанным статусом (все — при пустом
+// статусе) и, если задан telegramID, только заявки этого пользователя.
+func GetProfileChanges(cfg *model.Config, telegramID string, status string) ([]model.ProfileChange, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+profileChangeColumns+`
+		FROM profile_changes
+		WHERE (? = '' OR telegram_id = ?) AND (? = '' OR status = ?)
+		ORDER BY id
+	`, telegramID, telegramID, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]model.ProfileChange, 0)
+	for rows.Next() {
+		c, err := scanProfileChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func GetProfileChangeByID(cfg *model.Config, changeID int) (*model.ProfileChange, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	c, err := scanProfileChange(db.QueryRow(`SELECT `+profileChangeColumns+` FROM profile_changes WHERE id = ?`, changeID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// CreateProfileChange создаёт заявку; предыдущая незакрытая заявка на то же
+// поле заменяется новой. Уведомления notify(id) ставятся в очередь в той же
+// транзакции.
+func CreateProfileChange(cfg *model.Config, c *model.ProfileChange, notify func(id int) []model.OutboxMessage) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`DELETE FROM profile_changes WHERE telegram_id = ? AND field = ? AND status = ?`,
+		c.TelegramID,
+		c.Field,
+		model.ProfileChangePending,
+	); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO profile_changes (telegram_id, field, old_value, value, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, c.TelegramID, c.Field, c.OldValue, c.Value, c.Status, c.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if notify != nil {
+		if err := enqueueNotifications(tx, notify(int(id))); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// ReviewProfileChange закрывает заявку и при одобрении применяет изменение
+// к профилю в той же транзакции; там же в очередь ставятся notifications.
+func ReviewProfileChange(cfg *model.Config, c *model.ProfileChange, notifications []model.OutboxMessage) error {
+	column, ok := selfEditableColumns[c.Field]
+	if !ok {
+		return fmt.Errorf("field %s is not editable", c.Field)
+	}
+
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if c.Status == model.ProfileChangeApproved {
+		value := c.Value
+		if encryptedColumns[column] {
+			if value, err = encryptField(cfg, value); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(`UPDATE users SET `+column+` = ? WHERE TelegramID = ?`, value, c.TelegramID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE profile_changes SET status = ?, reviewed_by = ?, reviewed_at = ? WHERE id = ?
+	`, c.Status, c.ReviewedBy, time.Now().Format(time.RFC3339), c.ID); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -3,11 +3,14 @@ package repository
 import "backend/internal/model"
 
 type ProjectRow struct {
-	ID          int
-	Title       string
-	Description string
-	Status      string
-	RawUsers    string
+	ID           int
+	Title        string
+	Description  string
+	Status       string
+	RawUsers     string
+	StatusLocked bool
+	Archived     bool
+	Visibility   string
 }
 
 func GetProjects(cfg *model.Config) ([]ProjectRow, error) {
@@ -17,7 +20,12 @@ func GetProjects(cfg *model.Config) ([]ProjectRow, error) {
 	}
 	defer db.Close()
 
-	rows, err := db.Query("SELECT id, title, description, status, users FROM projects ORDER BY id")
+	rows, err := db.Query(`
+		SELECT id, title, description, status, users, COALESCE(status_locked, 0),
+			COALESCE(archived, 0), COALESCE(visibility, 'public')
+		FROM projects
+		ORDER BY id
+	`)
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +34,16 @@ func GetProjects(cfg *model.Config) ([]ProjectRow, error) {
 	projects := make([]ProjectRow, 0)
 	for rows.Next() {
 		var row ProjectRow
-		if err := rows.Scan(&row.ID, &row.Title, &row.Description, &row.Status, &row.RawUsers); err != nil {
+		if err := rows.Scan(
+			&row.ID,
+			&row.Title,
+			&row.Description,
+			&row.Status,
+			&row.RawUsers,
+			&row.StatusLocked,
+			&row.Archived,
+			&row.Visibility,
+		); err != nil {
 			return nil, err
 		}
 		projects = append(projects, row)
@@ -50,14 +67,61 @@ func UpdateProjectMembers(cfg *model.Config, projectID int, usersJSON string) er
 	return err
 }
 
-func UpdateProjectStatus(cfg *model.Config, projectID int, status string) error {
+// UpdateProjectStatus меняет статус проекта и ставит notifications в очередь
+// в той же транзакции.
+func UpdateProjectStatus(cfg *model.Config, projectID int, status string, notifications []model.OutboxMessage) error {
 	db, err := openDB(cfg)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	_, err = db.Exec(`UPDATE projects SET status = ? WHERE id = ?`, status, projectID)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE projects SET status = ? WHERE id = ?`, status, projectID); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func UpdateProjectStatusLock(cfg *model.Config, projectID int, locked bool) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE projects SET status_locked = ? WHERE id = ?`, locked, projectID)
+	return err
+}
+
+func UpdateProjectArchived(cfg *model.Config, projectID int, archived bool) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE projects SET archived = ? WHERE id = ?`, archived, projectID)
+	return err
+}
+
+func UpdateProjectVisibility(cfg *model.Config, projectID int, visibility string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE projects SET visibility = ? WHERE id = ?`, visibility, projectID)
 	return err
 }
 
@@ -88,3 +152,71 @@ func GetTaskStatusesByProjectID(cfg *model.Config, projectID int) ([]string, err
 
 	return statuses, nil
 }
+
+func CreateProject(cfg *model.Config, row *ProjectRow) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	return insertProject(db, row)
+}
+
+// CreateProjectWithTasks создаёт проект с задачами в одной транзакции: при
+// ошибке не остаётся ни проекта, ни части задач. notify собирает
+// уведомления по i-й задаче после того, как ей присвоен ID.
+func CreateProjectWithTasks(cfg *model.Config, row *ProjectRow, tasks []model.Task, notify func(i int, task *model.Task) []model.OutboxMessage) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	projectID, err := insertProject(tx, row)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range tasks {
+		tasks[i].IdProject = projectID
+		id, err := insertTask(tx, &tasks[i])
+		if err != nil {
+			return 0, err
+		}
+		tasks[i].ID = id
+		if notify != nil {
+			if err := enqueueNotifications(tx, notify(i, &tasks[i])); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return projectID, nil
+}
+
+func insertProject(ex execer, row *ProjectRow) (int, error) {
+	result, err := ex.Exec(`
+		INSERT INTO projects (title, description, status, users, status_locked, archived, visibility)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, row.Title, row.Description, row.Status, row.RawUsers, row.StatusLocked, row.Archived, row.Visibility)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
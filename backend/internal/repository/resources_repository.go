@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"backend/internal/model"
+)
+
+func GetResources(cfg *model.Config) ([]model.Resource, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, COALESCE(name, ''), COALESCE(kind, ''), COALESCE(description, '')
+		FROM resources
+		ORDER BY kind, name, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resources := make([]model.Resource, 0)
+	for rows.Next() {
+		var r model.Resource
+		if err := rows.Scan(&r.ID, &r.Name, &r.Kind, &r.Description); err != nil {
+			return nil, err
+		}
+		resources = append(resources, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+func GetResourceByID(cfg *model.Config, resourceID int) (*model.Resource, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	r := &model.Resource{}
+	err = db.QueryRow(`
+		SELECT id, COALESCE(name, ''), COALESCE(kind, ''), COALESCE(description, '')
+		FROM resources
+		WHERE id = ?
+	`, resourceID).Scan(&r.ID, &r.Name, &r.Kind, &r.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func CreateResource(cfg *model.Config, r *model.Resource) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	result, err := db.Exec(`
+		INSERT INTO resources (name, kind, description, created_at)
+		VALUES (?, ?, ?, ?)
+	`, r.Name, r.Kind, r.Description, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func UpdateResource(cfg *model.Config, r *model.Resource) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		UPDATE resources SET name = ?, kind = ?, description = ? WHERE id = ?
+	`, r.Name, r.Kind, r.Description, r.ID)
+	return err
+}
+
+func DeleteResource(cfg *model.Config, resourceID int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM event_resources WHERE resource_id = ?`, resourceID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM resources WHERE id = ?`, resourceID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func GetEventResourceIDs(cfg *model.Config, eventID int) ([]int, error) {
+	return queryIDs(cfg, `SELECT resource_id FROM event_resources WHERE event_id = ? ORDER BY resource_id`, eventID)
+}
+
+func GetResourceEventIDs(cfg *model.Config, resourceID int) ([]int, error) {
+	return queryIDs(cfg, `SELECT event_id FROM event_resources WHERE resource_id = ? ORDER BY event_id`, resourceID)
+}
+
+func queryIDs(cfg *model.Config, query string, args ...interface{}) ([]int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// SetEventResources заменяет брони события. check — проверка конфликтов:
+// она выполняется под блокировкой записи, так что два бронирования одного
+// ресурса не могут пройти её одновременно.
+func SetEventResources(cfg *model.Config, eventID int, resourceIDs []int, check func() error) error {
+	return writeLocked(cfg, check, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM event_resources WHERE event_id = ?`, eventID); err != nil {
+			return err
+		}
+		for _, resourceID := range resourceIDs {
+			if _, err := tx.Exec(
+				`INSERT OR IGNORE INTO event_resources (event_id, resource_id) VALUES (?, ?)`,
+				eventID,
+				resourceID,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+// GetSelfDeclaredSkills возвращает заявленные навыки по Telegram ID. Пустой
+// telegramID — навыки всех пользователей.
+func GetSelfDeclaredSkills(cfg *model.Config, telegramID string) (map[string][]string, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT telegram_id, skill FROM user_skills
+		WHERE ? = '' OR telegram_id = ?
+		ORDER BY telegram_id, skill
+	`, telegramID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skills := make(map[string][]string)
+	for rows.Next() {
+		var id, skill string
+		if err := rows.Scan(&id, &skill); err != nil {
+			return nil, err
+		}
+		skills[id] = append(skills[id], skill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return skills, nil
+}
+
+// GetSkillEndorsements возвращает подтверждения навыков. Пустой telegramID —
+// подтверждения всех пользователей.
+func GetSkillEndorsements(cfg *model.Config, telegramID string) ([]model.SkillEndorsement, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT telegram_id, skill, endorsed_by, COALESCE(endorsed_at, '')
+		FROM skill_endorsements
+		WHERE ? = '' OR telegram_id = ?
+		ORDER BY telegram_id, skill, endorsed_at
+	`, telegramID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endorsements := make([]model.SkillEndorsement, 0)
+	for rows.Next() {
+		var e model.SkillEndorsement
+		if err := rows.Scan(&e.TelegramID, &e.Skill, &e.EndorsedBy, &e.EndorsedAt); err != nil {
+			return nil, err
+		}
+		endorsements = append(endorsements, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return endorsements, nil
+}
+
+// ReplaceSelfDeclaredSkills заменяет заявленные пользователем навыки.
+// Подтверждения руководителей не трогаются.
+func ReplaceSelfDeclaredSkills(cfg *model.Config, telegramID string, skills []string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_skills WHERE telegram_id = ?`, telegramID); err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	for _, skill := range skills {
+		if _, err := tx.Exec(
+			`INSERT INTO user_skills (telegram_id, skill, created_at) VALUES (?, ?, ?)`,
+			telegramID, skill, now,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func AddSkillEndorsement(cfg *model.Config, telegramID string, skill string, endorsedBy string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		INSERT OR IGNORE INTO skill_endorsements (telegram_id, skill, endorsed_by, endorsed_at)
+		VALUES (?, ?, ?, ?)
+	`, telegramID, skill, endorsedBy, time.Now().Format(time.RFC3339))
+	return err
+}
+
+func DeleteSkillEndorsement(cfg *model.Config, telegramID string, skill string, endorsedBy string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(
+		`DELETE FROM skill_endorsements WHERE telegram_id = ? AND skill = ? AND endorsed_by = ?`,
+		telegramID, skill, endorsedBy,
+	)
+	return err
+}
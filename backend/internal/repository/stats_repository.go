@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+
+	"backend/internal/model"
+)
+
+const (
+	taskDoneStatus     = "Выполнена"
+	taskInReviewStatus = "На проверке"
+)
+
+// GetProjectStats считает агрегаты по задачам проекта средствами SQL.
+// HealthScore здесь не заполняется — его собирает сервисный слой.
+func GetProjectStats(cfg *model.Config, projectID int) (*model.ProjectStats, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stats := &model.ProjectStats{
+		ProjectID: projectID,
+		ByStatus:  make(map[string]int),
+		Members:   make([]model.MemberStats, 0),
+	}
+
+	rows, err := db.Query(`
+		SELECT COALESCE(status, ''), COUNT(*)
+		FROM tasks
+		WHERE id_project = ?
+		GROUP BY COALESCE(status, '')
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats.ByStatus[status] = count
+		stats.Total += count
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	var (
+		avgHours    sql.NullFloat64
+		reviews     sql.NullInt64
+		rejections  sql.NullInt64
+		sinceUpdate sql.NullFloat64
+		overdue     sql.NullInt64
+	)
+	err = db.QueryRow(`
+		SELECT
+			SUM(CASE WHEN COALESCE(deadline, '') != '' AND deadline < date('now') AND COALESCE(status, '') != ? THEN 1 ELSE 0 END),
+			AVG(CASE WHEN status = ? AND COALESCE(created_at, '') != '' AND COALESCE(reviewed_at, '') != ''
+				THEN (julianday(reviewed_at) - julianday(created_at)) * 24 END),
+			SUM(COALESCE(review_count, 0)),
+			SUM(COALESCE(rejection_count, 0)),
+			julianday('now') - MAX(julianday(COALESCE(NULLIF(updated_at, ''), NULLIF(created_at, ''))))
+		FROM tasks
+		WHERE id_project = ?
+	`, taskDoneStatus, taskDoneStatus, projectID).Scan(&overdue, &avgHours, &reviews, &rejections, &sinceUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Overdue = int(overdue.Int64)
+	stats.AvgHoursToApproval = avgHours.Float64
+	// у задач, созданных до появления created_at/updated_at, даты нет —
+	// давность обновления неизвестна, а не нулевая
+	if sinceUpdate.Valid {
+		stats.DaysSinceLastUpdate = &sinceUpdate.Float64
+	}
+	if stats.Total > 0 {
+		stats.PercentComplete = float64(stats.ByStatus[taskDoneStatus]) * 100 / float64(stats.Total)
+	}
+	if reviews.Int64 > 0 {
+		stats.ReviewRejectionRate = float64(rejections.Int64) / float64(reviews.Int64)
+	}
+
+	memberRows, err := db.Query(`
+		SELECT
+			COALESCE(user, ''),
+			COUNT(*),
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN COALESCE(deadline, '') != '' AND deadline < date('now') AND COALESCE(status, '') != ? THEN 1 ELSE 0 END)
+		FROM tasks
+		WHERE id_project = ?
+		GROUP BY COALESCE(user, '')
+		ORDER BY COUNT(*) DESC
+	`, taskDoneStatus, taskInReviewStatus, taskDoneStatus, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+
+	for memberRows.Next() {
+		var m model.MemberStats
+		if err := memberRows.Scan(&m.User, &m.Total, &m.Done, &m.InReview, &m.Overdue); err != nil {
+			return nil, err
+		}
+		stats.Members = append(stats.Members, m)
+	}
+	if err := memberRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"time"
+
+	"backend/internal/model"
+)
+
+const taskHistoryColumns = `id,
+	COALESCE(task_id, 0),
+	COALESCE(action, ''),
+	COALESCE(actor_id, ''),
+	COALESCE(on_behalf_of, ''),
+	COALESCE(details, ''),
+	COALESCE(created_at, '')`
+
+func scanTaskHistoryEntry(row rowScanner) (model.TaskHistoryEntry, error) {
+	var e model.TaskHistoryEntry
+	err := row.Scan(&e.ID, &e.TaskID, &e.Action, &e.ActorID, &e.OnBehalfOfID, &e.Details, &e.CreatedAt)
+	return e, err
+}
+
+func AddTaskHistory(cfg *model.Config, e *model.TaskHistoryEntry) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	e.CreatedAt = time.Now().Format(time.RFC3339)
+	result, err := db.Exec(`
+		INSERT INTO task_history (task_id, action, actor_id, on_behalf_of, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.TaskID, e.Action, e.ActorID, e.OnBehalfOfID, e.Details, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	e.ID = int(id)
+	return nil
+}
+
+// GetTaskHistory возвращает историю задачи. taskID = 0 — записи всех задач,
+// где участник telegramID действовал сам или за него действовал заместитель.
+func GetTaskHistory(cfg *model.Config, taskID int, telegramID string) ([]model.TaskHistoryEntry, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+taskHistoryColumns+`
+		FROM task_history
+		WHERE (? != 0 AND task_id = ?)
+			OR (? != '' AND (actor_id = ? OR on_behalf_of = ?))
+		ORDER BY id
+	`, taskID, taskID, telegramID, telegramID, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]model.TaskHistoryEntry, 0)
+	for rows.Next() {
+		e, err := scanTaskHistoryEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -2,19 +2,91 @@ package repository
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
 	"backend/internal/model"
 )
 
-func CreateTask(cfg *model.Config, task *model.Task) (int, error) {
+const taskColumns = `id,
+	COALESCE(description, ''),
+	deadline,
+	COALESCE(status, ''),
+	COALESCE(completion_message, ''),
+	COALESCE(review_message, ''),
+	COALESCE(reviewed_by, ''),
+	COALESCE(reviewed_at, ''),
+	COALESCE(user, ''),
+	COALESCE(title, ''),
+	COALESCE(author, ''),
+	COALESCE(id_project, 0),
+	COALESCE(id_user, 0),
+	COALESCE(start_date, ''),
+	COALESCE(created_at, ''),
+	COALESCE(estimate_hours, 0)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTask(row rowScanner) (model.Task, error) {
+	var t model.Task
+	err := row.Scan(
+		&t.ID,
+		&t.Description,
+		&t.Deadline,
+		&t.Status,
+		&t.CompletionMessage,
+		&t.ReviewMessage,
+		&t.ReviewedBy,
+		&t.ReviewedAt,
+		&t.User,
+		&t.Title,
+		&t.Author,
+		&t.IdProject,
+		&t.IdUser,
+		&t.StartDate,
+		&t.CreatedAt,
+		&t.EstimateHours,
+	)
+	return t, err
+}
+
+// CreateTask создаёт задачу и в той же транзакции ставит в очередь
+// уведомления, которые notify собирает по ID новой задачи.
+func CreateTask(cfg *model.Config, task *model.Task, notify func(id int) []model.OutboxMessage) (int, error) {
 	db, err := openDB(cfg)
 	if err != nil {
 		return 0, err
 	}
 	defer db.Close()
 
-	result, err := db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	id, err := insertTask(tx, task)
+	if err != nil {
+		return 0, err
+	}
+
+	if notify != nil {
+		if err := enqueueNotifications(tx, notify(id)); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func insertTask(ex execer, task *model.Task) (int, error) {
+	now := time.Now().Format(time.RFC3339)
+	result, err := ex.Exec(`
 		INSERT INTO tasks (
 			description,
 			deadline,
@@ -27,8 +99,12 @@ func CreateTask(cfg *model.Config, task *model.Task) (int, error) {
 			user,
 			title,
 			author,
-			id_project
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id_project,
+			start_date,
+			created_at,
+			updated_at,
+			estimate_hours
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		task.Description,
 		task.Deadline,
@@ -42,6 +118,10 @@ func CreateTask(cfg *model.Config, task *model.Task) (int, error) {
 		task.Title,
 		task.Author,
 		task.IdProject,
+		task.StartDate,
+		now,
+		now,
+		task.EstimateHours,
 	)
 	if err != nil {
 		return 0, err
@@ -63,12 +143,7 @@ func GetTasksByProjectID(cfg *model.Config, projectID int) ([]model.Task, error)
 	defer db.Close()
 
 	rows, err := db.Query(`
-		SELECT id, description, deadline, status,
-			COALESCE(completion_message, ''),
-			COALESCE(review_message, ''),
-			COALESCE(reviewed_by, ''),
-			COALESCE(reviewed_at, ''),
-			user, title, author, id_project, COALESCE(id_user, 0)
+		SELECT `+taskColumns+`
 		FROM tasks
 		WHERE id_project = ?
 		ORDER BY id DESC
@@ -80,22 +155,7 @@ func GetTasksByProjectID(cfg *model.Config, projectID int) ([]model.Task, error)
 
 	var tasks []model.Task
 	for rows.Next() {
-		var t model.Task
-		err := rows.Scan(
-			&t.ID,
-			&t.Description,
-			&t.Deadline,
-			&t.Status,
-			&t.CompletionMessage,
-			&t.ReviewMessage,
-			&t.ReviewedBy,
-			&t.ReviewedAt,
-			&t.User,
-			&t.Title,
-			&t.Author,
-			&t.IdProject,
-			&t.IdUser,
-		)
+		t, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -118,15 +178,18 @@ func UpdateTask(cfg *model.Config, task *model.Task) error {
 
 	_, err = db.Exec(`
 		UPDATE tasks
-		SET title = ?, description = ?, deadline = ?, status = ?, user = ?, id_user = ?
+		SET title = ?, description = ?, start_date = ?, deadline = ?, status = ?, user = ?, id_user = ?, estimate_hours = ?, updated_at = ?
 		WHERE id = ?
 	`,
 		task.Title,
 		task.Description,
+		task.StartDate,
 		task.Deadline,
 		task.Status,
 		task.User,
 		task.IdUser,
+		task.EstimateHours,
+		time.Now().Format(time.RFC3339),
 		task.ID,
 	)
 	return err
@@ -139,43 +202,89 @@ func DeleteTask(cfg *model.Config, taskID int) error {
 	}
 	defer db.Close()
 
-	_, err = db.Exec(`DELETE FROM tasks WHERE id = ?`, taskID)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM milestone_tasks WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM task_dependencies WHERE task_id = ? OR depends_on = ?`, taskID, taskID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM tasks WHERE id = ?`, taskID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func SubmitTaskCompletion(cfg *model.Config, taskID int, message string) error {
+// SubmitTaskCompletion сохраняет решение и ставит уведомления в очередь
+// в одной транзакции.
+func SubmitTaskCompletion(cfg *model.Config, taskID int, message string, notifications []model.OutboxMessage) error {
 	db, err := openDB(cfg)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	_, err = db.Exec(`
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
 		UPDATE tasks
-		SET status = ?, completion_message = ?, review_message = '', reviewed_by = '', reviewed_at = ''
+		SET status = ?, completion_message = ?, review_message = '', reviewed_by = '', reviewed_at = '', updated_at = ?
 		WHERE id = ?
-	`, "На проверке", message, taskID)
-	return err
+	`, "На проверке", message, time.Now().Format(time.RFC3339), taskID); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer string, message string) error {
+// ReviewTaskCompletion сохраняет решение проверяющего и ставит уведомления
+// в очередь в одной транзакции.
+func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer string, message string, notifications []model.OutboxMessage) error {
 	db, err := openDB(cfg)
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	status := "Отклонена"
+	rejected := 1
 	if approved {
 		status = "Выполнена"
+		rejected = 0
 	}
 
-	_, err = db.Exec(`
+	now := time.Now().Format(time.RFC3339)
+	if _, err := tx.Exec(`
 		UPDATE tasks
-		SET status = ?, review_message = ?, reviewed_by = ?, reviewed_at = ?
+		SET status = ?, review_message = ?, reviewed_by = ?, reviewed_at = ?, updated_at = ?,
+			review_count = COALESCE(review_count, 0) + 1,
+			rejection_count = COALESCE(rejection_count, 0) + ?
 		WHERE id = ?
-	`, status, message, reviewer, time.Now().Format(time.RFC3339), taskID)
-	return err
+	`, status, message, reviewer, now, now, rejected, taskID); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func GetTaskByID(cfg *model.Config, taskID int) (*model.Task, error) {
@@ -186,32 +295,13 @@ func GetTaskByID(cfg *model.Config, taskID int) (*model.Task, error) {
 	defer db.Close()
 
 	row := db.QueryRow(`
-		SELECT id, description, deadline, status,
-			COALESCE(completion_message, ''),
-			COALESCE(review_message, ''),
-			COALESCE(reviewed_by, ''),
-			COALESCE(reviewed_at, ''),
-			user, title, author, id_project, COALESCE(id_user, 0)
+		SELECT `+taskColumns+`
 		FROM tasks
 		WHERE id = ?
 	`, taskID)
 
-	var t model.Task
-	if err := row.Scan(
-		&t.ID,
-		&t.Description,
-		&t.Deadline,
-		&t.Status,
-		&t.CompletionMessage,
-		&t.ReviewMessage,
-		&t.ReviewedBy,
-		&t.ReviewedAt,
-		&t.User,
-		&t.Title,
-		&t.Author,
-		&t.IdProject,
-		&t.IdUser,
-	); err != nil {
+	t, err := scanTask(row)
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -220,3 +310,81 @@ func GetTaskByID(cfg *model.Config, taskID int) (*model.Task, error) {
 
 	return &t, nil
 }
+
+// GetTasksByAssignee возвращает задачи, назначенные пользователю по
+// Telegram ID или по username (старые задачи хранят только username).
+func GetTasksByAssignee(cfg *model.Config, telegramID int64, username string) ([]model.Task, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE (? != 0 AND id_user = ?)
+			OR (? != '' AND lower(trim(ltrim(user, '@'))) = ?)
+		ORDER BY deadline, id
+	`, telegramID, telegramID, username, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// likeEscaper экранирует символы шаблона LIKE, чтобы поисковая строка
+// совпадала буквально.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchTasks ищет задачи по подстроке в названии или описании.
+func SearchTasks(cfg *model.Config, term string) ([]model.Task, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	pattern := "%" + likeEscaper.Replace(strings.ToLower(term)) + "%"
+	rows, err := db.Query(`
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE lower(COALESCE(title, '')) LIKE ? ESCAPE '\'
+			OR lower(COALESCE(description, '')) LIKE ? ESCAPE '\'
+		ORDER BY id DESC
+	`, pattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]model.Task, 0)
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"database/sql"
+
+	"backend/internal/model"
+)
+
+type TemplateRow struct {
+	ID          int
+	Title       string
+	Description string
+	RawTasks    string
+	CreatedBy   string
+	CreatedAt   string
+}
+
+func CreateTemplate(cfg *model.Config, row *TemplateRow) (int, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	result, err := db.Exec(`
+		INSERT INTO project_templates (title, description, tasks, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, row.Title, row.Description, row.RawTasks, row.CreatedBy, row.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+func GetTemplates(cfg *model.Config) ([]TemplateRow, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(tasks, ''),
+			COALESCE(created_by, ''), COALESCE(created_at, '')
+		FROM project_templates
+		ORDER BY title
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]TemplateRow, 0)
+	for rows.Next() {
+		var row TemplateRow
+		if err := rows.Scan(&row.ID, &row.Title, &row.Description, &row.RawTasks, &row.CreatedBy, &row.CreatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func GetTemplateByID(cfg *model.Config, templateID int) (*TemplateRow, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var row TemplateRow
+	err = db.QueryRow(`
+		SELECT id, COALESCE(title, ''), COALESCE(description, ''), COALESCE(tasks, ''),
+			COALESCE(created_by, ''), COALESCE(created_at, '')
+		FROM project_templates
+		WHERE id = ?
+	`, templateID).Scan(&row.ID, &row.Title, &row.Description, &row.RawTasks, &row.CreatedBy, &row.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &row, nil
+}
+
+func DeleteTemplate(cfg *model.Config, templateID int) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`DELETE FROM project_templates WHERE id = ?`, templateID)
+	return err
+}
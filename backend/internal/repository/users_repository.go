@@ -1,6 +1,11 @@
 package repository
 
-import "backend/internal/model"
+import (
+	"strings"
+	"time"
+
+	"backend/internal/model"
+)
 
 func GetUsers(cfg *model.Config) ([]model.UserProfile, error) {
 	db, err := openDB(cfg)
@@ -10,7 +15,7 @@ func GetUsers(cfg *model.Config) ([]model.UserProfile, error) {
 	defer db.Close()
 
 	rows, err := db.Query(`
-		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen
+		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, COALESCE(IsActive, 1)
 		FROM users
 		ORDER BY FullName
 	`)
@@ -33,9 +38,13 @@ func GetUsers(cfg *model.Config) ([]model.UserProfile, error) {
 			&u.NumberOfPhone,
 			&u.Role,
 			&u.MayToOpen,
+			&u.Active,
 		); err != nil {
 			return nil, err
 		}
+		if err := decryptUser(cfg, &u); err != nil {
+			return nil, err
+		}
 		users = append(users, u)
 	}
 
@@ -55,7 +64,7 @@ func GetUserByTelegramID(cfg *model.Config, telegramID string) (*model.UserProfi
 
 	u := &model.UserProfile{}
 	err = db.QueryRow(`
-		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen
+		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, COALESCE(IsActive, 1)
 		FROM users
 		WHERE TelegramID = ?
 	`, telegramID).Scan(
@@ -69,10 +78,14 @@ func GetUserByTelegramID(cfg *model.Config, telegramID string) (*model.UserProfi
 		&u.NumberOfPhone,
 		&u.Role,
 		&u.MayToOpen,
+		&u.Active,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := decryptUser(cfg, u); err != nil {
+		return nil, err
+	}
 
 	return u, nil
 }
@@ -86,7 +99,7 @@ func GetUserByUsername(cfg *model.Config, normalized string, normalizedWithAt st
 
 	u := &model.UserProfile{}
 	err = db.QueryRow(`
-		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen
+		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, COALESCE(IsActive, 1)
 		FROM users
 		WHERE lower(trim(Username)) = ? OR lower(trim(Username)) = ?
 	`, normalized, normalizedWithAt).Scan(
@@ -100,15 +113,21 @@ func GetUserByUsername(cfg *model.Config, normalized string, normalizedWithAt st
 		&u.NumberOfPhone,
 		&u.Role,
 		&u.MayToOpen,
+		&u.Active,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := decryptUser(cfg, u); err != nil {
+		return nil, err
+	}
 
 	return u, nil
 }
 
-func GetUserByFullName(cfg *model.Config, normalized string) (*model.UserProfile, error) {
+// GetUserByFullName ищет пользователя по полному имени. lower() в SQLite не
+// работает с кириллицей, поэтому имя сравнивается как есть, без пробелов по краям.
+func GetUserByFullName(cfg *model.Config, fullName string) (*model.UserProfile, error) {
 	db, err := openDB(cfg)
 	if err != nil {
 		return nil, err
@@ -117,10 +136,10 @@ func GetUserByFullName(cfg *model.Config, normalized string) (*model.UserProfile
 
 	u := &model.UserProfile{}
 	err = db.QueryRow(`
-		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen
+		SELECT TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, COALESCE(IsActive, 1)
 		FROM users
-		WHERE lower(trim(FullName)) = ? OR lower(trim(FullName)) = ?
-	`, normalized).Scan(
+		WHERE trim(FullName) = ?
+	`, strings.TrimSpace(fullName)).Scan(
 		&u.TelegramID,
 		&u.FirstName,
 		&u.LastName,
@@ -131,10 +150,14 @@ func GetUserByFullName(cfg *model.Config, normalized string) (*model.UserProfile
 		&u.NumberOfPhone,
 		&u.Role,
 		&u.MayToOpen,
+		&u.Active,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := decryptUser(cfg, u); err != nil {
+		return nil, err
+	}
 
 	return u, nil
 }
@@ -159,7 +182,8 @@ func SearchUsersByFullName(cfg *model.Config, fullName string) ([]model.UserProf
 			DateOfBirthday,
 			NumberOfPhone,
 			Role,
-			MayToOpen
+			MayToOpen,
+			COALESCE(IsActive, 1)
 		FROM users
 		WHERE trim(FullName) LIKE ?
 		ORDER BY FullName
@@ -185,10 +209,14 @@ func SearchUsersByFullName(cfg *model.Config, fullName string) ([]model.UserProf
 			&user.NumberOfPhone,
 			&user.Role,
 			&user.MayToOpen,
+			&user.Active,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if err := decryptUser(cfg, &user); err != nil {
+			return nil, err
+		}
 
 		users = append(users, user)
 	}
@@ -207,6 +235,11 @@ func UpdateUser(cfg *model.Config, telegramID string, updates *model.UserProfile
 	}
 	defer db.Close()
 
+	encrypted, err := encryptedUser(cfg, updates)
+	if err != nil {
+		return err
+	}
+
 	_, err = db.Exec(`
 		UPDATE users
 		SET FullName = ?, Username = ?, DateOfBirthday = ?, NumberOfPhone = ?, Role = ?, MayToOpen = ?
@@ -214,11 +247,162 @@ func UpdateUser(cfg *model.Config, telegramID string, updates *model.UserProfile
 	`,
 		updates.FullName,
 		updates.Username,
-		updates.DateOfBirthday,
-		updates.NumberOfPhone,
+		encrypted.DateOfBirthday,
+		encrypted.NumberOfPhone,
 		updates.Role,
 		updates.MayToOpen,
 		telegramID,
 	)
 	return err
 }
+
+// SetUserActive включает или отключает аккаунт. by — кто отключил аккаунт.
+func SetUserActive(cfg *model.Config, telegramID string, active bool, by string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	deactivatedAt := ""
+	if !active {
+		deactivatedAt = time.Now().Format(time.RFC3339)
+	} else {
+		by = ""
+	}
+
+	_, err = db.Exec(`
+		UPDATE users SET IsActive = ?, DeactivatedAt = ?, DeactivatedBy = ? WHERE TelegramID = ?
+	`, active, deactivatedAt, by, telegramID)
+	return err
+}
+
+// GetPhoneVisibilities возвращает заданные пользователями уровни видимости
+// телефона. Пользователи без настройки в результат не попадают.
+func GetPhoneVisibilities(cfg *model.Config) (map[string]string, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT TelegramID, PhoneVisibility FROM users WHERE COALESCE(PhoneVisibility, '') != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	visibilities := make(map[string]string)
+	for rows.Next() {
+		var telegramID, visibility string
+		if err := rows.Scan(&telegramID, &visibility); err != nil {
+			return nil, err
+		}
+		visibilities[telegramID] = visibility
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return visibilities, nil
+}
+
+func SetPhoneVisibility(cfg *model.Config, telegramID string, visibility string) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`UPDATE users SET PhoneVisibility = ? WHERE TelegramID = ?`, visibility, telegramID)
+	return err
+}
+
+// GetUserCapacities возвращает заданную вручную ёмкость участников в часах.
+// Участников без своей ёмкости в результате нет.
+func GetUserCapacities(cfg *model.Config) (map[string]float64, error) {
+	db, err := openDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT TelegramID, CapacityHours FROM users WHERE COALESCE(CapacityHours, 0) > 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	capacities := make(map[string]float64)
+	for rows.Next() {
+		var telegramID string
+		var hours float64
+		if err := rows.Scan(&telegramID, &hours); err != nil {
+			return nil, err
+		}
+		capacities[telegramID] = hours
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return capacities, nil
+}
+
+// SetUserCapacity задаёт ёмкость участника; 0 возвращает значение по умолчанию.
+func SetUserCapacity(cfg *model.Config, telegramID string, hours float64) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var value interface{}
+	if hours > 0 {
+		value = hours
+	}
+	_, err = db.Exec(`UPDATE users SET CapacityHours = ? WHERE TelegramID = ?`, value, telegramID)
+	return err
+}
+
+// OffboardUser в одной транзакции переназначает задачи, заменяет составы
+// проектов (projectMembers: ID проекта → JSON участников) и отключает
+// аккаунт.
+func OffboardUser(cfg *model.Config, telegramID string, by string, tasks []model.Task, projectMembers map[int]string, notifications []model.OutboxMessage) error {
+	db, err := openDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Format(time.RFC3339)
+	for _, task := range tasks {
+		if _, err := tx.Exec(
+			`UPDATE tasks SET user = ?, id_user = ?, updated_at = ? WHERE id = ?`,
+			task.User, task.IdUser, now, task.ID,
+		); err != nil {
+			return err
+		}
+	}
+	for projectID, users := range projectMembers {
+		if _, err := tx.Exec(`UPDATE projects SET users = ? WHERE id = ?`, users, projectID); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`
+		UPDATE users SET IsActive = ?, DeactivatedAt = ?, DeactivatedBy = ? WHERE TelegramID = ?
+	`, false, now, by, telegramID); err != nil {
+		return err
+	}
+	if err := enqueueNotifications(tx, notifications); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
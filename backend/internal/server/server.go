@@ -1,10 +1,14 @@
 package server
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"backend/internal/handler"
 	"backend/internal/middleware"
@@ -29,6 +33,11 @@ func WrapMiddleware(h http.HandlerFunc, middlewares ...func(http.Handler) http.H
 func New(cfg *model.Config) *App {
 	mux := http.NewServeMux()
 
+	// токены отключённых участников отклоняются до обработчиков
+	middleware.UserActive = func(userID int64) (bool, error) {
+		return services.IsUserActive(cfg, strconv.FormatInt(userID, 10))
+	}
+
 	// проверка работы api
 	mux.Handle("/health", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +51,9 @@ func New(cfg *model.Config) *App {
 	// end-point авторизации
 	mux.HandleFunc("/auth/telegram", handler.TelegramAuthHandler(cfg))
 
+	// заявка на вступление от пользователя, которого нет в сообществе
+	mux.HandleFunc("/auth/join", handler.JoinRequestHandler(cfg))
+
 	// end-point получения пользователей
 	mux.Handle("/get_users", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -51,6 +63,13 @@ func New(cfg *model.Config) *App {
 				return
 			}
 
+			userID, _ := r.Context().Value("user_id").(int64)
+			users, err = services.ApplyUserPrivacy(cfg, strconv.FormatInt(userID, 10), users)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(users)
 		}),
@@ -60,13 +79,56 @@ func New(cfg *model.Config) *App {
 	// end-point обновления пользователя
 	mux.Handle("/users/", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodPut {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+
+			// end-point загрузки участника: /users/{id}/workload. Смотреть может
+			// сам участник, руководители и админы; ёмкость меняют руководители и админы
+			if parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/"), "/"); len(parts) == 2 && parts[1] == "workload" {
+				telegramID := parts[0]
+				isSelf := telegramID == strconv.FormatInt(userID, 10)
+				canManage := canManageCapacity(cfg, userID, role)
+
+				var (
+					workload *model.Workload
+					err      error
+				)
+				switch r.Method {
+				case http.MethodGet:
+					if !isSelf && !canManage {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+					workload, err = services.GetUserWorkload(cfg, telegramID)
+				case http.MethodPut:
+					if !canManage {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+					var payload model.CapacityUpdate
+					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+					workload, err = services.SetUserCapacity(cfg, telegramID, payload.CapacityHours)
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				if err != nil {
+					if errors.Is(err, services.ErrUserNotFound) {
+						http.Error(w, err.Error(), http.StatusNotFound)
+						return
+					}
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(workload)
 				return
 			}
 
-			role, _ := r.Context().Value("role").(string)
-			userID, _ := r.Context().Value("user_id").(int64)
 			if !permissions.IsAdmin(role) {
 				user, err := services.GetUserByTelegramID(cfg, strconv.FormatInt(userID, 10))
 				if err != nil || user == nil || !permissions.IsAdmin(user.Role) {
@@ -75,12 +137,96 @@ func New(cfg *model.Config) *App {
 				}
 			}
 
-			telegramID := strings.TrimPrefix(r.URL.Path, "/users/")
+			parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/users/"), "/"), "/")
+			telegramID := parts[0]
 			if telegramID == "" {
 				http.Error(w, "telegram id is required", http.StatusBadRequest)
 				return
 			}
 
+			// end-point отключения участника: /users/{id}/offboard, /users/{id}/reactivate
+			// и обезличивания: /users/{id}/erase
+			if len(parts) > 1 {
+				if len(parts) != 2 {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				switch parts[1] {
+				case "offboard":
+					if telegramID == strconv.FormatInt(userID, 10) {
+						http.Error(w, "you cannot offboard yourself", http.StatusBadRequest)
+						return
+					}
+
+					var payload model.OffboardingRequest
+					if r.ContentLength != 0 {
+						if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+							http.Error(w, "invalid json", http.StatusBadRequest)
+							return
+						}
+					}
+
+					report, err := services.OffboardUser(cfg, telegramID, &payload, resolveReviewerName(cfg, userID))
+					if err != nil {
+						switch {
+						case errors.Is(err, services.ErrUserNotFound):
+							http.Error(w, err.Error(), http.StatusNotFound)
+						case errors.Is(err, services.ErrUserDeactivated):
+							http.Error(w, err.Error(), http.StatusConflict)
+						case errors.Is(err, services.ErrInvalidAssignee):
+							http.Error(w, err.Error(), http.StatusBadRequest)
+						default:
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+						}
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(report)
+				case "erase":
+					if telegramID == strconv.FormatInt(userID, 10) {
+						http.Error(w, "you cannot erase yourself", http.StatusBadRequest)
+						return
+					}
+
+					erasure, err := services.EraseUser(cfg, telegramID)
+					if err != nil {
+						if errors.Is(err, services.ErrUserNotFound) {
+							http.Error(w, err.Error(), http.StatusNotFound)
+							return
+						}
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(erasure)
+				case "reactivate":
+					if err := services.ReactivateUser(cfg, telegramID); err != nil {
+						if errors.Is(err, services.ErrUserNotFound) {
+							http.Error(w, err.Error(), http.StatusNotFound)
+							return
+						}
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+				return
+			}
+
+			if r.Method != http.MethodPut {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
 			var payload model.UserProfile
 			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 				http.Error(w, "invalid json", http.StatusBadRequest)
@@ -98,260 +244,2158 @@ func New(cfg *model.Config) *App {
 		middleware.JWTMiddleware(cfg.JWTSecret),
 	))
 
-	// end-point получения проектов
-	mux.Handle("/projects", WrapMiddleware(
+	// end-point календаря дней рождения
+	mux.Handle("/users/birthdays", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != http.MethodGet {
 				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 				return
 			}
 
-			id := r.URL.Query().Get("id")
-			username := r.URL.Query().Get("username")
-			var (
-				projects []model.Project
-				err      error
-			)
-
-			if username != "" {
-				projects, err = services.GetProjectsByUsername(cfg, username)
-			} else if id != "" {
-				idInt, err := strconv.Atoi(id)
-				if err != nil {
-					http.Error(w, "invalid id", http.StatusBadRequest)
-					return
-				}
-				projects, err = services.GetProjectsByID(cfg, idInt)
-			} else {
-				projects, err = services.GetProjects(cfg)
+			from, to, err := parseTimeRange(cfg, r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
 			}
+
+			birthdays, err := services.GetBirthdays(cfg, from, to)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(projects)
+			json.NewEncoder(w).Encode(birthdays)
 		}),
 		middleware.JWTMiddleware(cfg.JWTSecret),
 	))
 
-	// end-point получения/управления одним проектом
-	mux.Handle("/projects/", WrapMiddleware(
+	// end-point выгрузки личных данных текущего пользователя (ZIP)
+	mux.Handle("/me/export", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			path := strings.TrimPrefix(r.URL.Path, "/projects/")
-			parts := strings.Split(strings.Trim(path, "/"), "/")
-			if len(parts) == 0 || parts[0] == "" {
-				http.Error(w, "not found", http.StatusNotFound)
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 				return
 			}
 
-			id, err := strconv.Atoi(parts[0])
-			if err != nil {
-				http.Error(w, "invalid id", http.StatusBadRequest)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
 				return
 			}
 
-			if len(parts) == 1 {
-				if r.Method != http.MethodGet {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			var buf bytes.Buffer
+			if err := services.ExportUserData(cfg, strconv.FormatInt(userID, 10), &buf); err != nil {
+				if errors.Is(err, services.ErrUserNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
 					return
 				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
-				project, err := services.GetProjectByID(cfg, id)
-				if err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+			w.Header().Set("Content-Type", "application/zip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="personal-data-%d.zip"`, userID))
+			w.Write(buf.Bytes())
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point профиля текущего пользователя: роль и доступ здесь не меняются
+	mux.Handle("/me", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+			telegramID := strconv.FormatInt(userID, 10)
+
+			var (
+				me  *model.MeProfile
+				err error
+			)
+			switch r.Method {
+			case http.MethodGet:
+				me, err = services.GetMe(cfg, telegramID)
+			case http.MethodPatch:
+				var payload model.ProfilePatch
+				decoder := json.NewDecoder(r.Body)
+				decoder.DisallowUnknownFields()
+				if err := decoder.Decode(&payload); err != nil {
+					http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
 					return
 				}
-				if project == nil {
-					http.Error(w, "not found", http.StatusNotFound)
+
+				me, err = services.UpdateMe(cfg, telegramID, &payload)
+				if err != nil && !errors.Is(err, services.ErrUserNotFound) {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err != nil {
+				if errors.Is(err, services.ErrUserNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
 					return
 				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(project)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(me)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point настроек уведомлений текущего пользователя
+	mux.Handle("/me/notification-settings", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
 				return
 			}
+			telegramID := strconv.FormatInt(userID, 10)
 
-			sub := parts[1]
-			switch sub {
-			case "status":
-				if r.Method != http.MethodPut {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			var (
+				settings *model.NotificationSettings
+				err      error
+			)
+			switch r.Method {
+			case http.MethodGet:
+				settings, err = services.GetNotificationSettings(cfg, telegramID)
+			case http.MethodPut:
+				var payload model.NotificationSettings
+				decoder := json.NewDecoder(r.Body)
+				decoder.DisallowUnknownFields()
+				if err := decoder.Decode(&payload); err != nil {
+					http.Error(w, "invalid json: "+err.Error(), http.StatusBadRequest)
 					return
 				}
 
-				role, _ := r.Context().Value("role").(string)
-				userID, _ := r.Context().Value("user_id").(int64)
-				if !canManageProjectTasks(cfg, id, userID, role) {
-					http.Error(w, "access denied", http.StatusForbidden)
+				settings, err = services.UpdateNotificationSettings(cfg, telegramID, &payload)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
 
-				var payload struct {
-					Status string `json:"status"`
-				}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(settings)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point отсутствия текущего пользователя: PUT задаёт период и заместителя
+	mux.Handle("/me/absence", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+			telegramID := strconv.FormatInt(userID, 10)
+
+			var (
+				absence *model.Absence
+				err     error
+			)
+			switch r.Method {
+			case http.MethodGet:
+				absence, err = services.GetAbsence(cfg, telegramID)
+			case http.MethodPut:
+				var payload model.AbsenceRequest
 				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 					http.Error(w, "invalid json", http.StatusBadRequest)
 					return
 				}
-				status := strings.TrimSpace(payload.Status)
-				if status == "" {
-					http.Error(w, "status is required", http.StatusBadRequest)
+
+				absence, err = services.SetAbsence(cfg, telegramID, &payload)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
-
-				if err := services.UpdateProjectStatus(cfg, id, status); err != nil {
+			case http.MethodDelete:
+				if err := services.ClearAbsence(cfg, telegramID); err != nil {
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 				w.WriteHeader(http.StatusNoContent)
 				return
-			case "members":
-				role, _ := r.Context().Value("role").(string)
-				userID, _ := r.Context().Value("user_id").(int64)
-				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
-					http.Error(w, "access denied", http.StatusForbidden)
-					return
-				}
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if absence == nil {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
 
-				switch r.Method {
-				case http.MethodPost:
-					var payload model.ProjectMember
-					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-						http.Error(w, "invalid json", http.StatusBadRequest)
-						return
-					}
-					payload.Username = strings.TrimSpace(strings.TrimPrefix(payload.Username, "@"))
-					if payload.Username == "" {
-						http.Error(w, "username is required", http.StatusBadRequest)
-						return
-					}
-					if invalidRoleCombo(payload.Role) {
-						http.Error(w, "invalid role combination", http.StatusBadRequest)
-						return
-					}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(absence)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
 
-					if payload.FullName == "" {
-						if user, err := services.GetUserByUsername(cfg, payload.Username); err == nil && user != nil {
-							payload.FullName = user.FullName
-							payload.TelegramID = user.TelegramID
-						}
-					}
+	// end-point навыков текущего пользователя: PUT заменяет заявленные им навыки
+	mux.Handle("/me/skills", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+			telegramID := strconv.FormatInt(userID, 10)
 
-					payload.Role = normalizeMemberRole(payload.Role)
+			var (
+				skills []model.UserSkill
+				err    error
+			)
+			switch r.Method {
+			case http.MethodGet:
+				skills, err = services.GetUserSkills(cfg, telegramID)
+			case http.MethodPut:
+				var payload struct {
+					Skills []string `json:"skills"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				skills, err = services.SetSelfDeclaredSkills(cfg, telegramID, payload.Skills)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(skills)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point каталога навыков
+	mux.Handle("/skills", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			catalog, err := services.GetSkillCatalog(cfg)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(catalog)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point навыков участника
+	mux.Handle("/skills/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, "/skills/")
+			parts := strings.Split(strings.Trim(path, "/"), "/")
+			if len(parts) == 0 || parts[0] == "" || len(parts) > 2 {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			telegramID := parts[0]
+			if _, err := strconv.ParseInt(telegramID, 10, 64); err != nil {
+				http.Error(w, "invalid telegram id", http.StatusBadRequest)
+				return
+			}
+
+			if len(parts) == 1 {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				skills, err := services.GetUserSkills(cfg, telegramID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(skills)
+				return
+			}
+
+			if parts[1] != "endorsements" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !canEndorseSkills(cfg, userID, role) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			var payload struct {
+				Skill string `json:"skill"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				http.Error(w, "invalid json", http.StatusBadRequest)
+				return
+			}
+
+			var (
+				skills []model.UserSkill
+				err    error
+			)
+			endorsedBy := strconv.FormatInt(userID, 10)
+			switch r.Method {
+			case http.MethodPost:
+				skills, err = services.EndorseSkill(cfg, telegramID, payload.Skill, endorsedBy)
+			case http.MethodDelete:
+				skills, err = services.WithdrawSkillEndorsement(cfg, telegramID, payload.Skill, endorsedBy)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if err != nil {
+				if errors.Is(err, services.ErrUserNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(skills)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point очереди заявок на изменение профиля
+	mux.Handle("/profile-changes", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			status := r.URL.Query().Get("status")
+			if status == "" {
+				status = model.ProfileChangePending
+			} else if status == "all" {
+				status = ""
+			}
+
+			changes, err := services.GetProfileChanges(cfg, status)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(changes)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point одобрения/отклонения заявки на изменение профиля
+	mux.Handle("/profile-changes/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/profile-changes/"), "/"), "/")
+			if len(parts) != 2 || (parts[1] != "approve" && parts[1] != "reject") {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			change, err := services.ReviewProfileChange(cfg, id, parts[1] == "approve", resolveReviewerName(cfg, userID))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(change)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point списка заявок на вступление
+	mux.Handle("/join-requests", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			status := r.URL.Query().Get("status")
+			if status == "" {
+				status = model.JoinRequestPending
+			} else if status == "all" {
+				status = ""
+			}
+
+			requests, err := services.GetJoinRequests(cfg, status)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(requests)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point одобрения (с ролью) или отклонения заявки на вступление
+	mux.Handle("/join-requests/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/join-requests/"), "/"), "/")
+			if len(parts) != 2 {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			var payload struct {
+				Role   string `json:"role"`
+				Reason string `json:"reason"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+			}
+
+			var request *model.JoinRequest
+			switch parts[1] {
+			case "approve":
+				request, err = services.ApproveJoinRequest(cfg, id, payload.Role, resolveReviewerName(cfg, userID))
+			case "reject":
+				request, err = services.RejectJoinRequest(cfg, id, payload.Reason, resolveReviewerName(cfg, userID))
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				if errors.Is(err, services.ErrAlreadyMember) {
+					http.Error(w, err.Error(), http.StatusConflict)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(request)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point очереди исходящих уведомлений: ?status=dead показывает недоставленные
+	mux.Handle("/notifications/outbox", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			limit := 0
+			if value := r.URL.Query().Get("limit"); value != "" {
+				parsed, err := strconv.Atoi(value)
+				if err != nil || parsed <= 0 {
+					http.Error(w, "invalid limit", http.StatusBadRequest)
+					return
+				}
+				limit = parsed
+			}
+
+			messages, err := services.GetOutboxMessages(cfg, r.URL.Query().Get("status"), limit)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(messages)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point повторной отправки: /notifications/outbox/{id}/replay — одно
+	// сообщение, /notifications/outbox/replay — все недоставленные
+	mux.Handle("/notifications/outbox/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/notifications/outbox/"), "/"), "/")
+			var (
+				result *model.OutboxReplay
+				err    error
+			)
+			switch {
+			case len(parts) == 1 && parts[0] == "replay":
+				result, err = services.ReplayDeadOutboxMessages(cfg)
+			case len(parts) == 2 && parts[1] == "replay":
+				id, parseErr := strconv.Atoi(parts[0])
+				if parseErr != nil {
+					http.Error(w, "invalid id", http.StatusBadRequest)
+					return
+				}
+				result, err = services.ReplayOutboxMessage(cfg, id)
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				if errors.Is(err, services.ErrOutboxMessageNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получения проектов
+	mux.Handle("/projects", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			id := r.URL.Query().Get("id")
+			username := r.URL.Query().Get("username")
+			var (
+				projects []model.Project
+				err      error
+			)
+
+			if username != "" {
+				projects, err = services.GetProjectsByUsername(cfg, username)
+			} else if id != "" {
+				idInt, err := strconv.Atoi(id)
+				if err != nil {
+					http.Error(w, "invalid id", http.StatusBadRequest)
+					return
+				}
+				projects, err = services.GetProjectsByID(cfg, idInt)
+			} else {
+				projects, err = services.GetProjects(cfg)
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			includeArchived := r.URL.Query().Get("include_archived") == "true" || id != ""
+			projects = filterVisibleProjects(cfg, projects, userID, role, includeArchived)
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(projects)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получения/управления одним проектом
+	mux.Handle("/projects/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, "/projects/")
+			parts := strings.Split(strings.Trim(path, "/"), "/")
+			if len(parts) == 0 || parts[0] == "" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			if parts[0] == "from-template" {
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload model.ProjectFromTemplateRequest
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+				if payload.TemplateID == 0 {
+					http.Error(w, "template_id is required", http.StatusBadRequest)
+					return
+				}
+
+				project, err := services.CreateProjectFromTemplate(cfg, &payload, resolveReviewerName(cfg, userID))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(project)
+				return
+			}
+
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			project, err := services.GetProjectByID(cfg, id)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if project == nil {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			viewerRole, _ := r.Context().Value("role").(string)
+			viewerID, _ := r.Context().Value("user_id").(int64)
+			if !canViewProject(cfg, project, viewerID, viewerRole) {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+
+			if len(parts) == 1 {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(project)
+				return
+			}
+
+			sub := parts[1]
+			// архивный проект доступен только на чтение
+			if project.Archived && r.Method != http.MethodGet && sub != "archive" && sub != "template" {
+				http.Error(w, "project is archived", http.StatusConflict)
+				return
+			}
+
+			switch sub {
+			case "status":
+				if r.Method != http.MethodPut {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Status string `json:"status"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+				status := strings.TrimSpace(payload.Status)
+				if status == "" {
+					http.Error(w, "status is required", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.UpdateProjectStatus(cfg, id, status); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "archive":
+				if r.Method != http.MethodPut {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Archived bool `json:"archived"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.SetProjectArchived(cfg, id, payload.Archived); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "visibility":
+				if r.Method != http.MethodPut {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Visibility string `json:"visibility"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.SetProjectVisibility(cfg, id, strings.TrimSpace(payload.Visibility)); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "status-lock":
+				if r.Method != http.MethodPut {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Locked bool `json:"locked"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.SetProjectStatusLock(cfg, id, payload.Locked); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "template":
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Title     string `json:"title"`
+					StartDate string `json:"start_date"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				template, err := services.SaveProjectAsTemplate(cfg, id, payload.Title, strings.TrimSpace(payload.StartDate), resolveReviewerName(cfg, userID))
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(template)
+				return
+			case "milestones":
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+
+				if len(parts) == 2 {
+					switch r.Method {
+					case http.MethodGet:
+						milestones, err := services.GetMilestones(cfg, id)
+						if err != nil {
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(milestones)
+						return
+					case http.MethodPost:
+						if !canManageProjectTasks(cfg, id, userID, role) {
+							http.Error(w, "access denied", http.StatusForbidden)
+							return
+						}
+
+						var payload model.Milestone
+						if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+							http.Error(w, "invalid json", http.StatusBadRequest)
+							return
+						}
+						payload.IdProject = id
+
+						if err := services.CreateMilestone(cfg, &payload); err != nil {
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(http.StatusCreated)
+						json.NewEncoder(w).Encode(payload)
+						return
+					default:
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+				}
+
+				milestoneID, err := strconv.Atoi(parts[2])
+				if err != nil {
+					http.Error(w, "invalid milestone id", http.StatusBadRequest)
+					return
+				}
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				switch r.Method {
+				case http.MethodPut:
+					var payload model.Milestone
+					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+					payload.ID = milestoneID
+					payload.IdProject = id
+
+					if err := services.UpdateMilestone(cfg, &payload); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				case http.MethodDelete:
+					if err := services.DeleteMilestone(cfg, id, milestoneID); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+			case "timeline":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				timeline, err := services.GetProjectTimeline(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if timeline == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(timeline)
+				return
+			case "workload":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				workload, err := services.GetProjectWorkload(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if workload == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(workload)
+				return
+			case "suggest-assignees":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, id, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				task := strings.TrimSpace(r.URL.Query().Get("task"))
+				if task == "" {
+					http.Error(w, "task is required", http.StatusBadRequest)
+					return
+				}
+
+				suggestions, err := services.SuggestAssignees(cfg, id, task)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if suggestions == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(suggestions)
+				return
+			case "stats":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				stats, err := services.GetProjectStats(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if stats == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(stats)
+				return
+			case "members":
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				switch r.Method {
+				case http.MethodPost:
+					var payload model.ProjectMember
+					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+					payload.Username = strings.TrimSpace(strings.TrimPrefix(payload.Username, "@"))
+					if payload.Username == "" {
+						http.Error(w, "username is required", http.StatusBadRequest)
+						return
+					}
+					if invalidRoleCombo(payload.Role) {
+						http.Error(w, "invalid role combination", http.StatusBadRequest)
+						return
+					}
+
+					if payload.FullName == "" {
+						if user, err := services.GetUserByUsername(cfg, payload.Username); err == nil && user != nil {
+							payload.FullName = user.FullName
+							payload.TelegramID = user.TelegramID
+						}
+					}
+
+					payload.Role = permissions.NormalizeMemberRole(payload.Role)
 					if err := services.AddProjectMember(cfg, id, payload); err != nil {
 						http.Error(w, err.Error(), http.StatusBadRequest)
 						return
 					}
-					w.WriteHeader(http.StatusCreated)
-					return
-				case http.MethodPut:
-					if len(parts) < 3 {
-						http.Error(w, "username is required", http.StatusBadRequest)
+					w.WriteHeader(http.StatusCreated)
+					return
+				case http.MethodPut:
+					if len(parts) < 3 {
+						http.Error(w, "username is required", http.StatusBadRequest)
+						return
+					}
+					username := parts[2]
+					var payload struct {
+						Role string `json:"role"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+					if invalidRoleCombo(payload.Role) {
+						http.Error(w, "invalid role combination", http.StatusBadRequest)
+						return
+					}
+					roleValue := permissions.NormalizeMemberRole(payload.Role)
+					if err := services.UpdateProjectMemberRole(cfg, id, username, roleValue); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				case http.MethodDelete:
+					if len(parts) < 3 {
+						http.Error(w, "username is required", http.StatusBadRequest)
+						return
+					}
+					username := parts[2]
+					if err := services.RemoveProjectMember(cfg, id, username); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получение/создание тасок по задаче
+	mux.Handle("/tasks", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+
+			case http.MethodGet:
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+
+				id := r.URL.Query().Get("id_project")
+				if id == "" {
+					term := strings.TrimSpace(r.URL.Query().Get("q"))
+					if term == "" {
+						http.Error(w, "id_project or q is required", http.StatusBadRequest)
+						return
+					}
+					tasks, err := searchVisibleTasks(cfg, term, userID, role)
+					if err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(tasks)
+					return
+				}
+
+				idInt, err := strconv.Atoi(id)
+				if err != nil {
+					http.Error(w, "invalid id_project", http.StatusBadRequest)
+					return
+				}
+
+				project, err := services.GetProjectByID(cfg, idInt)
+				if err != nil {
+					http.Error(w, "failed to load project", http.StatusInternalServerError)
+					return
+				}
+				if project == nil {
+					http.Error(w, "project not found", http.StatusNotFound)
+					return
+				}
+				if !canViewProject(cfg, project, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				tasks, err := services.GetTasksByProjectID(idInt)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(tasks)
+
+			case http.MethodPost:
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+
+				var input model.Task
+
+				if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				task := model.Task{
+					Description:   input.Description,
+					StartDate:     input.StartDate,
+					Deadline:      input.Deadline,
+					Status:        input.Status,
+					User:          input.User,
+					Title:         input.Title,
+					Author:        input.Author,
+					IdProject:     input.IdProject,
+					IdUser:        input.IdUser,
+					EstimateHours: input.EstimateHours,
+				}
+
+				if !canManageProjectTasks(cfg, task.IdProject, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+				if isProjectArchived(cfg, task.IdProject) {
+					http.Error(w, "project is archived", http.StatusConflict)
+					return
+				}
+				if err := services.ValidateTask(&task); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				if err := services.CreateTask(&task); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				// задача создаётся в любом случае, перегрузка исполнителя — только предупреждение
+				response := struct {
+					model.Task
+					Warnings []string `json:"warnings,omitempty"`
+				}{Task: task}
+				if warning := services.CapacityWarning(cfg, &task); warning != "" {
+					response.Warnings = append(response.Warnings, warning)
+				}
+
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(response)
+
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point управления задачами
+	mux.Handle("/tasks/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, "/tasks/")
+			parts := strings.Split(strings.Trim(path, "/"), "/")
+			if len(parts) == 0 || parts[0] == "" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			if existing, err := services.GetTaskByID(cfg, id); err == nil && existing != nil {
+				if isProjectArchived(cfg, existing.IdProject) {
+					http.Error(w, "project is archived", http.StatusConflict)
+					return
+				}
+			}
+
+			if len(parts) == 1 {
+				switch r.Method {
+				case http.MethodPut:
+					role, _ := r.Context().Value("role").(string)
+					userID, _ := r.Context().Value("user_id").(int64)
+
+					var update model.TaskUpdate
+					if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+
+					payload := update.Task
+					payload.ID = id
+					existing, err := services.GetTaskByID(cfg, id)
+					if err != nil {
+						http.Error(w, "failed to load task", http.StatusInternalServerError)
+						return
+					}
+					if existing == nil {
+						http.Error(w, "task not found", http.StatusNotFound)
+						return
+					}
+					if payload.IdProject == 0 {
+						payload.IdProject = existing.IdProject
+					}
+					// клиенты, которые не знают о start_date, не должны его затирать;
+					// пустая строка явно очищает дату
+					if update.StartDate != nil {
+						payload.StartDate = strings.TrimSpace(*update.StartDate)
+					} else {
+						payload.StartDate = existing.StartDate
+					}
+					if update.EstimateHours != nil {
+						payload.EstimateHours = *update.EstimateHours
+					} else {
+						payload.EstimateHours = existing.EstimateHours
+					}
+					if err := services.ValidateTask(&payload); err != nil {
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					if !canManageProjectTasks(cfg, payload.IdProject, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+					if payload.IdUser == 0 && payload.User != "" {
+						if user, err := services.GetUserByUsername(cfg, payload.User); err == nil && user != nil {
+							if user.TelegramID != "" {
+								if telegramID, err := strconv.ParseInt(user.TelegramID, 10, 64); err == nil {
+									payload.IdUser = telegramID
+								}
+							}
+						}
+					}
+
+					if err := services.UpdateTask(cfg, &payload); err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				case http.MethodDelete:
+					role, _ := r.Context().Value("role").(string)
+					userID, _ := r.Context().Value("user_id").(int64)
+
+					var projectID int
+					if existing, err := services.GetTaskByID(cfg, id); err == nil && existing != nil {
+						projectID = existing.IdProject
+					}
+					if !canManageProjectTasks(cfg, projectID, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+
+					if err := services.DeleteTask(cfg, id); err != nil {
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+					return
+				default:
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+			}
+
+			sub := parts[1]
+			switch sub {
+			case "complete":
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				task, err := services.GetTaskByID(cfg, id)
+				if err != nil || task == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				userID, _ := r.Context().Value("user_id").(int64)
+				role, _ := r.Context().Value("role").(string)
+				if !canSubmitCompletion(cfg, task, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					Message string `json:"message"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.SubmitTaskCompletion(cfg, id, strings.TrimSpace(payload.Message), strconv.FormatInt(userID, 10)); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "review":
+				if r.Method != http.MethodPost {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+
+				var payload struct {
+					Approved bool   `json:"approved"`
+					Message  string `json:"message"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				reviewer := resolveReviewerName(cfg, userID)
+
+				task, err := services.GetTaskByID(cfg, id)
+				if err != nil || task == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				// заместитель отсутствующего руководителя проверяет от его имени
+				onBehalfOf := ""
+				if !canManageProjectTasks(cfg, task.IdProject, userID, role) {
+					onBehalfOf = reviewDelegator(cfg, task.IdProject, userID)
+					if onBehalfOf == "" {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+				}
+
+				if err := services.ReviewTaskCompletion(cfg, id, payload.Approved, reviewer, strings.TrimSpace(payload.Message), strconv.FormatInt(userID, 10), onBehalfOf); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			case "history":
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				task, err := services.GetTaskByID(cfg, id)
+				if err != nil || task == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				project, err := services.GetProjectByID(cfg, task.IdProject)
+				if err != nil {
+					http.Error(w, "failed to load project", http.StatusInternalServerError)
+					return
+				}
+				if project == nil || !canViewProject(cfg, project, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				history, err := services.GetTaskHistory(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(history)
+				return
+			case "dependencies":
+				if r.Method != http.MethodPut {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+
+				task, err := services.GetTaskByID(cfg, id)
+				if err != nil || task == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageProjectTasks(cfg, task.IdProject, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload struct {
+					DependsOn []int `json:"depends_on"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+
+				if err := services.SetTaskDependencies(cfg, id, payload.DependsOn); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			default:
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получения/создания шаблонов проектов
+	mux.Handle("/templates", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				templates, err := services.GetTemplates(cfg)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(templates)
+			case http.MethodPost:
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload model.ProjectTemplate
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+				payload.CreatedBy = resolveReviewerName(cfg, userID)
+
+				if err := services.CreateTemplate(cfg, &payload); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(payload)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получения/удаления одного шаблона
+	mux.Handle("/templates/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(r.URL.Path, "/templates/"), "/"))
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet:
+				template, err := services.GetTemplateByID(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if template == nil {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(template)
+			case http.MethodDelete:
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				if err := services.DeleteTemplate(cfg, id); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// dashboard data
+	mux.Handle("/dashboard", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			username := r.URL.Query().Get("username")
+			if username == "" {
+				http.Error(w, "username is required", http.StatusBadRequest)
+				return
+			}
+
+			projects, err := services.GetProjectsByUsername(cfg, username)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			projects = filterVisibleProjects(cfg, projects, userID, role, false)
+
+			projectMap := make(map[int]string)
+			for _, project := range projects {
+				projectMap[project.ID] = project.Title
+			}
+
+			normalizedUsername := normalizeUsername(username)
+			tasks := make([]model.Task, 0)
+			for _, project := range projects {
+				projectTasks, _ := services.GetTasksByProjectID(project.ID)
+				for _, task := range projectTasks {
+					if strings.ToLower(task.Status) == strings.ToLower("Выполнена") {
+						continue
+					}
+					if normalizedUsername != "" &&
+						normalizeUsername(task.User) != normalizedUsername {
+						continue
+					}
+					task.ProjectTitle = projectMap[task.IdProject]
+					tasks = append(tasks, task)
+				}
+			}
+
+			// события вызывающего: приглашения, события его проектов и открытые;
+			// чужие приглашения по username не показываются
+			events, err := services.GetUserEvents(cfg, strconv.FormatInt(userID, 10), time.Now(), time.Time{})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			events = filterVisibleEvents(events, projectVisibility(cfg, userID, role))
+
+			payload := model.Dashboard{
+				Projects: projects,
+				Tasks:    tasks,
+				Events:   events,
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(payload)
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point получения/создания событий
+	mux.Handle("/events", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				from, to, err := parseTimeRange(cfg, r)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				events, err := services.GetEvents(cfg, from, to)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				events = filterVisibleEvents(events, projectVisibility(cfg, userID, role))
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(events)
+			case http.MethodPost:
+				role, _ := r.Context().Value("role").(string)
+				userID, _ := r.Context().Value("user_id").(int64)
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload model.Event
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+				payload.CreatedBy = resolveReviewerName(cfg, userID)
+
+				if err := services.CreateEvent(cfg, &payload); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusCreated)
+				json.NewEncoder(w).Encode(payload)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
+
+	// end-point управления событием
+	mux.Handle("/events/", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := strings.TrimPrefix(r.URL.Path, "/events/")
+			parts := strings.Split(strings.Trim(path, "/"), "/")
+			if len(parts) == 0 || parts[0] == "" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+			telegramID := strconv.FormatInt(userID, 10)
+
+			// отчёт о посещаемости по участникам за период
+			if parts[0] == "attendance-report" && len(parts) == 1 {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					return
+				}
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				from, to, err := parseTimeRange(cfg, r)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				report, err := services.GetAttendanceReport(cfg, from, to)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(report)
+				return
+			}
+
+			id, err := strconv.Atoi(parts[0])
+			if err != nil {
+				http.Error(w, "invalid id", http.StatusBadRequest)
+				return
+			}
+
+			if len(parts) > 1 {
+				event, err := services.GetEventByID(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if event == nil || !projectVisibility(cfg, userID, role)(event.ProjectID) {
+					http.Error(w, "not found", http.StatusNotFound)
+					return
+				}
+
+				switch {
+				// приглашения: весь состав, участники проекта или роль
+				case parts[1] == "invitations" && len(parts) == 2:
+					switch r.Method {
+					case http.MethodGet:
+						invitations, err := services.GetEventInvitations(cfg, id)
+						if err != nil {
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(invitations)
+					case http.MethodPut:
+						if !canManageEvents(cfg, userID, role) {
+							http.Error(w, "access denied", http.StatusForbidden)
+							return
+						}
+
+						var payload []model.EventInvitation
+						if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+							http.Error(w, "invalid json", http.StatusBadRequest)
+							return
+						}
+
+						if err := services.SetEventInvitations(cfg, id, payload); err != nil {
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+
+						invitations, err := services.GetEventInvitations(cfg, id)
+						if err != nil {
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(invitations)
+					default:
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					}
+				// ответ участника: going / maybe / no
+				case parts[1] == "rsvp" && len(parts) == 2:
+					if r.Method != http.MethodPost {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+
+					var payload struct {
+						Status         string `json:"status"`
+						OccurrenceDate string `json:"occurrence_date"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+						http.Error(w, "invalid json", http.StatusBadRequest)
+						return
+					}
+
+					if err := services.SetRSVP(cfg, id, payload.OccurrenceDate, telegramID, payload.Status); err != nil {
+						if errors.Is(err, services.ErrNotInvited) {
+							http.Error(w, err.Error(), http.StatusForbidden)
+							return
+						}
+						http.Error(w, err.Error(), http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusNoContent)
+				// отметка присутствия: организатор отмечает участника,
+				// участник отмечается сам по коду
+				case parts[1] == "check-in" && len(parts) == 2:
+					if r.Method != http.MethodPost {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 						return
 					}
-					username := parts[2]
+
 					var payload struct {
-						Role string `json:"role"`
+						TelegramID     string `json:"telegram_id"`
+						Username       string `json:"username"`
+						Code           string `json:"code"`
+						OccurrenceDate string `json:"occurrence_date"`
 					}
 					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 						http.Error(w, "invalid json", http.StatusBadRequest)
 						return
 					}
-					if invalidRoleCombo(payload.Role) {
-						http.Error(w, "invalid role combination", http.StatusBadRequest)
+
+					if payload.Code != "" {
+						if err := services.CheckInWithCode(cfg, id, payload.OccurrenceDate, telegramID, payload.Code); err != nil {
+							if errors.Is(err, services.ErrNotInvited) {
+								http.Error(w, err.Error(), http.StatusForbidden)
+								return
+							}
+							if errors.Is(err, services.ErrTooManyCheckInAttempts) {
+								http.Error(w, err.Error(), http.StatusTooManyRequests)
+								return
+							}
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+						w.WriteHeader(http.StatusNoContent)
 						return
 					}
-					roleValue := normalizeMemberRole(payload.Role)
-					if err := services.UpdateProjectMemberRole(cfg, id, username, roleValue); err != nil {
-						http.Error(w, err.Error(), http.StatusBadRequest)
+
+					if !canManageEvents(cfg, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
 						return
 					}
-					w.WriteHeader(http.StatusNoContent)
-					return
-				case http.MethodDelete:
-					if len(parts) < 3 {
-						http.Error(w, "username is required", http.StatusBadRequest)
+
+					target := strings.TrimSpace(payload.TelegramID)
+					if target == "" && payload.Username != "" {
+						user, err := services.GetUserByUsername(cfg, payload.Username)
+						if err != nil {
+							http.Error(w, "user not found", http.StatusNotFound)
+							return
+						}
+						target = user.TelegramID
+					}
+					if target == "" {
+						http.Error(w, "telegram_id, username or code is required", http.StatusBadRequest)
 						return
 					}
-					username := parts[2]
-					if err := services.RemoveProjectMember(cfg, id, username); err != nil {
+
+					if err := services.CheckInByOrganizer(cfg, id, payload.OccurrenceDate, target, resolveReviewerName(cfg, userID)); err != nil {
+						if errors.Is(err, services.ErrUserNotFound) {
+							http.Error(w, err.Error(), http.StatusNotFound)
+							return
+						}
 						http.Error(w, err.Error(), http.StatusBadRequest)
 						return
 					}
 					w.WriteHeader(http.StatusNoContent)
-					return
+				// одноразовый код для самостоятельной отметки
+				case parts[1] == "check-in-code" && len(parts) == 2:
+					if r.Method != http.MethodPost {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					if !canManageEvents(cfg, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+
+					code, err := services.IssueCheckInCode(cfg, id, r.URL.Query().Get("occurrence_date"))
+					if err != nil {
+						if errors.Is(err, services.ErrInvalidOccurrence) {
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(code)
+				// изменение или отмена одного повторения серии
+				case parts[1] == "occurrences" && len(parts) == 3:
+					if !canManageEvents(cfg, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+
+					switch r.Method {
+					case http.MethodPut:
+						var payload model.Event
+						if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+							http.Error(w, "invalid json", http.StatusBadRequest)
+							return
+						}
+
+						occurrence, err := services.UpdateEventOccurrence(cfg, id, parts[2], &payload)
+						if err != nil {
+							var conflict *services.ResourceConflictError
+							if errors.As(err, &conflict) {
+								http.Error(w, err.Error(), http.StatusConflict)
+								return
+							}
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(occurrence)
+					case http.MethodDelete:
+						if err := services.CancelEventOccurrence(cfg, id, parts[2]); err != nil {
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+						w.WriteHeader(http.StatusNoContent)
+					default:
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					}
+				// бронирование ресурсов на время события
+				case parts[1] == "resources" && len(parts) == 2:
+					switch r.Method {
+					case http.MethodGet:
+						resources, err := services.GetEventResources(cfg, id)
+						if err != nil {
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(resources)
+					case http.MethodPut:
+						if !canManageEvents(cfg, userID, role) {
+							http.Error(w, "access denied", http.StatusForbidden)
+							return
+						}
+
+						var payload struct {
+							ResourceIDs []int `json:"resource_ids"`
+						}
+						if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+							http.Error(w, "invalid json", http.StatusBadRequest)
+							return
+						}
+
+						if err := services.SetEventResources(cfg, id, payload.ResourceIDs); err != nil {
+							var conflict *services.ResourceConflictError
+							if errors.As(err, &conflict) {
+								http.Error(w, err.Error(), http.StatusConflict)
+								return
+							}
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+
+						resources, err := services.GetEventResources(cfg, id)
+						if err != nil {
+							http.Error(w, err.Error(), http.StatusInternalServerError)
+							return
+						}
+
+						w.Header().Set("Content-Type", "application/json")
+						json.NewEncoder(w).Encode(resources)
+					default:
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					}
+				case parts[1] == "attendance" && len(parts) == 2:
+					if r.Method != http.MethodGet {
+						http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+						return
+					}
+					if !canManageEvents(cfg, userID, role) {
+						http.Error(w, "access denied", http.StatusForbidden)
+						return
+					}
+
+					attendance, err := services.GetEventAttendance(cfg, id, r.URL.Query().Get("occurrence_date"))
+					if err != nil {
+						if errors.Is(err, services.ErrInvalidOccurrence) {
+							http.Error(w, err.Error(), http.StatusBadRequest)
+							return
+						}
+						http.Error(w, err.Error(), http.StatusInternalServerError)
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					json.NewEncoder(w).Encode(attendance)
 				default:
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+				return
+			}
+
+			switch r.Method {
+			case http.MethodGet:
+				event, err := services.GetEventByID(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if event == nil || !projectVisibility(cfg, userID, role)(event.ProjectID) {
+					http.Error(w, "not found", http.StatusNotFound)
 					return
 				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(event)
+			case http.MethodPut:
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				var payload model.Event
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
+					return
+				}
+				payload.ID = id
+
+				if err := services.UpdateEvent(cfg, &payload); err != nil {
+					var conflict *services.ResourceConflictError
+					if errors.As(err, &conflict) {
+						http.Error(w, err.Error(), http.StatusConflict)
+						return
+					}
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(payload)
+			case http.MethodDelete:
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
+					return
+				}
+
+				if err := services.DeleteEvent(cfg, id); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
 			default:
-				http.Error(w, "not found", http.StatusNotFound)
-				return
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
 		}),
 		middleware.JWTMiddleware(cfg.JWTSecret),
 	))
 
-	// end-point получение/создание тасок по задаче
-	mux.Handle("/tasks", WrapMiddleware(
+	// end-point каталога ресурсов (аудитории, оборудование)
+	mux.Handle("/resources", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			switch r.Method {
-
 			case http.MethodGet:
-				id := r.URL.Query().Get("id_project")
-				if id == "" {
-					http.Error(w, "id_project is required", http.StatusBadRequest)
-					return
-				}
-
-				idInt, err := strconv.Atoi(id)
+				resources, err := services.GetResources(cfg)
 				if err != nil {
-					http.Error(w, "invalid id_project", http.StatusBadRequest)
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				tasks, _ := services.GetTasksByProjectID(idInt)
-
 				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(tasks)
-
+				json.NewEncoder(w).Encode(resources)
 			case http.MethodPost:
 				role, _ := r.Context().Value("role").(string)
 				userID, _ := r.Context().Value("user_id").(int64)
-
-				var input model.Task
-
-				if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-					http.Error(w, "invalid json", http.StatusBadRequest)
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
 					return
 				}
 
-				task := model.Task{
-					Description: input.Description,
-					Deadline:    input.Deadline,
-					Status:      input.Status,
-					User:        input.User,
-					Title:       input.Title,
-					Author:      input.Author,
-					IdProject:   input.IdProject,
-					IdUser:      input.IdUser,
-				}
-
-				if !canManageProjectTasks(cfg, task.IdProject, userID, role) {
-					http.Error(w, "access denied", http.StatusForbidden)
+				var payload model.Resource
+				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+					http.Error(w, "invalid json", http.StatusBadRequest)
 					return
 				}
 
-				if err := services.CreateTask(&task); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err := services.CreateResource(cfg, &payload); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
 
+				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusCreated)
-				json.NewEncoder(w).Encode(task)
-
+				json.NewEncoder(w).Encode(payload)
 			default:
 				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
@@ -359,246 +2403,214 @@ func New(cfg *model.Config) *App {
 		middleware.JWTMiddleware(cfg.JWTSecret),
 	))
 
-	// end-point управления задачами
-	mux.Handle("/tasks/", WrapMiddleware(
+	// end-point управления ресурсом и просмотра его занятости
+	mux.Handle("/resources/", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			path := strings.TrimPrefix(r.URL.Path, "/tasks/")
+			path := strings.TrimPrefix(r.URL.Path, "/resources/")
 			parts := strings.Split(strings.Trim(path, "/"), "/")
-			if len(parts) == 0 || parts[0] == "" {
-				http.Error(w, "not found", http.StatusNotFound)
-				return
-			}
 
 			id, err := strconv.Atoi(parts[0])
 			if err != nil {
 				http.Error(w, "invalid id", http.StatusBadRequest)
 				return
-			}
-
-			if len(parts) == 1 {
-				switch r.Method {
-				case http.MethodPut:
-					role, _ := r.Context().Value("role").(string)
-					userID, _ := r.Context().Value("user_id").(int64)
-
-					var payload model.Task
-					if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-						http.Error(w, "invalid json", http.StatusBadRequest)
-						return
-					}
-
-					payload.ID = id
-					if payload.IdProject == 0 {
-						if existing, err := services.GetTaskByID(cfg, id); err == nil && existing != nil {
-							payload.IdProject = existing.IdProject
-						}
-					}
-					if !canManageProjectTasks(cfg, payload.IdProject, userID, role) {
-						http.Error(w, "access denied", http.StatusForbidden)
-						return
-					}
-					if payload.IdUser == 0 && payload.User != "" {
-						if user, err := services.GetUserByUsername(cfg, payload.User); err == nil && user != nil {
-							if user.TelegramID != "" {
-								if telegramID, err := strconv.ParseInt(user.TelegramID, 10, 64); err == nil {
-									payload.IdUser = telegramID
-								}
-							}
-						}
-					}
+			}
 
-					if err := services.UpdateTask(cfg, &payload); err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
-						return
-					}
-					w.WriteHeader(http.StatusNoContent)
+			role, _ := r.Context().Value("role").(string)
+			userID, _ := r.Context().Value("user_id").(int64)
+
+			if len(parts) == 2 && parts[1] == "availability" {
+				if r.Method != http.MethodGet {
+					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 					return
-				case http.MethodDelete:
-					role, _ := r.Context().Value("role").(string)
-					userID, _ := r.Context().Value("user_id").(int64)
+				}
 
-					var projectID int
-					if existing, err := services.GetTaskByID(cfg, id); err == nil && existing != nil {
-						projectID = existing.IdProject
-					}
-					if !canManageProjectTasks(cfg, projectID, userID, role) {
-						http.Error(w, "access denied", http.StatusForbidden)
-						return
-					}
+				from, to, err := parseTimeRange(cfg, r)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
 
-					if err := services.DeleteTask(cfg, id); err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
-						return
-					}
-					w.WriteHeader(http.StatusNoContent)
+				availability, err := services.GetResourceAvailability(cfg, id, from, to)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
-				default:
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				}
+				if availability == nil {
+					http.Error(w, "not found", http.StatusNotFound)
 					return
 				}
+
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(availability)
+				return
+			}
+			if len(parts) > 1 {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
 			}
 
-			sub := parts[1]
-			switch sub {
-			case "complete":
-				if r.Method != http.MethodPost {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			switch r.Method {
+			case http.MethodGet:
+				resource, err := services.GetResourceByID(cfg, id)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
-
-				task, err := services.GetTaskByID(cfg, id)
-				if err != nil || task == nil {
+				if resource == nil {
 					http.Error(w, "not found", http.StatusNotFound)
 					return
 				}
 
-				userID, _ := r.Context().Value("user_id").(int64)
-				role, _ := r.Context().Value("role").(string)
-				if !canSubmitCompletion(cfg, task, userID, role) {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(resource)
+			case http.MethodPut:
+				if !canManageEvents(cfg, userID, role) {
 					http.Error(w, "access denied", http.StatusForbidden)
 					return
 				}
 
-				var payload struct {
-					Message string `json:"message"`
-				}
+				var payload model.Resource
 				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 					http.Error(w, "invalid json", http.StatusBadRequest)
 					return
 				}
+				payload.ID = id
 
-				if err := services.SubmitTaskCompletion(cfg, id, strings.TrimSpace(payload.Message)); err != nil {
-					http.Error(w, err.Error(), http.StatusInternalServerError)
+				if err := services.UpdateResource(cfg, &payload); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
 					return
 				}
 
-				w.WriteHeader(http.StatusNoContent)
-				return
-			case "review":
-				if r.Method != http.MethodPost {
-					http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(payload)
+			case http.MethodDelete:
+				if !canManageEvents(cfg, userID, role) {
+					http.Error(w, "access denied", http.StatusForbidden)
 					return
 				}
 
-				role, _ := r.Context().Value("role").(string)
-				userID, _ := r.Context().Value("user_id").(int64)
-
-				var payload struct {
-					Approved bool   `json:"approved"`
-					Message  string `json:"message"`
-				}
-				if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-					http.Error(w, "invalid json", http.StatusBadRequest)
+				if err := services.DeleteResource(cfg, id); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			}
+		}),
+		middleware.JWTMiddleware(cfg.JWTSecret),
+	))
 
-				reviewer := resolveReviewerName(cfg, userID)
+	// end-point управления токеном календарных лент
+	mux.Handle("/calendar/token", WrapMiddleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value("user_id").(int64)
+			if userID == 0 {
+				http.Error(w, "access denied", http.StatusForbidden)
+				return
+			}
+			telegramID := strconv.FormatInt(userID, 10)
 
-				task, err := services.GetTaskByID(cfg, id)
-				if err != nil || task == nil {
-					http.Error(w, "not found", http.StatusNotFound)
+			switch r.Method {
+			case http.MethodGet:
+				feeds, err := services.GetCalendarFeeds(cfg, telegramID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				if !canReviewProjectTasks(cfg, task.IdProject, userID, role) {
-					http.Error(w, "access denied", http.StatusForbidden)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(feeds)
+			case http.MethodPost:
+				feeds, err := services.RotateCalendarToken(cfg, telegramID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
 
-				if err := services.ReviewTaskCompletion(cfg, id, payload.Approved, reviewer, strings.TrimSpace(payload.Message)); err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(feeds)
+			case http.MethodDelete:
+				if err := services.RevokeCalendarToken(cfg, telegramID); err != nil {
 					http.Error(w, err.Error(), http.StatusInternalServerError)
 					return
 				}
-
 				w.WriteHeader(http.StatusNoContent)
-				return
 			default:
-				http.Error(w, "not found", http.StatusNotFound)
-				return
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			}
 		}),
 		middleware.JWTMiddleware(cfg.JWTSecret),
 	))
 
-	// dashboard data
-	mux.Handle("/dashboard", WrapMiddleware(
-		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodGet {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
+	// календарные ленты: клиенты календарей не умеют передавать JWT,
+	// поэтому доступ проверяется по отзываемому токену в пути
+	mux.HandleFunc("/calendar/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
 
-			username := r.URL.Query().Get("username")
-			if username == "" {
-				http.Error(w, "username is required", http.StatusBadRequest)
+		name := strings.TrimPrefix(r.URL.Path, "/calendar/")
+		if !strings.HasSuffix(name, ".ics") {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		user, err := services.ResolveCalendarToken(cfg, strings.TrimSuffix(name, ".ics"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+
+		userID, _ := strconv.ParseInt(user.TelegramID, 10, 64)
+		visible := projectVisibility(cfg, userID, user.Role)
+
+		var body string
+		switch {
+		case r.URL.Query().Get("project") != "":
+			projectID, err := strconv.Atoi(r.URL.Query().Get("project"))
+			if err != nil {
+				http.Error(w, "invalid project", http.StatusBadRequest)
 				return
 			}
 
-			projects, err := services.GetProjectsByUsername(cfg, username)
+			project, err := services.GetProjectByID(cfg, projectID)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-
-			projectMap := make(map[int]string)
-			for _, project := range projects {
-				projectMap[project.ID] = project.Title
-			}
-
-			normalizedUsername := normalizeUsername(username)
-			tasks := make([]model.Task, 0)
-			for _, project := range projects {
-				projectTasks, _ := services.GetTasksByProjectID(project.ID)
-				for _, task := range projectTasks {
-					if strings.ToLower(task.Status) == strings.ToLower("Выполнена") {
-						continue
-					}
-					if normalizedUsername != "" &&
-						normalizeUsername(task.User) != normalizedUsername {
-						continue
-					}
-					task.ProjectTitle = projectMap[task.IdProject]
-					tasks = append(tasks, task)
-				}
+			if project == nil || !canViewProject(cfg, project, userID, user.Role) {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
 			}
 
-			events, err := services.GetEvents(cfg)
+			body, err = services.BuildProjectCalendar(cfg, project)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-
-			payload := model.Dashboard{
-				Projects: projects,
-				Tasks:    tasks,
-				Events:   events,
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(payload)
-		}),
-		middleware.JWTMiddleware(cfg.JWTSecret),
-	))
-
-	// events list
-	mux.Handle("/events", WrapMiddleware(
-		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodGet {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		case r.URL.Query().Get("feed") == "events":
+			body, err = services.BuildEventsCalendar(cfg, visible)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-
-			events, err := services.GetEvents(cfg)
+		default:
+			body, err = services.BuildPersonalCalendar(cfg, user, visible)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
+		}
 
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(events)
-		}),
-		middleware.JWTMiddleware(cfg.JWTSecret),
-	))
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write([]byte(body))
+	})
 
 	mux.Handle("/search_users", WrapMiddleware(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -619,6 +2631,13 @@ func New(cfg *model.Config) *App {
 				return
 			}
 
+			userID, _ := r.Context().Value("user_id").(int64)
+			users, err = services.ApplyUserPrivacy(cfg, strconv.FormatInt(userID, 10), users)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(users)
 		}),
@@ -640,7 +2659,7 @@ func withCORS(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, PATCH, DELETE")
 
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
@@ -703,8 +2722,160 @@ func canManageProjectTasks(cfg *model.Config, projectID int, userID int64, role
 	return isLeaderRole(memberRole)
 }
 
-func canReviewProjectTasks(cfg *model.Config, projectID int, userID int64, role string) bool {
-	return canManageProjectTasks(cfg, projectID, userID, role)
+// reviewDelegator возвращает Telegram ID отсутствующего участника, которого
+// userID сейчас замещает и который сам проверяет задачи проекта как его
+// руководитель или участник-модератор. Глобальные права админа заместителю не
+// передаются.
+func reviewDelegator(cfg *model.Config, projectID int, userID int64) string {
+	if userID == 0 {
+		return ""
+	}
+	delegations, err := services.ActiveDelegations(cfg, strconv.FormatInt(userID, 10), time.Now())
+	if err != nil {
+		return ""
+	}
+	for _, delegation := range delegations {
+		delegatorID, err := strconv.ParseInt(delegation.TelegramID, 10, 64)
+		if err != nil {
+			continue
+		}
+		memberRole, isMember := getProjectMemberRole(cfg, projectID, delegatorID)
+		if !isMember {
+			continue
+		}
+		if isLeaderRole(memberRole) || permissions.IsModerator(resolveEffectiveRole(cfg, delegatorID, "")) {
+			return delegation.TelegramID
+		}
+	}
+	return ""
+}
+
+func canViewProject(cfg *model.Config, project *model.Project, userID int64, role string) bool {
+	if permissions.IsAdmin(resolveEffectiveRole(cfg, userID, role)) {
+		return true
+	}
+
+	switch project.Visibility {
+	case model.VisibilityAdmins:
+		return false
+	case model.VisibilityMembers:
+		_, isMember := projectMemberRole(cfg, project, userID)
+		return isMember
+	default:
+		return true
+	}
+}
+
+// filterVisibleEvents убирает события, привязанные к скрытым проектам.
+func filterVisibleEvents(events []model.Event, visible func(projectID int) bool) []model.Event {
+	result := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		if visible(event.ProjectID) {
+			result = append(result, event)
+		}
+	}
+	return result
+}
+
+// projectVisibility возвращает проверку, видит ли userID проект с данным id.
+// Результаты кешируются на время запроса; проект, который не удалось
+// загрузить, считается скрытым, удалённый — видимым: ограничивать нечем.
+func projectVisibility(cfg *model.Config, userID int64, role string) func(projectID int) bool {
+	cache := make(map[int]bool)
+	return func(projectID int) bool {
+		if projectID == 0 {
+			return true
+		}
+		if visible, ok := cache[projectID]; ok {
+			return visible
+		}
+		project, err := services.GetProjectByID(cfg, projectID)
+		visible := err == nil && (project == nil || canViewProject(cfg, project, userID, role))
+		cache[projectID] = visible
+		return visible
+	}
+}
+
+func filterVisibleProjects(cfg *model.Config, projects []model.Project, userID int64, role string, includeArchived bool) []model.Project {
+	visible := make([]model.Project, 0, len(projects))
+	for i := range projects {
+		if projects[i].Archived && !includeArchived {
+			continue
+		}
+		if !canViewProject(cfg, &projects[i], userID, role) {
+			continue
+		}
+		visible = append(visible, projects[i])
+	}
+	return visible
+}
+
+// searchVisibleTasks ищет задачи только в неархивных проектах, которые
+// пользователь может видеть.
+func searchVisibleTasks(cfg *model.Config, term string, userID int64, role string) ([]model.Task, error) {
+	projects, err := services.GetProjects(cfg)
+	if err != nil {
+		return nil, err
+	}
+	visible := make(map[int]bool)
+	for _, project := range filterVisibleProjects(cfg, projects, userID, role, false) {
+		visible[project.ID] = true
+	}
+
+	tasks, err := services.SearchTasks(cfg, term)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if visible[task.IdProject] {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+// parseTimeRange читает необязательные границы периода ?from=&to=.
+func parseTimeRange(cfg *model.Config, r *http.Request) (time.Time, time.Time, error) {
+	var from, to time.Time
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := services.ParseEventTime(cfg, value)
+		if err != nil {
+			return from, to, errors.New("invalid from")
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := services.ParseEventTime(cfg, value)
+		if err != nil {
+			return from, to, errors.New("invalid to")
+		}
+		to = parsed
+	}
+	return from, to, nil
+}
+
+func canManageEvents(cfg *model.Config, userID int64, role string) bool {
+	effectiveRole := resolveEffectiveRole(cfg, userID, role)
+	return permissions.IsAdmin(effectiveRole) || permissions.IsLeader(effectiveRole)
+}
+
+// canManageCapacity — видеть чужую загрузку и менять ёмкость участников
+// могут руководители и админы.
+func canManageCapacity(cfg *model.Config, userID int64, role string) bool {
+	effectiveRole := resolveEffectiveRole(cfg, userID, role)
+	return permissions.IsAdmin(effectiveRole) || permissions.IsLeader(effectiveRole)
+}
+
+// canEndorseSkills — подтверждать навыки участников могут руководители и админы.
+func canEndorseSkills(cfg *model.Config, userID int64, role string) bool {
+	effectiveRole := resolveEffectiveRole(cfg, userID, role)
+	return permissions.IsAdmin(effectiveRole) || permissions.IsLeader(effectiveRole)
+}
+
+func isProjectArchived(cfg *model.Config, projectID int) bool {
+	project, err := services.GetProjectByID(cfg, projectID)
+	return err == nil && project != nil && project.Archived
 }
 
 func getProjectMemberRole(cfg *model.Config, projectID int, userID int64) (string, bool) {
@@ -713,6 +2884,14 @@ func getProjectMemberRole(cfg *model.Config, projectID int, userID int64) (strin
 		return "", false
 	}
 
+	return projectMemberRole(cfg, project, userID)
+}
+
+func projectMemberRole(cfg *model.Config, project *model.Project, userID int64) (string, bool) {
+	if userID == 0 {
+		return "", false
+	}
+
 	user, err := services.GetUserByTelegramID(cfg, strconv.FormatInt(userID, 10))
 	if err != nil || user == nil {
 		return "", false
@@ -746,18 +2925,6 @@ func canSubmitCompletion(cfg *model.Config, task *model.Task, userID int64, role
 	return userID != 0
 }
 
-func normalizeMemberRole(role string) string {
-	role = strings.TrimSpace(role)
-	if role == "" {
-		return role
-	}
-
-	if permissions.MustIncludeModerator(role) && !permissions.IsModerator(role) {
-		return role + ", Модератор"
-	}
-	return role
-}
-
 func invalidRoleCombo(role string) bool {
 	roles := permissions.ParseRoles(role)
 	return roles["разработчик"] && (roles["админ"] || roles["admin"])
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// maxAbsenceLength ограничивает один период отсутствия.
+const maxAbsenceLength = 180 * 24 * time.Hour
+
+// GetAbsence возвращает период отсутствия участника или nil, если он не задан.
+func GetAbsence(cfg *model.Config, telegramID string) (*model.Absence, error) {
+	absence, err := repository.GetAbsence(cfg, telegramID)
+	if err != nil || absence == nil {
+		return nil, err
+	}
+	fillAbsence(cfg, absence, time.Now())
+	return absence, nil
+}
+
+// SetAbsence задаёт период отсутствия и заместителя. Без from период
+// начинается сегодня.
+func SetAbsence(cfg *model.Config, telegramID string, req *model.AbsenceRequest) (*model.Absence, error) {
+	loc := config.Location(cfg)
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	from := today
+	if value := strings.TrimSpace(req.From); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, loc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from, expected YYYY-MM-DD")
+		}
+		from = parsed
+	}
+	until, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(req.Until), loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid until, expected YYYY-MM-DD")
+	}
+	if until.Before(from) {
+		return nil, fmt.Errorf("until must not be before from")
+	}
+	if until.Before(today) {
+		return nil, fmt.Errorf("until must not be in the past")
+	}
+	if until.Sub(from) > maxAbsenceLength {
+		return nil, fmt.Errorf("absence must not exceed %d days", int(maxAbsenceLength/(24*time.Hour)))
+	}
+
+	delegate, err := resolveDelegate(cfg, req.Delegate)
+	if err != nil {
+		return nil, err
+	}
+	if delegate.TelegramID == telegramID {
+		return nil, fmt.Errorf("you cannot delegate to yourself")
+	}
+
+	absence := &model.Absence{
+		TelegramID: telegramID,
+		From:       from.Format("2006-01-02"),
+		Until:      until.Format("2006-01-02"),
+		DelegateID: delegate.TelegramID,
+		Note:       strings.TrimSpace(req.Note),
+	}
+	if err := repository.SaveAbsence(cfg, absence); err != nil {
+		return nil, err
+	}
+
+	fillAbsence(cfg, absence, now)
+	return absence, nil
+}
+
+func ClearAbsence(cfg *model.Config, telegramID string) error {
+	return repository.DeleteAbsence(cfg, telegramID)
+}
+
+func resolveDelegate(cfg *model.Config, value string) (*model.UserProfile, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("delegate is required")
+	}
+
+	var (
+		user *model.UserProfile
+		err  error
+	)
+	if _, parseErr := strconv.ParseInt(value, 10, 64); parseErr == nil {
+		user, err = GetUserByTelegramID(cfg, value)
+	} else {
+		user, err = GetUserByUsername(cfg, value)
+	}
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, fmt.Errorf("delegate %q not found", value)
+		}
+		return nil, err
+	}
+	if !user.Active {
+		return nil, fmt.Errorf("delegate %q is deactivated", value)
+	}
+	return user, nil
+}
+
+func fillAbsence(cfg *model.Config, absence *model.Absence, now time.Time) {
+	today := now.In(config.Location(cfg)).Format("2006-01-02")
+	absence.Active = absence.From <= today && today <= absence.Until
+	if delegate, err := GetUserByTelegramID(cfg, absence.DelegateID); err == nil {
+		absence.Delegate = delegate.FullName
+	}
+}
+
+// activeAbsences возвращает действующие сейчас отсутствия по Telegram ID
+// отсутствующего.
+func activeAbsences(cfg *model.Config, now time.Time) (map[string]model.Absence, error) {
+	absences, err := repository.GetAbsencesOn(cfg, now.In(config.Location(cfg)).Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]model.Absence, len(absences))
+	for _, absence := range absences {
+		if absence.DelegateID == "" {
+			continue
+		}
+		absence.Active = true
+		active[absence.TelegramID] = absence
+	}
+	return active, nil
+}
+
+// ActiveDelegations возвращает отсутствия, в которых delegateID сейчас
+// замещает коллег.
+func ActiveDelegations(cfg *model.Config, delegateID string, now time.Time) ([]model.Absence, error) {
+	absences, err := activeAbsences(cfg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	delegations := make([]model.Absence, 0)
+	for _, absence := range absences {
+		if absence.DelegateID == delegateID {
+			delegations = append(delegations, absence)
+		}
+	}
+	return delegations, nil
+}
+
+// delegateFor возвращает действующее отсутствие участника с заместителем или
+// nil, если участник на месте. Заместитель сам может отсутствовать — тогда
+// уведомление всё равно уходит ему, цепочки замещений не строятся.
+func delegateFor(cfg *model.Config, telegramID string, now time.Time) *model.Absence {
+	absences, err := activeAbsences(cfg, now)
+	if err != nil {
+		logger.Error.Printf("delegateFor: failed to load absences: %v\n", err)
+		return nil
+	}
+	absence, ok := absences[telegramID]
+	if !ok {
+		return nil
+	}
+	return &absence
+}
+
+func formatAbsenceDate(value string) string {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t.Format("02.01.2006")
+	}
+	return value
+}
+
+// GetTaskHistory возвращает историю задачи с именами участников.
+func GetTaskHistory(cfg *model.Config, taskID int) ([]model.TaskHistoryEntry, error) {
+	entries, err := repository.GetTaskHistory(cfg, taskID, "")
+	if err != nil {
+		return nil, err
+	}
+	return withHistoryNames(cfg, entries)
+}
+
+func withHistoryNames(cfg *model.Config, entries []model.TaskHistoryEntry) ([]model.TaskHistoryEntry, error) {
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.TelegramID] = user.FullName
+	}
+
+	for i := range entries {
+		entries[i].Actor = names[entries[i].ActorID]
+		entries[i].OnBehalfOf = names[entries[i].OnBehalfOfID]
+	}
+	return entries, nil
+}
+
+func recordTaskHistory(cfg *model.Config, entry model.TaskHistoryEntry) {
+	if err := repository.AddTaskHistory(cfg, &entry); err != nil {
+		logger.Error.Printf("recordTaskHistory: failed to record %s for task %d: %v\n", entry.Action, entry.TaskID, err)
+	}
+}
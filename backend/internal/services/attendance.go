@@ -0,0 +1,581 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+const (
+	checkInCodeTTL = 15 * time.Minute
+	// maxCheckInFailures неверных кодов за checkInCodeTTL блокируют отметку
+	// по коду, чтобы шестизначный код нельзя было перебрать
+	maxCheckInFailures = 5
+)
+
+var (
+	ErrNotInvited             = fmt.Errorf("user is not invited to event")
+	ErrTooManyCheckInAttempts = fmt.Errorf("too many check-in attempts, try again later")
+	ErrInvalidOccurrence      = fmt.Errorf("invalid occurrence")
+	ErrCheckInCodeOccurrence  = fmt.Errorf("check-in code was issued for another occurrence")
+)
+
+func GetEventInvitations(cfg *model.Config, eventID int) ([]model.EventInvitation, error) {
+	return repository.GetEventInvitations(cfg, eventID)
+}
+
+func SetEventInvitations(cfg *model.Config, eventID int, invitations []model.EventInvitation) error {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+
+	normalized := make([]model.EventInvitation, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitation.Scope = strings.ToLower(strings.TrimSpace(invitation.Scope))
+		invitation.Value = strings.TrimSpace(invitation.Value)
+
+		switch invitation.Scope {
+		case model.InvitationScopeAll:
+			invitation.Value = ""
+		case model.InvitationScopeProject:
+			projectID, err := strconv.Atoi(invitation.Value)
+			if err != nil {
+				return fmt.Errorf("invalid project id: %s", invitation.Value)
+			}
+			if project, err := GetProjectByID(cfg, projectID); err != nil || project == nil {
+				return fmt.Errorf("project not found: %s", invitation.Value)
+			}
+		case model.InvitationScopeRole:
+			if invitation.Value == "" {
+				return fmt.Errorf("role is required")
+			}
+		default:
+			return fmt.Errorf("invalid invitation scope: %s", invitation.Scope)
+		}
+		normalized = append(normalized, invitation)
+	}
+
+	return repository.ReplaceEventInvitations(cfg, eventID, normalized)
+}
+
+// eventInvitations возвращает приглашения события; событие проекта
+// неявно приглашает всех участников этого проекта.
+func eventInvitations(cfg *model.Config, event *model.Event) ([]model.EventInvitation, error) {
+	invitations, err := repository.GetEventInvitations(cfg, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.ProjectID != 0 {
+		invitations = append(invitations, model.EventInvitation{
+			Scope: model.InvitationScopeProject,
+			Value: strconv.Itoa(event.ProjectID),
+		})
+	}
+
+	return invitations, nil
+}
+
+// GetEventInvitees раскрывает приглашения события в список пользователей.
+func GetEventInvitees(cfg *model.Config, eventID int) (map[string]model.UserProfile, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return map[string]model.UserProfile{}, nil
+	}
+
+	invitations, err := eventInvitations(cfg, event)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveInvitees(cfg, invitations, users), nil
+}
+
+func resolveInvitees(cfg *model.Config, invitations []model.EventInvitation, users []model.UserProfile) map[string]model.UserProfile {
+	invitees := make(map[string]model.UserProfile)
+	byUsername := make(map[string]model.UserProfile, len(users))
+	for _, user := range users {
+		byUsername[normalizeUsername(user.Username)] = user
+	}
+
+	for _, invitation := range invitations {
+		switch invitation.Scope {
+		case model.InvitationScopeAll:
+			for _, user := range users {
+				invitees[user.TelegramID] = user
+			}
+		case model.InvitationScopeProject:
+			projectID, _ := strconv.Atoi(invitation.Value)
+			project, err := GetProjectByID(cfg, projectID)
+			if err != nil || project == nil {
+				continue
+			}
+			for _, member := range project.Members {
+				if user, ok := byUsername[normalizeUsername(member.Username)]; ok {
+					invitees[user.TelegramID] = user
+				} else if member.TelegramID != "" {
+					invitees[member.TelegramID] = model.UserProfile{
+						TelegramID: member.TelegramID,
+						Username:   member.Username,
+						FullName:   member.FullName,
+					}
+				}
+			}
+		case model.InvitationScopeRole:
+			role := strings.ToLower(invitation.Value)
+			for _, user := range users {
+				if permissions.ParseRoles(user.Role)[role] {
+					invitees[user.TelegramID] = user
+				}
+			}
+		}
+	}
+
+	// отключённые участники не получают приглашений
+	for _, user := range users {
+		if !user.Active {
+			delete(invitees, user.TelegramID)
+		}
+	}
+
+	return invitees
+}
+
+// isInvited проверяет приглашение; событие без приглашений и без проекта
+// открыто для всех.
+func isInvited(cfg *model.Config, eventID int, telegramID string) (bool, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil || event == nil {
+		return false, err
+	}
+
+	invitations, err := eventInvitations(cfg, event)
+	if err != nil {
+		return false, err
+	}
+	if len(invitations) == 0 {
+		return true, nil
+	}
+
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return false, err
+	}
+	_, ok := resolveInvitees(cfg, invitations, users)[telegramID]
+	return ok, nil
+}
+
+// GetUserEvents возвращает события периода, которые касаются пользователя:
+// он приглашён, событие относится к его проекту или открыто для всех.
+func GetUserEvents(cfg *model.Config, telegramID string, from time.Time, to time.Time) ([]model.Event, error) {
+	events, err := GetEvents(cfg, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// повторения одной серии приходят с одинаковым id
+	visible := make(map[int]bool)
+	result := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		allowed, ok := visible[event.ID]
+		if !ok {
+			invitations, err := eventInvitations(cfg, &event)
+			if err != nil {
+				return nil, err
+			}
+			_, invited := resolveInvitees(cfg, invitations, users)[telegramID]
+			allowed = len(invitations) == 0 || (telegramID != "" && invited)
+			visible[event.ID] = allowed
+		}
+		if allowed {
+			result = append(result, event)
+		}
+	}
+
+	return result, nil
+}
+
+// resolveOccurrenceDate проверяет дату повторения, к которой относится
+// отметка. У разовых событий она всегда пустая; для серии без даты берётся
+// сегодняшнее повторение.
+func resolveOccurrenceDate(cfg *model.Config, event *model.Event, date string) (string, error) {
+	if event.Recurrence == nil {
+		return "", nil
+	}
+
+	date = strings.TrimSpace(date)
+	if date == "" {
+		date = time.Now().In(config.Location(cfg)).Format("2006-01-02")
+	}
+	if _, err := findOccurrence(cfg, event, date); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidOccurrence, err)
+	}
+
+	overrides, err := repository.GetEventOccurrences(cfg, event.ID)
+	if err != nil {
+		return "", err
+	}
+	if overrides[date].Cancelled {
+		return "", fmt.Errorf("%w: %s is cancelled", ErrInvalidOccurrence, date)
+	}
+	return date, nil
+}
+
+func SetRSVP(cfg *model.Config, eventID int, occurrenceDate string, telegramID string, status string) error {
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case model.RSVPGoing, model.RSVPMaybe, model.RSVPNo:
+	default:
+		return fmt.Errorf("invalid rsvp status")
+	}
+
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+	occurrenceDate, err = resolveOccurrenceDate(cfg, event, occurrenceDate)
+	if err != nil {
+		return err
+	}
+
+	invited, err := isInvited(cfg, eventID, telegramID)
+	if err != nil {
+		return err
+	}
+	if !invited {
+		return ErrNotInvited
+	}
+
+	return repository.SaveRSVP(cfg, eventID, occurrenceDate, telegramID, status)
+}
+
+// CheckInByOrganizer отмечает присутствие участника организатором.
+func CheckInByOrganizer(cfg *model.Config, eventID int, occurrenceDate string, telegramID string, organizer string) error {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+	occurrenceDate, err = resolveOccurrenceDate(cfg, event, occurrenceDate)
+	if err != nil {
+		return err
+	}
+
+	if _, err := GetUserByTelegramID(cfg, telegramID); err != nil {
+		return err
+	}
+
+	return repository.SaveCheckIn(cfg, eventID, occurrenceDate, telegramID, organizer)
+}
+
+// CheckInWithCode отмечает присутствие по коду, который организатор показывает на событии.
+func CheckInWithCode(cfg *model.Config, eventID int, occurrenceDate string, telegramID string, code string) error {
+	since := time.Now().Add(-checkInCodeTTL).UTC().Format(time.RFC3339)
+	failures, err := repository.CountCheckInFailures(cfg, eventID, telegramID, since)
+	if err != nil {
+		return err
+	}
+	if failures >= maxCheckInFailures {
+		return ErrTooManyCheckInAttempts
+	}
+
+	expected, err := repository.GetCheckInCode(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if expected == nil || expected.Code == "" || strings.TrimSpace(code) != expected.Code {
+		if err := repository.RecordCheckInFailure(cfg, eventID, telegramID); err != nil {
+			return err
+		}
+		return fmt.Errorf("invalid check-in code")
+	}
+
+	expires, err := time.Parse(time.RFC3339, expected.ExpiresAt)
+	if err != nil || time.Now().After(expires) {
+		return fmt.Errorf("check-in code expired")
+	}
+
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+	// код показывают на конкретной встрече: без даты отметка идёт в неё,
+	// отметиться кодом в другом повторении нельзя
+	if occurrenceDate == "" {
+		occurrenceDate = expected.OccurrenceDate
+	}
+	occurrenceDate, err = resolveOccurrenceDate(cfg, event, occurrenceDate)
+	if err != nil {
+		return err
+	}
+	if occurrenceDate != expected.OccurrenceDate {
+		return ErrCheckInCodeOccurrence
+	}
+
+	invited, err := isInvited(cfg, eventID, telegramID)
+	if err != nil {
+		return err
+	}
+	if !invited {
+		return ErrNotInvited
+	}
+
+	return repository.SaveCheckIn(cfg, eventID, occurrenceDate, telegramID, "code")
+}
+
+// IssueCheckInCode выпускает код для повторения occurrenceDate (у серии без
+// даты — для сегодняшнего). Новый код заменяет прежний.
+func IssueCheckInCode(cfg *model.Config, eventID int, occurrenceDate string) (*model.CheckInCode, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+	occurrenceDate, err = resolveOccurrenceDate(cfg, event, occurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return nil, err
+	}
+
+	code := &model.CheckInCode{
+		Code:           fmt.Sprintf("%06d", n.Int64()),
+		OccurrenceDate: occurrenceDate,
+		ExpiresAt:      time.Now().Add(checkInCodeTTL).Format(time.RFC3339),
+	}
+	if err := repository.SaveCheckInCode(cfg, eventID, code); err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+// GetEventAttendance собирает ответы и отметки события; у повторяющегося
+// события — одного повторения.
+func GetEventAttendance(cfg *model.Config, eventID int, occurrenceDate string) (*model.EventAttendance, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	occurrenceDate, err = resolveOccurrenceDate(cfg, event, occurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	invitees, err := GetEventInvitees(cfg, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := repository.GetEventAttendance(cfg, eventID, occurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := &model.EventAttendance{
+		EventID:        eventID,
+		OccurrenceDate: occurrenceDate,
+		Invited:        len(invitees),
+		Attendees:      make([]model.EventAttendee, 0, len(invitees)),
+	}
+
+	// индекс по позиции, а не по указателю: append может переложить срез
+	byID := make(map[string]int, len(invitees)+len(records))
+	for telegramID, user := range invitees {
+		byID[telegramID] = len(attendance.Attendees)
+		attendance.Attendees = append(attendance.Attendees, model.EventAttendee{
+			TelegramID: telegramID,
+			Username:   user.Username,
+			FullName:   user.FullName,
+			Invited:    true,
+		})
+	}
+
+	for _, record := range records {
+		i, ok := byID[record.TelegramID]
+		if !ok {
+			entry := model.EventAttendee{TelegramID: record.TelegramID}
+			if user, err := GetUserByTelegramID(cfg, record.TelegramID); err == nil && user != nil {
+				entry.Username = user.Username
+				entry.FullName = user.FullName
+			}
+			i = len(attendance.Attendees)
+			byID[record.TelegramID] = i
+			attendance.Attendees = append(attendance.Attendees, entry)
+		}
+
+		attendee := &attendance.Attendees[i]
+		attendee.RSVP = record.RSVP
+		attendee.CheckedIn = record.CheckedInAt != ""
+		attendee.CheckedInAt = record.CheckedInAt
+	}
+
+	for _, attendee := range attendance.Attendees {
+		switch attendee.RSVP {
+		case model.RSVPGoing:
+			attendance.Going++
+		case model.RSVPMaybe:
+			attendance.Maybe++
+		case model.RSVPNo:
+			attendance.Declined++
+		}
+		if attendee.CheckedIn {
+			attendance.CheckedIn++
+		}
+	}
+
+	sort.Slice(attendance.Attendees, func(i, j int) bool {
+		return attendance.Attendees[i].FullName < attendance.Attendees[j].FullName
+	})
+
+	return attendance, nil
+}
+
+// GetAttendanceReport считает долю посещённых событий по каждому участнику
+// среди событий, начавшихся в [from, to); каждое повторение серии считается
+// отдельным событием. Для событий без приглашений приглашёнными считаются
+// только те, кто ответил или отметился.
+func GetAttendanceReport(cfg *model.Config, from time.Time, to time.Time) ([]model.AttendanceReportRow, error) {
+	now := time.Now()
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+
+	events, err := GetEvents(cfg, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := repository.GetAttendanceForEvents(cfg, formatEventBound(from), formatEventBound(to))
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	usersByID := make(map[string]model.UserProfile, len(users))
+	for _, user := range users {
+		usersByID[user.TelegramID] = user
+	}
+
+	type occurrenceKey struct {
+		eventID int
+		date    string
+	}
+	recordsByOccurrence := make(map[occurrenceKey][]model.AttendanceRecord)
+	for _, record := range records {
+		key := occurrenceKey{record.EventID, record.OccurrenceDate}
+		recordsByOccurrence[key] = append(recordsByOccurrence[key], record)
+	}
+
+	rows := make(map[string]*model.AttendanceReportRow)
+	row := func(telegramID string) *model.AttendanceReportRow {
+		if r, ok := rows[telegramID]; ok {
+			return r
+		}
+		r := &model.AttendanceReportRow{TelegramID: telegramID}
+		if user, ok := usersByID[telegramID]; ok {
+			r.Username = user.Username
+			r.FullName = user.FullName
+		}
+		rows[telegramID] = r
+		return r
+	}
+
+	inviteesByEvent := make(map[int]map[string]model.UserProfile)
+	for _, event := range events {
+		start, err := time.Parse(time.RFC3339, event.StartAt)
+		if err != nil || (!from.IsZero() && start.Before(from)) || !start.Before(to) {
+			continue
+		}
+
+		// повторения одной серии делят приглашения
+		invitees, ok := inviteesByEvent[event.ID]
+		if !ok {
+			invitations, err := eventInvitations(cfg, &event)
+			if err != nil {
+				return nil, err
+			}
+			invitees = map[string]model.UserProfile{}
+			if len(invitations) > 0 {
+				invitees = resolveInvitees(cfg, invitations, users)
+			}
+			inviteesByEvent[event.ID] = invitees
+		}
+
+		occurrenceRecords := recordsByOccurrence[occurrenceKey{event.ID, event.OccurrenceDate}]
+		invited := make(map[string]bool, len(invitees))
+		for telegramID := range invitees {
+			invited[telegramID] = true
+		}
+		for _, record := range occurrenceRecords {
+			invited[record.TelegramID] = true
+		}
+
+		for telegramID := range invited {
+			row(telegramID).Invited++
+		}
+		for _, record := range occurrenceRecords {
+			if record.CheckedInAt != "" {
+				row(record.TelegramID).Attended++
+			}
+		}
+	}
+
+	report := make([]model.AttendanceReportRow, 0, len(rows))
+	for _, r := range rows {
+		if r.Invited > 0 {
+			r.Rate = float64(r.Attended) / float64(r.Invited)
+		}
+		report = append(report, *r)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Rate != report[j].Rate {
+			return report[i].Rate > report[j].Rate
+		}
+		return report[i].FullName < report[j].FullName
+	})
+
+	return report, nil
+}
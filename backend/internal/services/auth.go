@@ -22,6 +22,10 @@ func TelegramAuth(req *model.AuthRequest, cfg *model.Config) (*model.AuthRespons
 		logger.Error.Printf("TelegramAuth: failed to get user by TelegramID '%s': %v\n", telegramID, err)
 		return nil, err
 	}
+	if !dbUser.Active {
+		logger.Error.Printf("TelegramAuth: login attempt for deactivated TelegramID '%s'\n", telegramID)
+		return nil, ErrUserDeactivated
+	}
 
 	username := req.Username
 	if username == "" {
@@ -60,6 +64,7 @@ func TelegramAuth(req *model.AuthRequest, cfg *model.Config) (*model.AuthRespons
 		NumberOfPhone:  dbUser.NumberOfPhone,
 		Role:           dbUser.Role,
 		MayToOpen:      dbUser.MayToOpen,
+		Active:         dbUser.Active,
 	}
 
 	ttl, err := time.ParseDuration(cfg.JWTTTL)
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// birthdayWindow — период по умолчанию для календаря дней рождения.
+const birthdayWindow = 30 * 24 * time.Hour
+
+// maxBirthdayRange ограничивает запрос календаря одним годом.
+const maxBirthdayRange = 366 * 24 * time.Hour
+
+const (
+	birthdayGreeting      = "greeting"
+	birthdayAdminReminder = "admin_reminder"
+)
+
+// birthdayPattern разбирает даты вида 02.01.2006, 2.1.06, 02/01/2006,
+// 02-01-2006 и 02.01 (без года).
+var birthdayPattern = regexp.MustCompile(`^(\d{1,2})[./-](\d{1,2})(?:[./-](\d{2}|\d{4}))?$`)
+
+type birthdayDate struct {
+	Day   int
+	Month int
+	Year  int
+}
+
+// parseStoredBirthday разбирает дату рождения в любом из форматов, которые
+// встречаются в базе. Год может отсутствовать.
+func parseStoredBirthday(value string) (birthdayDate, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return birthdayDate{}, false
+	}
+
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return birthdayDate{Day: t.Day(), Month: int(t.Month()), Year: t.Year()}, true
+		}
+	}
+
+	match := birthdayPattern.FindStringSubmatch(value)
+	if match == nil {
+		return birthdayDate{}, false
+	}
+
+	day, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	year := 0
+	if match[3] != "" {
+		year, _ = strconv.Atoi(match[3])
+		if len(match[3]) == 2 {
+			// двузначный год: 05 → 2005, 98 → 1998
+			year += 1900
+			if year+100 <= time.Now().Year() {
+				year += 100
+			}
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return birthdayDate{}, false
+	}
+	// 29 февраля без года допустимо, с годом проверяем по календарю
+	checkYear := year
+	if checkYear == 0 {
+		checkYear = 2000
+	}
+	if time.Date(checkYear, time.Month(month), day, 0, 0, 0, 0, time.UTC).Day() != day {
+		return birthdayDate{}, false
+	}
+
+	return birthdayDate{Day: day, Month: month, Year: year}, true
+}
+
+// occurrence возвращает день рождения в указанном году. В невисокосный год
+// 29 февраля отмечается 28 февраля.
+func (b birthdayDate) occurrence(year int, loc *time.Location) time.Time {
+	day := b.Day
+	if b.Month == 2 && day == 29 && time.Date(year, 2, 29, 0, 0, 0, 0, loc).Day() != 29 {
+		day = 28
+	}
+	return time.Date(year, time.Month(b.Month), day, 0, 0, 0, 0, loc)
+}
+
+// GetBirthdays возвращает дни рождения активных участников в периоде
+// [from, to] по дням, отсортированные по дате. Участники, отказавшиеся от
+// поздравлений, не показываются.
+func GetBirthdays(cfg *model.Config, from time.Time, to time.Time) ([]model.Birthday, error) {
+	loc := config.Location(cfg)
+	if from.IsZero() {
+		from = time.Now()
+	}
+	from = from.In(loc)
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+
+	if to.IsZero() {
+		to = start.Add(birthdayWindow)
+	}
+	if to.Before(start) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+	if to.Sub(start) > maxBirthdayRange {
+		return nil, fmt.Errorf("range must not exceed one year")
+	}
+	to = to.In(loc)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc)
+
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	optOuts, err := repository.GetBirthdayOptOuts(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	birthdays := make([]model.Birthday, 0)
+	for _, user := range users {
+		if !user.Active || optOuts[user.TelegramID] {
+			continue
+		}
+		date, ok := parseStoredBirthday(user.DateOfBirthday)
+		if !ok {
+			continue
+		}
+
+		for year := start.Year(); year <= end.Year(); year++ {
+			day := date.occurrence(year, loc)
+			if day.Before(start) || day.After(end) {
+				continue
+			}
+
+			birthday := model.Birthday{
+				TelegramID: user.TelegramID,
+				Username:   user.Username,
+				FullName:   user.FullName,
+				Date:       day.Format("2006-01-02"),
+			}
+			if date.Year > 0 && year > date.Year {
+				birthday.Age = year - date.Year
+			}
+			birthdays = append(birthdays, birthday)
+		}
+	}
+
+	sort.SliceStable(birthdays, func(i, j int) bool {
+		if birthdays[i].Date != birthdays[j].Date {
+			return birthdays[i].Date < birthdays[j].Date
+		}
+		return birthdays[i].FullName < birthdays[j].FullName
+	})
+
+	return birthdays, nil
+}
+
+// SendBirthdayGreetings поздравляет именинников в общем чате и напоминает
+// админам о завтрашних днях рождения. Вызывается периодически; каждое
+// уведомление отправляется один раз, начиная с BIRTHDAY_GREETING_HOUR.
+func SendBirthdayGreetings(cfg *model.Config, now time.Time) {
+	loc := config.Location(cfg)
+	now = now.In(loc)
+	if now.Hour() < config.BirthdayGreetingHour(cfg) {
+		return
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	birthdays, err := GetBirthdays(cfg, today, tomorrow)
+	if err != nil {
+		logger.Error.Printf("SendBirthdayGreetings: failed to load birthdays: %v\n", err)
+		return
+	}
+
+	chatID, _ := strconv.ParseInt(strings.TrimSpace(cfg.BirthdayChatID), 10, 64)
+	upcoming := make([]model.Birthday, 0)
+
+	for _, birthday := range birthdays {
+		if birthday.Date != today.Format("2006-01-02") {
+			if claimBirthday(cfg, birthday, birthdayAdminReminder, nil) {
+				upcoming = append(upcoming, birthday)
+			}
+			continue
+		}
+
+		if chatID == 0 {
+			continue
+		}
+		claimBirthday(cfg, birthday, birthdayGreeting, []model.OutboxMessage{
+			{ChatID: chatID, EventType: model.NotifyBirthday, Text: birthdayGreetingMessage(birthday)},
+		})
+	}
+
+	if len(upcoming) == 0 {
+		return
+	}
+
+	lines := make([]string, 0, len(upcoming))
+	for _, birthday := range upcoming {
+		line := "• " + html.EscapeString(birthday.FullName)
+		if birthday.Username != "" {
+			line += " (@" + html.EscapeString(strings.TrimPrefix(birthday.Username, "@")) + ")"
+		}
+		if birthday.Age > 0 {
+			line += fmt.Sprintf(", исполняется %d", birthday.Age)
+		}
+		lines = append(lines, line)
+	}
+	notifyAdmins(cfg, fmt.Sprintf(
+		"🎂 Завтра, %s, день рождения:\n\n%s",
+		tomorrow.Format("02.01"),
+		strings.Join(lines, "\n"),
+	))
+}
+
+// claimBirthday отмечает уведомление отправленным и в той же транзакции
+// ставит в очередь notifications.
+func claimBirthday(cfg *model.Config, birthday model.Birthday, kind string, notifications []model.OutboxMessage) bool {
+	claimed, err := repository.ClaimBirthdayNotification(cfg, birthday.TelegramID, kind, birthday.Date, notifications)
+	if err != nil {
+		logger.Error.Printf("SendBirthdayGreetings: failed to claim %s for %s: %v\n", kind, birthday.TelegramID, err)
+		return false
+	}
+	return claimed
+}
+
+func birthdayGreetingMessage(birthday model.Birthday) string {
+	name := html.EscapeString(birthday.FullName)
+	if birthday.Username != "" {
+		name += " (@" + html.EscapeString(strings.TrimPrefix(birthday.Username, "@")) + ")"
+	}
+	return fmt.Sprintf(
+		"🎉 Сегодня день рождения у %s!\n\n"+
+			"Поздравляем и желаем успехов во всех проектах! 🎂",
+		name,
+	)
+}
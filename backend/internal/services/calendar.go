@@ -0,0 +1,210 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/calendar"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+const (
+	calendarUIDDomain   = "taskmanager-itc"
+	calendarEventsSince = 90 * 24 * time.Hour
+)
+
+func GetCalendarFeeds(cfg *model.Config, telegramID string) (*model.CalendarFeeds, error) {
+	token, err := repository.GetCalendarToken(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return RotateCalendarToken(cfg, telegramID)
+	}
+	return calendarFeeds(cfg, token), nil
+}
+
+// RotateCalendarToken выпускает новый токен ленты; старые ссылки перестают работать.
+func RotateCalendarToken(cfg *model.Config, telegramID string) (*model.CalendarFeeds, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := repository.SaveCalendarToken(cfg, telegramID, token); err != nil {
+		return nil, err
+	}
+	return calendarFeeds(cfg, token), nil
+}
+
+func RevokeCalendarToken(cfg *model.Config, telegramID string) error {
+	return repository.DeleteCalendarToken(cfg, telegramID)
+}
+
+// ResolveCalendarToken возвращает владельца токена или nil, если токен отозван.
+func ResolveCalendarToken(cfg *model.Config, token string) (*model.UserProfile, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, nil
+	}
+
+	telegramID, err := repository.GetTelegramIDByCalendarToken(cfg, token)
+	if err != nil || telegramID == "" {
+		return nil, err
+	}
+
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		if err == ErrUserNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !user.Active {
+		return nil, nil
+	}
+	return user, nil
+}
+
+// BuildEventsCalendar собирает ленту событий. visible решает, видит ли
+// владелец ленты проект, к которому привязано событие.
+func BuildEventsCalendar(cfg *model.Config, visible func(projectID int) bool) (string, error) {
+	entries, err := eventCalendarEntries(cfg, visible)
+	if err != nil {
+		return "", err
+	}
+	return calendar.Render("События ИТС", entries), nil
+}
+
+func BuildPersonalCalendar(cfg *model.Config, user *model.UserProfile, visible func(projectID int) bool) (string, error) {
+	telegramID, _ := strconv.ParseInt(user.TelegramID, 10, 64)
+	tasks, err := repository.GetTasksByAssignee(cfg, telegramID, normalizeUsername(user.Username))
+	if err != nil {
+		return "", err
+	}
+
+	entries := taskCalendarEntries(cfg, tasks)
+	events, err := eventCalendarEntries(cfg, visible)
+	if err != nil {
+		return "", err
+	}
+	entries = append(entries, events...)
+
+	return calendar.Render("Мои задачи и события ИТС", entries), nil
+}
+
+func BuildProjectCalendar(cfg *model.Config, project *model.Project) (string, error) {
+	tasks, err := repository.GetTasksByProjectID(cfg, project.ID)
+	if err != nil {
+		return "", err
+	}
+
+	entries := taskCalendarEntries(cfg, tasks)
+	milestones, err := repository.GetMilestonesByProjectID(cfg, project.ID)
+	if err != nil {
+		return "", err
+	}
+	for _, milestone := range milestones {
+		due, err := time.Parse("2006-01-02", milestone.DueDate)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, calendar.Entry{
+			UID:     fmt.Sprintf("milestone-%d@%s", milestone.ID, calendarUIDDomain),
+			Summary: "🏁 " + milestone.Title,
+			Start:   due,
+			AllDay:  true,
+		})
+	}
+
+	return calendar.Render(project.Title, entries), nil
+}
+
+func taskCalendarEntries(cfg *model.Config, tasks []model.Task) []calendar.Entry {
+	projectTitles := make(map[int]string)
+	entries := make([]calendar.Entry, 0, len(tasks))
+	for _, task := range tasks {
+		if strings.EqualFold(task.Status, "Выполнена") {
+			continue
+		}
+		deadline, err := parseTaskDate(task.Deadline)
+		if err != nil {
+			continue
+		}
+
+		title, ok := projectTitles[task.IdProject]
+		if !ok {
+			if project, err := GetProjectByID(cfg, task.IdProject); err == nil && project != nil {
+				title = project.Title
+			}
+			projectTitles[task.IdProject] = title
+		}
+
+		entries = append(entries, calendar.Entry{
+			UID:     fmt.Sprintf("task-%d@%s", task.ID, calendarUIDDomain),
+			Summary: "⏰ " + task.Title,
+			Description: fmt.Sprintf(
+				"Проект: %s\nСтатус: %s\nИсполнитель: %s\n\n%s",
+				title,
+				task.Status,
+				task.User,
+				task.Description,
+			),
+			Start:  deadline,
+			AllDay: true,
+		})
+	}
+	return entries
+}
+
+func eventCalendarEntries(cfg *model.Config, visible func(projectID int) bool) ([]calendar.Entry, error) {
+	events, err := GetEvents(cfg, time.Now().Add(-calendarEventsSince), time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]calendar.Entry, 0, len(events))
+	for _, event := range events {
+		if event.ProjectID != 0 && !visible(event.ProjectID) {
+			continue
+		}
+		start, err := time.Parse(time.RFC3339, event.StartAt)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, event.EndAt)
+		if err != nil {
+			end = start
+		}
+
+		uid := fmt.Sprintf("event-%d@%s", event.ID, calendarUIDDomain)
+		if event.OccurrenceDate != "" {
+			uid = fmt.Sprintf("event-%d-%s@%s", event.ID, strings.ReplaceAll(event.OccurrenceDate, "-", ""), calendarUIDDomain)
+		}
+
+		entries = append(entries, calendar.Entry{
+			UID:         uid,
+			Summary:     event.Title,
+			Description: event.Description,
+			Start:       start,
+			End:         end,
+		})
+	}
+	return entries, nil
+}
+
+func calendarFeeds(cfg *model.Config, token string) *model.CalendarFeeds {
+	base := strings.TrimRight(cfg.PublicURL, "/") + "/calendar/" + token + ".ics"
+	return &model.CalendarFeeds{
+		Token:       token,
+		PersonalURL: base,
+		EventsURL:   base + "?feed=events",
+		ProjectURL:  base + "?project={id}",
+	}
+}
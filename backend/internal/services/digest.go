@@ -0,0 +1,385 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+const (
+	defaultDigestHour = 9
+	// telegramMessageLimit — максимальная длина сообщения Telegram в символах.
+	telegramMessageLimit = 4096
+	// dailyDueHorizon — ежедневная сводка показывает дедлайны сегодня и завтра.
+	dailyDueHorizon = 2 * 24 * time.Hour
+	weekLength      = 7 * 24 * time.Hour
+)
+
+var digestWeekdays = map[string]time.Weekday{
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+	"sunday":    time.Sunday,
+}
+
+func normalizeDigest(digest *model.DigestSettings) (*model.DigestSettings, error) {
+	result := &model.DigestSettings{
+		Frequency: strings.ToLower(strings.TrimSpace(digest.Frequency)),
+		Hour:      digest.Hour,
+	}
+	switch result.Frequency {
+	case model.DigestOff, model.DigestDaily:
+	case model.DigestWeekly:
+		result.Weekday = strings.ToLower(strings.TrimSpace(digest.Weekday))
+		if result.Weekday == "" {
+			result.Weekday = "monday"
+		}
+		if _, ok := digestWeekdays[result.Weekday]; !ok {
+			return nil, fmt.Errorf("invalid digest weekday %q", digest.Weekday)
+		}
+	default:
+		return nil, fmt.Errorf("invalid digest frequency %q, expected off, daily or weekly", digest.Frequency)
+	}
+	if result.Hour < 0 || result.Hour > 23 {
+		return nil, fmt.Errorf("digest hour must be between 0 and 23")
+	}
+	return result, nil
+}
+
+// SendDigests собирает и отправляет сводки пользователям, у которых
+// наступил выбранный час. Вызывается периодически; каждая сводка
+// собирается один раз за период, пустые сводки не отправляются.
+func SendDigests(cfg *model.Config, now time.Time) {
+	subscribers, err := repository.GetDigestSubscribers(cfg)
+	if err != nil {
+		logger.Error.Printf("SendDigests: failed to load subscribers: %v\n", err)
+		return
+	}
+	if len(subscribers) == 0 {
+		return
+	}
+
+	events, err := digestEvents(cfg, now)
+	if err != nil {
+		logger.Error.Printf("SendDigests: failed to load events: %v\n", err)
+		return
+	}
+	projects, err := workloadProjectTitles(cfg)
+	if err != nil {
+		logger.Error.Printf("SendDigests: failed to load projects: %v\n", err)
+		return
+	}
+
+	for _, telegramID := range subscribers {
+		user, err := GetUserByTelegramID(cfg, telegramID)
+		if err != nil || !user.Active {
+			continue
+		}
+		settings, err := GetNotificationSettings(cfg, telegramID)
+		if err != nil {
+			logger.Error.Printf("SendDigests: failed to load settings for %s: %v\n", telegramID, err)
+			continue
+		}
+
+		loc, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			loc = time.Local
+		}
+		period, due := digestPeriod(settings.Digest, now.In(loc))
+		if !due {
+			continue
+		}
+		recorded, err := repository.DigestRecorded(cfg, telegramID, period)
+		if err != nil {
+			logger.Error.Printf("SendDigests: failed to check digest for %s: %v\n", telegramID, err)
+			continue
+		}
+		if recorded {
+			continue
+		}
+
+		// период отмечается только вместе с готовой сводкой: если сборка не
+		// удалась, следующий запуск попробует снова
+		text, items, err := buildDigest(cfg, user, settings.Digest.Frequency, now.In(loc), events, projects)
+		if err != nil {
+			logger.Error.Printf("SendDigests: failed to build digest for %s: %v\n", telegramID, err)
+			continue
+		}
+
+		var messages []model.OutboxMessage
+		if items > 0 {
+			chatID, _ := strconv.ParseInt(telegramID, 10, 64)
+			for _, part := range splitMessage(text, telegramMessageLimit) {
+				messages = append(messages, model.OutboxMessage{ChatID: chatID, EventType: model.NotifyDigest, Text: part})
+			}
+		}
+		if _, err := repository.RecordDigest(cfg, telegramID, period, items, messages); err != nil {
+			logger.Error.Printf("SendDigests: failed to record digest for %s: %v\n", telegramID, err)
+		}
+	}
+}
+
+// digestPeriod возвращает период сводки и признак того, что её пора
+// собирать: для ежедневной — с выбранного часа, для еженедельной — с
+// выбранного часа в выбранный день недели.
+func digestPeriod(digest *model.DigestSettings, local time.Time) (string, bool) {
+	if digest == nil || local.Hour() < digest.Hour {
+		return "", false
+	}
+	switch digest.Frequency {
+	case model.DigestDaily:
+		return local.Format("2006-01-02"), true
+	case model.DigestWeekly:
+		if local.Weekday() != digestWeekdays[digest.Weekday] {
+			return "", false
+		}
+		year, week := local.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), true
+	}
+	return "", false
+}
+
+// scheduledEvent — событие сводки с разобранным временем начала.
+type scheduledEvent struct {
+	event model.Event
+	start time.Time
+}
+
+// digestEvents возвращает события ближайшей недели по получателям
+// напоминаний, чтобы не разбирать приглашения для каждого пользователя.
+func digestEvents(cfg *model.Config, now time.Time) (map[int64][]scheduledEvent, error) {
+	events, err := GetEvents(cfg, now, now.Add(weekLength))
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[int64][]scheduledEvent)
+	for _, event := range events {
+		start, err := time.Parse(time.RFC3339, event.StartAt)
+		if err != nil || !start.After(now) {
+			continue
+		}
+		recipients, err := eventReminderRecipients(cfg, &event)
+		if err != nil {
+			return nil, err
+		}
+		for _, telegramID := range recipients {
+			byUser[telegramID] = append(byUser[telegramID], scheduledEvent{event: event, start: start})
+		}
+	}
+	return byUser, nil
+}
+
+func buildDigest(cfg *model.Config, user *model.UserProfile, frequency string, now time.Time, events map[int64][]scheduledEvent, projects map[int]string) (string, int, error) {
+	window := weekLength
+	dueHorizon := weekLength
+	title := "🗞 Сводка за неделю"
+	if frequency == model.DigestDaily {
+		window = 24 * time.Hour
+		dueHorizon = dailyDueHorizon
+		title = "🗞 Сводка за день"
+	}
+
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	telegramID, _ := strconv.ParseInt(user.TelegramID, 10, 64)
+
+	tasks, err := repository.GetTasksByAssignee(cfg, telegramID, normalizeUsername(user.Username))
+	if err != nil {
+		return "", 0, err
+	}
+
+	var dueSoon, overdue, assigned []string
+	for _, task := range tasks {
+		if strings.EqualFold(task.Status, "Выполнена") {
+			continue
+		}
+		line := digestTaskLine(task, projects)
+
+		if created, err := time.Parse(time.RFC3339, task.CreatedAt); err == nil && now.Sub(created) <= window {
+			assigned = append(assigned, line)
+		}
+
+		deadline, err := parseTaskDate(task.Deadline)
+		if err != nil {
+			continue
+		}
+		day := time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, loc)
+		switch {
+		case day.Before(today):
+			overdue = append(overdue, line+" — до "+day.Format("02.01"))
+		case day.Before(today.Add(dueHorizon)):
+			dueSoon = append(dueSoon, line+" — до "+day.Format("02.01"))
+		}
+	}
+
+	queue, err := reviewQueue(cfg, user, now)
+	if err != nil {
+		return "", 0, err
+	}
+	review := make([]string, 0, len(queue))
+	for _, task := range queue {
+		line := digestTaskLine(task, projects)
+		if task.User != "" {
+			line += " — " + html.EscapeString(task.User)
+		}
+		review = append(review, line)
+	}
+
+	upcoming := make([]string, 0)
+	for _, scheduled := range events[telegramID] {
+		if scheduled.start.Sub(now) > window {
+			continue
+		}
+		upcoming = append(upcoming, fmt.Sprintf("• %s — %s", html.EscapeString(scheduled.event.Title), scheduled.start.In(loc).Format("02.01 15:04")))
+	}
+
+	sections := []struct {
+		title string
+		lines []string
+	}{
+		{"⏰ Скоро дедлайн", dueSoon},
+		{"🔥 Просрочено", overdue},
+		{"📝 Ждут вашей проверки", review},
+		{"📌 Новые задачи", assigned},
+		{"📅 События", upcoming},
+	}
+
+	var b strings.Builder
+	b.WriteString(title)
+	items := 0
+	for _, section := range sections {
+		if len(section.lines) == 0 {
+			continue
+		}
+		items += len(section.lines)
+		b.WriteString("\n\n" + section.title + ":\n")
+		b.WriteString(strings.Join(section.lines, "\n"))
+	}
+
+	return b.String(), items, nil
+}
+
+func digestTaskLine(task model.Task, projects map[int]string) string {
+	line := "• " + html.EscapeString(task.Title)
+	if title := projects[task.IdProject]; title != "" {
+		line += " (" + html.EscapeString(title) + ")"
+	}
+	return line
+}
+
+// reviewQueue возвращает решения на проверке, которые ждут пользователя:
+// в задачах, где он автор, в проектах, где он руководитель, и то же самое
+// за коллег, которых он сейчас замещает.
+func reviewQueue(cfg *model.Config, user *model.UserProfile, now time.Time) ([]model.Task, error) {
+	reviewers := []*model.UserProfile{user}
+	delegations, err := ActiveDelegations(cfg, user.TelegramID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, delegation := range delegations {
+		if delegator, err := GetUserByTelegramID(cfg, delegation.TelegramID); err == nil {
+			reviewers = append(reviewers, delegator)
+		}
+	}
+
+	projects, err := GetProjects(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	queue := make([]model.Task, 0)
+	add := func(tasks []model.Task) {
+		for _, task := range tasks {
+			if seen[task.ID] || !strings.EqualFold(task.Status, "На проверке") {
+				continue
+			}
+			seen[task.ID] = true
+			queue = append(queue, task)
+		}
+	}
+
+	for _, reviewer := range reviewers {
+		authored, err := repository.GetTasksMentioning(cfg, []string{reviewer.FullName})
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range authored {
+			if strings.EqualFold(strings.TrimSpace(task.Author), strings.TrimSpace(reviewer.FullName)) {
+				add([]model.Task{task})
+			}
+		}
+
+		username := normalizeUsername(reviewer.Username)
+		for _, project := range projects {
+			if project.Archived {
+				continue
+			}
+			for _, member := range project.Members {
+				matches := member.TelegramID == reviewer.TelegramID ||
+					(username != "" && normalizeUsername(member.Username) == username)
+				if !matches || !permissions.IsLeader(member.Role) {
+					continue
+				}
+				tasks, err := repository.GetTasksByProjectID(cfg, project.ID)
+				if err != nil {
+					return nil, err
+				}
+				add(tasks)
+				break
+			}
+		}
+	}
+
+	sort.Slice(queue, func(i, j int) bool { return queue[i].ID < queue[j].ID })
+	return queue, nil
+}
+
+// splitMessage делит текст на части не длиннее limit символов по границам
+// строк; слишком длинная строка режется посередине.
+func splitMessage(text string, limit int) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	parts := make([]string, 0)
+	var current strings.Builder
+	currentLen := 0
+	flush := func() {
+		if currentLen > 0 {
+			parts = append(parts, strings.TrimRight(current.String(), "\n"))
+			current.Reset()
+			currentLen = 0
+		}
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		for len(runes) > limit {
+			flush()
+			parts = append(parts, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		if currentLen+len(runes) > limit {
+			flush()
+		}
+		current.WriteString(string(runes))
+		currentLen += len(runes)
+	}
+	flush()
+
+	return parts
+}
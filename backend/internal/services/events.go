@@ -1,17 +1,544 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/config"
 	"backend/internal/logger"
 	"backend/internal/model"
 	"backend/internal/repository"
 )
 
-func GetEvents(cfg *model.Config) ([]model.Event, error) {
-	events, err := repository.GetEvents(cfg)
+// recurrenceHorizon ограничивает раскрытие бессрочных серий, когда верхняя
+// граница периода не задана.
+const recurrenceHorizon = 180 * 24 * time.Hour
+
+// GetEvents возвращает события, пересекающиеся с [from, to). Нулевое время
+// означает отсутствие соответствующей границы. Повторяющиеся события
+// раскрываются в отдельные повторения с заполненным occurrence_date.
+func GetEvents(cfg *model.Config, from time.Time, to time.Time) ([]model.Event, error) {
+	events, err := repository.GetEvents(cfg, formatEventBound(from), formatEventBound(to))
 	if err != nil {
 		logger.Error.Printf("GetEvents: failed to load events: %v\n", err)
 		return nil, err
 	}
 
-	return events, nil
+	loc := config.Location(cfg)
+	result := make([]model.Event, 0, len(events))
+	for _, event := range events {
+		if event.Recurrence == nil {
+			localizeEvent(&event, loc)
+			if eventInRange(&event, from, to) {
+				result = append(result, event)
+			}
+			continue
+		}
+
+		occurrences, err := expandEvent(cfg, event, from, to)
+		if err != nil {
+			logger.Error.Printf("GetEvents: failed to expand event %d: %v\n", event.ID, err)
+			return nil, err
+		}
+		result = append(result, occurrences...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartAt < result[j].StartAt
+	})
+
+	return result, nil
+}
+
+// expandEvent раскрывает серию в повторения, пересекающиеся с [from, to),
+// с учётом изменённых и отменённых повторений.
+func expandEvent(cfg *model.Config, event model.Event, from time.Time, to time.Time) ([]model.Event, error) {
+	start, err := time.Parse(time.RFC3339, event.StartAt)
+	if err != nil {
+		return nil, nil
+	}
+	end, err := time.Parse(time.RFC3339, event.EndAt)
+	if err != nil {
+		end = start
+	}
+
+	limit := to
+	if limit.IsZero() {
+		limit = time.Now()
+		if from.After(limit) {
+			limit = from
+		}
+		limit = limit.Add(recurrenceHorizon)
+	}
+
+	overrides, err := repository.GetEventOccurrences(cfg, event.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := config.Location(cfg)
+	occurrences := make([]model.Event, 0)
+	seen := make(map[string]bool)
+	for _, occurrenceStart := range seriesStarts(start, event.Recurrence, limit, loc) {
+		occurrence := event
+		occurrence.OccurrenceDate = occurrenceStart.In(loc).Format("2006-01-02")
+		occurrence.StartAt = occurrenceStart.UTC().Format(time.RFC3339)
+		occurrence.EndAt = occurrenceStart.Add(end.Sub(start)).UTC().Format(time.RFC3339)
+		seen[occurrence.OccurrenceDate] = true
+
+		if override, ok := overrides[occurrence.OccurrenceDate]; ok {
+			if override.Cancelled {
+				continue
+			}
+			applyOccurrenceOverride(&occurrence, override)
+		}
+
+		// границы проверяем по времени после переноса, а не по исходному
+		if !eventInRange(&occurrence, from, to) {
+			continue
+		}
+
+		localizeEvent(&occurrence, loc)
+		occurrences = append(occurrences, occurrence)
+	}
+
+	// повторение после limit могли перенести внутрь периода
+	for date, override := range overrides {
+		if seen[date] || override.Cancelled || override.StartAt == "" {
+			continue
+		}
+		if _, err := findOccurrence(cfg, &event, date); err != nil {
+			continue
+		}
+		occurrence := event
+		occurrence.OccurrenceDate = date
+		applyOccurrenceOverride(&occurrence, override)
+		if !eventInRange(&occurrence, from, to) {
+			continue
+		}
+
+		localizeEvent(&occurrence, loc)
+		occurrences = append(occurrences, occurrence)
+	}
+
+	return occurrences, nil
+}
+
+// seriesStarts возвращает начала повторений серии раньше limit. Повторения
+// считаются во времени сообщества, чтобы сохранялось время на часах.
+// Ежемесячная серия пропускает месяцы без нужного числа (например, 31-го).
+func seriesStarts(start time.Time, recurrence *model.Recurrence, limit time.Time, loc *time.Location) []time.Time {
+	local := start.In(loc)
+
+	until := time.Time{}
+	if recurrence.Until != "" {
+		if parsed, err := time.ParseInLocation("2006-01-02", recurrence.Until, loc); err == nil {
+			until = parsed.AddDate(0, 0, 1)
+		}
+	}
+
+	starts := make([]time.Time, 0)
+	for n := 0; n < 10000; n++ {
+		var next time.Time
+		switch recurrence.Frequency {
+		case model.RecurrenceWeekly:
+			next = local.AddDate(0, 0, 7*n)
+		case model.RecurrenceBiweekly:
+			next = local.AddDate(0, 0, 14*n)
+		case model.RecurrenceMonthly:
+			next = local.AddDate(0, n, 0)
+			if next.Day() != local.Day() {
+				continue
+			}
+		default:
+			return starts
+		}
+
+		if !next.Before(limit) || (!until.IsZero() && !next.Before(until)) {
+			break
+		}
+		if recurrence.Count > 0 && len(starts) >= recurrence.Count {
+			break
+		}
+		starts = append(starts, next)
+	}
+
+	return starts
+}
+
+func applyOccurrenceOverride(event *model.Event, override model.EventOccurrence) {
+	if override.Title != "" {
+		event.Title = override.Title
+	}
+	if override.StartAt != "" {
+		event.StartAt = override.StartAt
+	}
+	if override.EndAt != "" {
+		event.EndAt = override.EndAt
+	}
+	if override.Description != "" {
+		event.Description = override.Description
+	}
+}
+
+// findOccurrence возвращает начало повторения серии с датой date.
+func findOccurrence(cfg *model.Config, event *model.Event, date string) (time.Time, error) {
+	if event.Recurrence == nil {
+		return time.Time{}, fmt.Errorf("event is not recurring")
+	}
+
+	loc := config.Location(cfg)
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid occurrence date: %s", date)
+	}
+	start, err := time.Parse(time.RFC3339, event.StartAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, occurrenceStart := range seriesStarts(start, event.Recurrence, day.AddDate(0, 0, 1), loc) {
+		if occurrenceStart.In(loc).Format("2006-01-02") == date {
+			return occurrenceStart, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("occurrence not found")
+}
+
+// UpdateEventOccurrence меняет одно повторение серии, не затрагивая остальные.
+// Пустые поля наследуются от серии.
+func UpdateEventOccurrence(cfg *model.Config, eventID int, date string, payload *model.Event) (*model.Event, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	occurrenceStart, err := findOccurrence(cfg, event, date)
+	if err != nil {
+		return nil, err
+	}
+
+	seriesStart, _ := time.Parse(time.RFC3339, event.StartAt)
+	seriesEnd, err := time.Parse(time.RFC3339, event.EndAt)
+	if err != nil {
+		seriesEnd = seriesStart
+	}
+
+	start := occurrenceStart
+	if strings.TrimSpace(payload.StartAt) != "" {
+		start, err = ParseEventTime(cfg, payload.StartAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_at: %v", err)
+		}
+	}
+	end := start.Add(seriesEnd.Sub(seriesStart))
+	if strings.TrimSpace(payload.EndAt) != "" {
+		end, err = ParseEventTime(cfg, payload.EndAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_at: %v", err)
+		}
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end_at must not be before start_at")
+	}
+
+	override := model.EventOccurrence{
+		EventID:        eventID,
+		OccurrenceDate: date,
+		Title:          strings.TrimSpace(payload.Title),
+		StartAt:        start.UTC().Format(time.RFC3339),
+		EndAt:          end.UTC().Format(time.RFC3339),
+		Description:    payload.Description,
+	}
+
+	moved := model.Event{ID: eventID, Title: event.Title, StartAt: override.StartAt, EndAt: override.EndAt}
+	err = repository.SaveEventOccurrence(cfg, &override, func() error {
+		resourceIDs, err := repository.GetEventResourceIDs(cfg, eventID)
+		if err != nil {
+			return err
+		}
+		return checkResourceConflicts(cfg, &moved, resourceIDs)
+	})
+	if err != nil {
+		var conflict *ResourceConflictError
+		if errors.As(err, &conflict) {
+			return nil, err
+		}
+		logger.Error.Printf("UpdateEventOccurrence: failed to update occurrence %s of event %d: %v\n", date, eventID, err)
+		return nil, err
+	}
+
+	occurrence := *event
+	occurrence.OccurrenceDate = date
+	applyOccurrenceOverride(&occurrence, override)
+	localizeEvent(&occurrence, config.Location(cfg))
+	return &occurrence, nil
+}
+
+// CancelEventOccurrence отменяет одно повторение серии.
+func CancelEventOccurrence(cfg *model.Config, eventID int, date string) error {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+
+	if _, err := findOccurrence(cfg, event, date); err != nil {
+		return err
+	}
+
+	return repository.SaveEventOccurrence(cfg, &model.EventOccurrence{
+		EventID:        eventID,
+		OccurrenceDate: date,
+		Cancelled:      true,
+	}, nil)
+}
+
+func GetEventByID(cfg *model.Config, eventID int) (*model.Event, error) {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	localizeEvent(event, config.Location(cfg))
+	return event, nil
+}
+
+func CreateEvent(cfg *model.Config, event *model.Event) error {
+	if err := normalizeEvent(cfg, event); err != nil {
+		return err
+	}
+
+	id, err := repository.CreateEvent(cfg, event)
+	if err != nil {
+		logger.Error.Printf("CreateEvent: failed to create event '%s': %v\n", event.Title, err)
+		return err
+	}
+
+	event.ID = id
+	localizeEvent(event, config.Location(cfg))
+	return nil
+}
+
+func UpdateEvent(cfg *model.Config, event *model.Event) error {
+	existing, err := repository.GetEventByID(cfg, event.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("event not found")
+	}
+
+	event.CreatedBy = existing.CreatedBy
+	if err := normalizeEvent(cfg, event); err != nil {
+		return err
+	}
+
+	err = repository.UpdateEvent(cfg, event, func() error {
+		resourceIDs, err := repository.GetEventResourceIDs(cfg, event.ID)
+		if err != nil {
+			return err
+		}
+		return checkResourceConflicts(cfg, event, resourceIDs)
+	})
+	if err != nil {
+		var conflict *ResourceConflictError
+		if errors.As(err, &conflict) {
+			return err
+		}
+		logger.Error.Printf("UpdateEvent: failed to update event %d: %v\n", event.ID, err)
+		return err
+	}
+
+	localizeEvent(event, config.Location(cfg))
+	return nil
+}
+
+func DeleteEvent(cfg *model.Config, eventID int) error {
+	return repository.DeleteEvent(cfg, eventID)
+}
+
+// ParseEventTime принимает RFC3339, значение datetime-local ("2006-01-02T15:04")
+// или дату ("2006-01-02"); время без смещения считается временем сообщества.
+func ParseEventTime(cfg *model.Config, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	loc := config.Location(cfg)
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time format: %s", value)
+}
+
+func normalizeEvent(cfg *model.Config, event *model.Event) error {
+	event.Title = strings.TrimSpace(event.Title)
+	if event.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	start, err := ParseEventTime(cfg, event.StartAt)
+	if err != nil {
+		return fmt.Errorf("invalid start_at: %v", err)
+	}
+
+	end := start
+	if strings.TrimSpace(event.EndAt) != "" {
+		end, err = ParseEventTime(cfg, event.EndAt)
+		if err != nil {
+			return fmt.Errorf("invalid end_at: %v", err)
+		}
+	}
+	if end.Before(start) {
+		return fmt.Errorf("end_at must not be before start_at")
+	}
+
+	if err := normalizeRecurrence(event.Recurrence); err != nil {
+		return err
+	}
+
+	if event.ProjectID != 0 {
+		project, err := GetProjectByID(cfg, event.ProjectID)
+		if err != nil {
+			return err
+		}
+		if project == nil {
+			return fmt.Errorf("project not found")
+		}
+	}
+
+	loc := config.Location(cfg)
+	event.StartAt = start.UTC().Format(time.RFC3339)
+	event.EndAt = end.UTC().Format(time.RFC3339)
+	// старые поля оставляем заполненными для клиентов, которые читают только их
+	event.Date = start.In(loc).Format("02.01.06")
+	event.TimeRange = start.In(loc).Format("15:04") + "-" + end.In(loc).Format("15:04")
+	return nil
+}
+
+func normalizeRecurrence(recurrence *model.Recurrence) error {
+	if recurrence == nil {
+		return nil
+	}
+
+	recurrence.Frequency = strings.ToLower(strings.TrimSpace(recurrence.Frequency))
+	switch recurrence.Frequency {
+	case model.RecurrenceWeekly, model.RecurrenceBiweekly, model.RecurrenceMonthly:
+	default:
+		return fmt.Errorf("invalid recurrence frequency: %s", recurrence.Frequency)
+	}
+
+	recurrence.Until = strings.TrimSpace(recurrence.Until)
+	if recurrence.Until != "" {
+		if _, err := time.Parse("2006-01-02", recurrence.Until); err != nil {
+			return fmt.Errorf("invalid recurrence until: %v", err)
+		}
+	}
+	if recurrence.Count < 0 {
+		return fmt.Errorf("recurrence count must not be negative")
+	}
+
+	return nil
+}
+
+func localizeEvent(event *model.Event, loc *time.Location) {
+	start, err := time.Parse(time.RFC3339, event.StartAt)
+	if err != nil {
+		// событие не перенесено миграцией — время берём из старых колонок,
+		// а если и они не разбираются, отдаём их как есть
+		legacyStart, legacyEnd, err := ParseLegacyEventTime(event.Date, event.TimeRange, loc)
+		if err != nil {
+			return
+		}
+		event.StartAt = legacyStart.UTC().Format(time.RFC3339)
+		event.EndAt = legacyEnd.UTC().Format(time.RFC3339)
+		start = legacyStart
+	}
+	end, err := time.Parse(time.RFC3339, event.EndAt)
+	if err != nil {
+		end = start
+	}
+
+	event.StartAt = start.In(loc).Format(time.RFC3339)
+	event.EndAt = end.In(loc).Format(time.RFC3339)
+	event.Date = start.In(loc).Format("02.01.06")
+	event.TimeRange = start.In(loc).Format("15:04") + "-" + end.In(loc).Format("15:04")
+}
+
+// eventInRange проверяет пересечение события с [from, to); событие без
+// разборчивого времени в период с границами не попадает.
+func eventInRange(event *model.Event, from time.Time, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	start, err := time.Parse(time.RFC3339, event.StartAt)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(time.RFC3339, event.EndAt)
+	if err != nil {
+		end = start
+	}
+	if !from.IsZero() && end.Before(from) {
+		return false
+	}
+	return to.IsZero() || start.Before(to)
+}
+
+func formatEventBound(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseLegacyEventTime разбирает старые строковые date ("18.01.26") и
+// time_range ("17:00-18:30") во времени сообщества.
+func ParseLegacyEventTime(date string, timeRange string, loc *time.Location) (time.Time, time.Time, error) {
+	var day time.Time
+	var err error
+	for _, layout := range []string{"02.01.06", "02.01.2006", "2006-01-02"} {
+		day, err = time.ParseInLocation(layout, strings.TrimSpace(date), loc)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	startClock, endClock, _ := strings.Cut(strings.TrimSpace(timeRange), "-")
+	start := day
+	end := day
+	if strings.TrimSpace(startClock) != "" {
+		clock, err := time.Parse("15:04", strings.TrimSpace(startClock))
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		start = day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+		end = start
+	}
+	if strings.TrimSpace(endClock) != "" {
+		clock, err := time.Parse("15:04", strings.TrimSpace(endClock))
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		end = day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	}
+
+	return start, end, nil
 }
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func TestSeriesStarts(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data is not available")
+	}
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		recurrence model.Recurrence
+		limit      time.Time
+		want       []string
+	}{
+		{
+			name:       "weekly limited by count",
+			start:      time.Date(2024, 1, 1, 18, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: model.RecurrenceWeekly, Count: 3},
+			limit:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       []string{"2024-01-01 18:00", "2024-01-08 18:00", "2024-01-15 18:00"},
+		},
+		{
+			name:       "biweekly until is inclusive",
+			start:      time.Date(2024, 1, 1, 18, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: model.RecurrenceBiweekly, Until: "2024-01-29"},
+			limit:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       []string{"2024-01-01 18:00", "2024-01-15 18:00", "2024-01-29 18:00"},
+		},
+		{
+			name:       "stops before limit",
+			start:      time.Date(2024, 1, 1, 18, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: model.RecurrenceWeekly},
+			limit:      time.Date(2024, 1, 15, 18, 0, 0, 0, berlin),
+			want:       []string{"2024-01-01 18:00", "2024-01-08 18:00"},
+		},
+		{
+			name:       "monthly skips short months",
+			start:      time.Date(2024, 1, 31, 10, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: model.RecurrenceMonthly, Count: 3},
+			limit:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       []string{"2024-01-31 10:00", "2024-03-31 10:00", "2024-05-31 10:00"},
+		},
+		{
+			name:       "local time survives daylight saving change",
+			start:      time.Date(2024, 3, 24, 9, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: model.RecurrenceWeekly, Count: 2},
+			limit:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       []string{"2024-03-24 09:00", "2024-03-31 09:00"},
+		},
+		{
+			name:       "unknown frequency",
+			start:      time.Date(2024, 1, 1, 18, 0, 0, 0, berlin),
+			recurrence: model.Recurrence{Frequency: "daily"},
+			limit:      time.Date(2025, 1, 1, 0, 0, 0, 0, berlin),
+			want:       []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurrence := tt.recurrence
+			// начало хранится в UTC, повторения считаются в часовом поясе сообщества
+			starts := seriesStarts(tt.start.UTC(), &recurrence, tt.limit, berlin)
+
+			got := make([]string, 0, len(starts))
+			for _, start := range starts {
+				got = append(got, start.In(berlin).Format("2006-01-02 15:04"))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("seriesStarts() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("seriesStarts() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
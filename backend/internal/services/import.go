@@ -0,0 +1,460 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+// usersHeaderRows — сколько строк заголовка в выгрузке реестра участников.
+const usersHeaderRows = 2
+
+var ErrImportHasIssues = errors.New("import has validation issues")
+
+// ParseUsersCSV читает реестр участников: ФИО, юзернейм, дата рождения,
+// телефон, должность, Telegram ID, may_to_open. Строки без Telegram ID
+// пропускаются.
+func ParseUsersCSV(name string, r io.Reader) ([]model.UserProfile, []model.ImportIssue) {
+	users := make([]model.UserProfile, 0)
+	issues := make([]model.ImportIssue, 0)
+	seenIDs := make(map[string]int)
+	seenUsernames := make(map[string]int)
+
+	err := readCSV(r, func(line int, index int, row []string) {
+		if index < usersHeaderRows {
+			return
+		}
+		row = padRow(row, 7)
+
+		telegramID := strings.TrimSpace(row[5])
+		if telegramID == "" {
+			return
+		}
+		issue := func(format string, args ...interface{}) {
+			issues = append(issues, model.ImportIssue{File: name, Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if id, err := strconv.ParseInt(telegramID, 10, 64); err != nil || id <= 0 {
+			issue("invalid telegram id %q", telegramID)
+			return
+		}
+		if prev, ok := seenIDs[telegramID]; ok {
+			issue("telegram id %s duplicates line %d", telegramID, prev)
+			return
+		}
+		seenIDs[telegramID] = line
+
+		user := model.UserProfile{
+			TelegramID: telegramID,
+			FullName:   strings.Join(strings.Fields(row[0]), " "),
+			Username:   strings.TrimPrefix(strings.TrimSpace(row[1]), "@"),
+			Role:       strings.TrimSpace(row[4]),
+		}
+		valid := true
+
+		if user.FullName == "" {
+			issue("full name is required")
+			valid = false
+		}
+		if user.Username != "" {
+			if !validImportUsername(user.Username) {
+				issue("invalid username %q", user.Username)
+				valid = false
+			} else if prev, ok := seenUsernames[normalizeUsername(user.Username)]; ok {
+				issue("username @%s duplicates line %d", user.Username, prev)
+				valid = false
+			} else {
+				seenUsernames[normalizeUsername(user.Username)] = line
+			}
+		}
+
+		birthday, err := normalizeBirthday(row[2])
+		if err != nil {
+			issue("%v", err)
+			valid = false
+		}
+		user.DateOfBirthday = birthday
+
+		phone, err := normalizePhone(row[3])
+		if err != nil {
+			issue("%v", err)
+			valid = false
+		}
+		user.NumberOfPhone = phone
+
+		switch strings.ToLower(strings.TrimSpace(row[6])) {
+		case "true", "1":
+			user.MayToOpen = true
+		case "false", "0", "":
+		default:
+			issue("invalid may_to_open %q, expected true or false", row[6])
+			valid = false
+		}
+
+		if valid {
+			users = append(users, user)
+		}
+	})
+	if err != nil {
+		issues = append(issues, model.ImportIssue{File: name, Message: err.Error()})
+	}
+
+	return users, issues
+}
+
+// ParseProjectsCSV читает лист проектов: каждый проект начинается строкой
+// заголовка (статус, ..., ФИО, Юзернейм, Должность, ..., название), за ней
+// идут участники. Ссылки из последней колонки собираются в описание.
+func ParseProjectsCSV(name string, r io.Reader) ([]model.Project, []model.ImportIssue) {
+	projects := make([]model.Project, 0)
+	issues := make([]model.ImportIssue, 0)
+
+	var (
+		current *model.Project
+		links   map[string]bool
+		members map[string]int
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		sorted := make([]string, 0, len(links))
+		for link := range links {
+			sorted = append(sorted, link)
+		}
+		sort.Strings(sorted)
+		current.Description = strings.Join(sorted, "\n")
+		projects = append(projects, *current)
+	}
+
+	err := readCSV(r, func(line int, index int, row []string) {
+		row = padRow(row, 8)
+
+		if isProjectHeader(row) {
+			flush()
+			current = &model.Project{
+				Title:   strings.TrimSpace(row[7]),
+				Status:  strings.TrimSpace(row[0]),
+				Members: make([]model.ProjectMember, 0),
+			}
+			links = make(map[string]bool)
+			members = make(map[string]int)
+			return
+		}
+		if current == nil {
+			return
+		}
+
+		username := normalizeUsername(row[3])
+		if username != "" && username != "-" && username != "—" {
+			switch {
+			case !validImportUsername(username):
+				issues = append(issues, model.ImportIssue{File: name, Line: line, Message: fmt.Sprintf("invalid username %q", row[3])})
+			case members[username] != 0:
+				issues = append(issues, model.ImportIssue{
+					File:    name,
+					Line:    line,
+					Message: fmt.Sprintf("@%s is already listed in project %q on line %d", username, current.Title, members[username]),
+				})
+			default:
+				members[username] = line
+				current.Members = append(current.Members, model.ProjectMember{
+					Username: username,
+					FullName: strings.TrimSpace(row[2]),
+					Role:     strings.TrimSpace(row[4]),
+				})
+			}
+		}
+
+		if link := strings.TrimSpace(row[7]); link != "" {
+			links[link] = true
+		}
+	})
+	flush()
+	if err != nil {
+		issues = append(issues, model.ImportIssue{File: name, Message: err.Error()})
+	}
+
+	return projects, issues
+}
+
+// PlanImport сравнивает данные реестра с базой. nil вместо списка означает,
+// что этот реестр не импортируется. Без prune записи, которых нет в
+// реестре, не трогаются.
+func PlanImport(cfg *model.Config, users []model.UserProfile, projects []model.Project, prune bool) (*model.ImportPlan, error) {
+	plan := &model.ImportPlan{
+		Users:    make([]model.UserImportChange, 0),
+		Projects: make([]model.ProjectImportChange, 0),
+		Issues:   make([]model.ImportIssue, 0),
+	}
+
+	existingUsers, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.UserProfile, len(existingUsers))
+	byUsername := make(map[string]model.UserProfile, len(existingUsers))
+	for _, user := range existingUsers {
+		byID[user.TelegramID] = user
+		if user.Username != "" {
+			byUsername[normalizeUsername(user.Username)] = user
+		}
+	}
+
+	if users != nil {
+		imported := make(map[string]bool, len(users))
+		for _, user := range users {
+			imported[user.TelegramID] = true
+			existing, ok := byID[user.TelegramID]
+			if !ok {
+				plan.Users = append(plan.Users, model.UserImportChange{Action: model.ImportCreate, User: user})
+				// занятый юзернейм остаётся за владельцем, чтобы проверка ниже его нашла
+				if _, taken := byUsername[normalizeUsername(user.Username)]; user.Username != "" && !taken {
+					user.Active = true
+					byUsername[normalizeUsername(user.Username)] = user
+				}
+				continue
+			}
+
+			user.Role = mergeImportedRole(user.Role, existing.Role)
+			if changes := userFieldChanges(existing, user); len(changes) > 0 {
+				plan.Users = append(plan.Users, model.UserImportChange{Action: model.ImportUpdate, User: user, Changes: changes})
+			}
+		}
+
+		if prune {
+			for _, user := range existingUsers {
+				if user.Active && !imported[user.TelegramID] {
+					plan.Users = append(plan.Users, model.UserImportChange{Action: model.ImportRemove, User: user})
+				}
+			}
+		}
+
+		// юзернейм не должен достаться двум разным участникам
+		for _, change := range plan.Users {
+			if change.Action == model.ImportRemove || change.User.Username == "" {
+				continue
+			}
+			owner, ok := byUsername[normalizeUsername(change.User.Username)]
+			if ok && owner.TelegramID != change.User.TelegramID && !imported[owner.TelegramID] {
+				plan.Issues = append(plan.Issues, model.ImportIssue{Message: fmt.Sprintf(
+					"username @%s already belongs to %s (%s)",
+					change.User.Username,
+					owner.FullName,
+					owner.TelegramID,
+				)})
+			}
+		}
+	}
+
+	if projects != nil {
+		existingProjects, err := GetProjects(cfg)
+		if err != nil {
+			return nil, err
+		}
+		byTitle := make(map[string]model.Project, len(existingProjects))
+		for _, project := range existingProjects {
+			byTitle[strings.ToLower(strings.TrimSpace(project.Title))] = project
+		}
+
+		imported := make(map[string]bool, len(projects))
+		for _, project := range projects {
+			key := strings.ToLower(project.Title)
+			if imported[key] {
+				plan.Issues = append(plan.Issues, model.ImportIssue{Message: fmt.Sprintf("project %q is listed twice", project.Title)})
+				continue
+			}
+			imported[key] = true
+
+			// отключённые участники не возвращаются в проекты при повторном импорте;
+			// роли нормализуются так же, как при добавлении участника в приложении
+			members := make([]model.ProjectMember, 0, len(project.Members))
+			for _, member := range project.Members {
+				member.Role = permissions.NormalizeMemberRole(member.Role)
+				if user, ok := byUsername[member.Username]; ok {
+					if !user.Active {
+						continue
+					}
+					member.TelegramID = user.TelegramID
+				}
+				members = append(members, member)
+			}
+			project.Members = members
+
+			existing, ok := byTitle[key]
+			if !ok {
+				plan.Projects = append(plan.Projects, model.ProjectImportChange{Action: model.ImportCreate, Project: project})
+				continue
+			}
+
+			project.ID = existing.ID
+			project.Status = existing.Status
+			project.Members = mergeImportedMembers(project.Members, existing.Members)
+			if changes := projectFieldChanges(existing, project); len(changes) > 0 {
+				plan.Projects = append(plan.Projects, model.ProjectImportChange{Action: model.ImportUpdate, Project: project, Changes: changes})
+			}
+		}
+
+		if prune {
+			for _, project := range existingProjects {
+				if !project.Archived && !imported[strings.ToLower(strings.TrimSpace(project.Title))] {
+					plan.Projects = append(plan.Projects, model.ProjectImportChange{Action: model.ImportRemove, Project: project})
+				}
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// ApplyImport применяет план, если в нём нет ошибок.
+func ApplyImport(cfg *model.Config, plan *model.ImportPlan) error {
+	if len(plan.Issues) > 0 {
+		return ErrImportHasIssues
+	}
+	if err := repository.ApplyImport(cfg, plan); err != nil {
+		logger.Error.Printf("ApplyImport: failed to apply import: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// mergeImportedRole сохраняет админские и модераторские роли, выданные в
+// приложении: в реестре их нет, и повторный импорт не должен их снимать.
+func mergeImportedRole(imported string, existing string) string {
+	roles := permissions.ParseRoles(imported)
+	merged := imported
+	for _, part := range strings.Split(existing, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" || roles[strings.ToLower(part)] {
+			continue
+		}
+		if permissions.IsAdmin(part) || permissions.IsModerator(part) {
+			if merged != "" {
+				merged += ", "
+			}
+			merged += part
+		}
+	}
+	return merged
+}
+
+// mergeImportedMembers обновляет участников проекта по реестру, не удаляя
+// тех, кого добавили в приложении после прошлого импорта.
+func mergeImportedMembers(imported []model.ProjectMember, existing []model.ProjectMember) []model.ProjectMember {
+	byUsername := make(map[string]model.ProjectMember, len(imported))
+	for _, member := range imported {
+		byUsername[normalizeUsername(member.Username)] = member
+	}
+
+	merged := make([]model.ProjectMember, 0, len(existing)+len(imported))
+	seen := make(map[string]bool, len(existing))
+	for _, member := range existing {
+		key := normalizeUsername(member.Username)
+		seen[key] = true
+		if update, ok := byUsername[key]; ok {
+			member.FullName = update.FullName
+			member.Role = update.Role
+			if update.TelegramID != "" {
+				member.TelegramID = update.TelegramID
+			}
+		}
+		merged = append(merged, member)
+	}
+	for _, member := range imported {
+		if !seen[normalizeUsername(member.Username)] {
+			merged = append(merged, member)
+		}
+	}
+	return merged
+}
+
+func userFieldChanges(existing model.UserProfile, imported model.UserProfile) []model.FieldChange {
+	changes := make([]model.FieldChange, 0)
+	compare := func(field string, old string, value string) {
+		if old != value {
+			changes = append(changes, model.FieldChange{Field: field, Old: old, New: value})
+		}
+	}
+
+	compare("username", existing.Username, imported.Username)
+	compare("full_name", existing.FullName, imported.FullName)
+	compare("date_of_birthday", existing.DateOfBirthday, imported.DateOfBirthday)
+	compare("number_of_phone", existing.NumberOfPhone, imported.NumberOfPhone)
+	compare("role", existing.Role, imported.Role)
+	compare("may_to_open", strconv.FormatBool(existing.MayToOpen), strconv.FormatBool(imported.MayToOpen))
+	return changes
+}
+
+func projectFieldChanges(existing model.Project, imported model.Project) []model.FieldChange {
+	changes := make([]model.FieldChange, 0)
+	if existing.Description != imported.Description {
+		changes = append(changes, model.FieldChange{Field: "description", Old: existing.Description, New: imported.Description})
+	}
+
+	old, value := formatImportMembers(existing.Members), formatImportMembers(imported.Members)
+	if old != value {
+		changes = append(changes, model.FieldChange{Field: "members", Old: old, New: value})
+	}
+	return changes
+}
+
+func formatImportMembers(members []model.ProjectMember) string {
+	parts := make([]string, 0, len(members))
+	for _, member := range members {
+		parts = append(parts, fmt.Sprintf("@%s (%s)", normalizeUsername(member.Username), member.Role))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func isProjectHeader(row []string) bool {
+	return strings.TrimSpace(row[0]) != "" &&
+		strings.TrimSpace(row[2]) == "ФИО" &&
+		strings.TrimSpace(row[3]) == "Юзернейм" &&
+		strings.HasPrefix(strings.TrimSpace(row[4]), "Должность") &&
+		strings.TrimSpace(row[7]) != "" &&
+		!strings.HasPrefix(strings.TrimSpace(row[0]), "Руководство")
+}
+
+func validImportUsername(username string) bool {
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return username != ""
+}
+
+func readCSV(r io.Reader, handle func(line int, index int, row []string)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for index := 0; ; index++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		handle(line, index, row)
+	}
+}
+
+func padRow(row []string, size int) []string {
+	for len(row) < size {
+		row = append(row, "")
+	}
+	return row
+}
@@ -0,0 +1,167 @@
+package services_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"backend/internal/handler"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/services"
+)
+
+// newTestDB создаёт пустую базу со схемой приложения во временном каталоге.
+func newTestDB(t *testing.T) *model.Config {
+	t.Helper()
+	dir := t.TempDir()
+	if err := logger.Init(filepath.Join(dir, "test.log")); err != nil {
+		t.Fatalf("logger.Init: %v", err)
+	}
+
+	cfg := &model.Config{DATABASE: "sqlite3", NAME_OF_DATABASE: filepath.Join(dir, "test.db"), Timezone: "Europe/Moscow"}
+	handler.InitDatabase(cfg)
+	t.Cleanup(func() { handler.DB.Close() })
+	return cfg
+}
+
+// importTestDB добавляет в базу двух активных участников, одного
+// отключённого, проект и архивный проект.
+func importTestDB(t *testing.T) *model.Config {
+	t.Helper()
+	cfg := newTestDB(t)
+	for _, query := range []string{
+		`INSERT INTO users (TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, IsActive)
+			VALUES ('1', '', '', 'ivanov', '', 'Иванов Иван', '', '+79990000001', 'Разработчик, Админ', 0, 1)`,
+		`INSERT INTO users (TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, IsActive)
+			VALUES ('2', '', '', 'petrov', '', 'Петров Пётр', '', '+79990000002', 'Разработчик', 0, 1)`,
+		`INSERT INTO users (TelegramID, FirstName, LastName, Username, PhotoURL, FullName, DateOfBirthday, NumberOfPhone, Role, MayToOpen, IsActive)
+			VALUES ('3', '', '', 'sidorov', '', 'Сидоров Сидор', '', '', 'Разработчик', 0, 0)`,
+		`INSERT INTO projects (id, title, description, status, users, archived)
+			VALUES (1, 'Сайт', '', 'В работе', '[{"username":"ivanov","full_name":"Иванов Иван","role":"Руководитель, Модератор"}]', 0)`,
+		`INSERT INTO projects (id, title, description, status, users, archived)
+			VALUES (2, 'Архив', '', 'Выполнен', '[]', 1)`,
+	} {
+		if _, err := handler.DB.Exec(query); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	return cfg
+}
+
+func TestPlanImport(t *testing.T) {
+	ivanov := model.UserProfile{TelegramID: "1", Username: "ivanov", FullName: "Иванов Иван", NumberOfPhone: "+79990000001", Role: "Разработчик"}
+	petrov := model.UserProfile{TelegramID: "2", Username: "petrov", FullName: "Петров Пётр", NumberOfPhone: "+79990000002", Role: "Разработчик"}
+	site := model.Project{Title: "Сайт", Members: []model.ProjectMember{{Username: "ivanov", FullName: "Иванов Иван", Role: "Руководитель"}}}
+
+	tests := []struct {
+		name         string
+		users        []model.UserProfile
+		projects     []model.Project
+		prune        bool
+		wantUsers    []string
+		wantProjects []string
+		wantIssues   int
+	}{
+		{
+			name:         "unchanged registry",
+			users:        []model.UserProfile{ivanov, petrov},
+			projects:     []model.Project{site},
+			wantUsers:    []string{},
+			wantProjects: []string{},
+		},
+		{
+			name: "create and update",
+			users: []model.UserProfile{
+				ivanov,
+				{TelegramID: "2", Username: "petrov", FullName: "Петров Пётр", NumberOfPhone: "+79990000022", Role: "Разработчик"},
+				{TelegramID: "4", Username: "smirnov", FullName: "Смирнов Семён", Role: "Разработчик"},
+			},
+			projects: []model.Project{
+				{Title: "Сайт", Members: []model.ProjectMember{{Username: "ivanov", FullName: "Иванов Иван", Role: "Руководитель"}, {Username: "smirnov", Role: "Разработчик"}}},
+				{Title: "Бот", Members: []model.ProjectMember{{Username: "petrov", Role: "Руководитель"}}},
+			},
+			wantUsers:    []string{"update 2", "create 4"},
+			wantProjects: []string{"update Сайт", "create Бот"},
+		},
+		{
+			name:         "missing records are kept without prune",
+			users:        []model.UserProfile{ivanov},
+			projects:     []model.Project{},
+			wantUsers:    []string{},
+			wantProjects: []string{},
+		},
+		{
+			name:         "prune deactivates and archives missing records",
+			users:        []model.UserProfile{ivanov},
+			projects:     []model.Project{},
+			prune:        true,
+			wantUsers:    []string{"remove 2"},
+			wantProjects: []string{"remove Сайт"},
+		},
+		{
+			name:         "registry that is not imported is not pruned",
+			users:        nil,
+			projects:     []model.Project{site},
+			prune:        true,
+			wantUsers:    []string{},
+			wantProjects: []string{},
+		},
+		{
+			name:         "username taken by another member",
+			users:        []model.UserProfile{ivanov, {TelegramID: "5", Username: "Petrov", FullName: "Другой Пётр"}},
+			wantUsers:    []string{"create 5"},
+			wantProjects: []string{},
+			wantIssues:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := importTestDB(t)
+			plan, err := services.PlanImport(cfg, tt.users, tt.projects, tt.prune)
+			if err != nil {
+				t.Fatalf("PlanImport: %v", err)
+			}
+
+			users := make([]string, 0, len(plan.Users))
+			for _, change := range plan.Users {
+				users = append(users, change.Action+" "+change.User.TelegramID)
+			}
+			projects := make([]string, 0, len(plan.Projects))
+			for _, change := range plan.Projects {
+				projects = append(projects, change.Action+" "+change.Project.Title)
+			}
+			if !reflect.DeepEqual(users, tt.wantUsers) {
+				t.Errorf("user changes = %v, want %v", users, tt.wantUsers)
+			}
+			if !reflect.DeepEqual(projects, tt.wantProjects) {
+				t.Errorf("project changes = %v, want %v", projects, tt.wantProjects)
+			}
+			if len(plan.Issues) != tt.wantIssues {
+				t.Errorf("issues = %v, want %d", plan.Issues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestPlanImportKeepsAppRolesAndNormalizesMembers(t *testing.T) {
+	cfg := importTestDB(t)
+	plan, err := services.PlanImport(cfg,
+		[]model.UserProfile{{TelegramID: "1", Username: "ivanov", FullName: "Иванов Иван", NumberOfPhone: "+79990000001", Role: "Руководитель"}},
+		[]model.Project{{Title: "Бот", Members: []model.ProjectMember{{Username: "ivanov", Role: "Руководитель"}, {Username: "sidorov", Role: "Разработчик"}}}},
+		false,
+	)
+	if err != nil {
+		t.Fatalf("PlanImport: %v", err)
+	}
+
+	if len(plan.Users) != 1 || plan.Users[0].User.Role != "Руководитель, Админ" {
+		t.Errorf("user changes = %+v, want role update keeping the admin role", plan.Users)
+	}
+	// отключённый участник в проект не возвращается, руководитель получает модератора
+	want := []model.ProjectMember{{Username: "ivanov", TelegramID: "1", Role: "Руководитель, Модератор"}}
+	if len(plan.Projects) != 1 || !reflect.DeepEqual(plan.Projects[0].Project.Members, want) {
+		t.Errorf("project changes = %+v, want members %+v", plan.Projects, want)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+const (
+	maxMotivationLength = 1000
+	// joinRequestCooldown — сколько отклонённый заявитель ждёт перед новой
+	// заявкой: иначе каждая повторная отправка снова уведомляет админов
+	joinRequestCooldown = 7 * 24 * time.Hour
+)
+
+var (
+	ErrAlreadyMember       = errors.New("user is already a member")
+	ErrJoinRequestCooldown = errors.New("join request was recently rejected, try again later")
+	ErrUnknownRole         = errors.New("unknown role")
+)
+
+// SubmitJoinRequest сохраняет заявку на вступление. Подпись Telegram в
+// form.Auth должна быть проверена до вызова.
+func SubmitJoinRequest(cfg *model.Config, form *model.JoinRequestForm) (*model.JoinRequest, error) {
+	telegramID := strconv.FormatInt(form.Auth.ID, 10)
+	if _, err := GetUserByTelegramID(cfg, telegramID); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	existing, err := repository.GetJoinRequestByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status == model.JoinRequestRejected {
+		if reviewedAt, err := time.Parse(time.RFC3339, existing.ReviewedAt); err == nil && time.Since(reviewedAt) < joinRequestCooldown {
+			return nil, ErrJoinRequestCooldown
+		}
+	}
+
+	fullName := strings.Join(strings.Fields(form.FullName), " ")
+	if fullName == "" {
+		return nil, fmt.Errorf("full_name is required")
+	}
+	phone, err := normalizePhone(form.NumberOfPhone)
+	if err != nil {
+		return nil, err
+	}
+	if phone == "" {
+		return nil, fmt.Errorf("number_of_phone is required")
+	}
+	motivation := strings.TrimSpace(form.Motivation)
+	if utf8.RuneCountInString(motivation) > maxMotivationLength {
+		return nil, fmt.Errorf("motivation must not exceed %d characters", maxMotivationLength)
+	}
+
+	request := &model.JoinRequest{
+		TelegramID:    telegramID,
+		Username:      strings.TrimPrefix(strings.TrimSpace(form.Auth.Username), "@"),
+		FirstName:     form.Auth.FirstName,
+		LastName:      form.Auth.LastName,
+		PhotoURL:      form.Auth.PhotoURL,
+		FullName:      fullName,
+		NumberOfPhone: phone,
+		Motivation:    motivation,
+		Status:        model.JoinRequestPending,
+		CreatedAt:     time.Now().Format(time.RFC3339),
+	}
+	// повторная отправка анкеты в ожидании решения не дублирует уведомление
+	var notify func(id int) []model.OutboxMessage
+	if existing == nil || existing.Status != model.JoinRequestPending {
+		notify = func(id int) []model.OutboxMessage {
+			username := "—"
+			if request.Username != "" {
+				username = "@" + request.Username
+			}
+			// сообщения уходят с parse_mode=HTML: текст заявителя экранируется
+			return adminNotifications(cfg, fmt.Sprintf(
+				"🙋 Новая заявка на вступление\n\n"+
+					"ФИО: %s\n"+
+					"Telegram: %s\n"+
+					"Телефон: %s\n"+
+					"Мотивация: %s\n"+
+					"🆔 ID заявки: %d",
+				html.EscapeString(request.FullName),
+				html.EscapeString(username),
+				html.EscapeString(request.NumberOfPhone),
+				html.EscapeString(request.Motivation),
+				id,
+			))
+		}
+	}
+	if err := repository.SaveJoinRequest(cfg, request, notify); err != nil {
+		logger.Error.Printf("SubmitJoinRequest: failed to save request for %s: %v\n", telegramID, err)
+		return nil, err
+	}
+
+	return repository.GetJoinRequestByTelegramID(cfg, telegramID)
+}
+
+// GetJoinRequestStatus возвращает статус заявки пользователя или пустую
+// строку, если заявки нет.
+func GetJoinRequestStatus(cfg *model.Config, telegramID string) (string, error) {
+	request, err := repository.GetJoinRequestByTelegramID(cfg, telegramID)
+	if err != nil || request == nil {
+		return "", err
+	}
+	return request.Status, nil
+}
+
+func GetJoinRequests(cfg *model.Config, status string) ([]model.JoinRequest, error) {
+	return repository.GetJoinRequests(cfg, status)
+}
+
+// ApproveJoinRequest создаёт пользователя с указанной ролью и уведомляет заявителя.
+func ApproveJoinRequest(cfg *model.Config, requestID int, role string, reviewer string) (*model.JoinRequest, error) {
+	request, err := pendingJoinRequest(cfg, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	role = strings.TrimSpace(role)
+	if role == "" {
+		return nil, fmt.Errorf("role is required")
+	}
+	if !permissions.IsKnownRole(role) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRole, role)
+	}
+
+	if _, err := GetUserByTelegramID(cfg, request.TelegramID); err == nil {
+		return nil, ErrAlreadyMember
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	request.Status = model.JoinRequestApproved
+	request.Role = role
+	request.ReviewedBy = reviewer
+
+	user := &model.UserProfile{
+		TelegramID:    request.TelegramID,
+		FirstName:     request.FirstName,
+		LastName:      request.LastName,
+		Username:      request.Username,
+		PhotoURL:      request.PhotoURL,
+		FullName:      request.FullName,
+		NumberOfPhone: request.NumberOfPhone,
+		Role:          role,
+	}
+	notifications := applicantNotifications(request, fmt.Sprintf(
+		"🎉 Ваша заявка на вступление одобрена!\n\n"+
+			"Роль: %s\n"+
+			"Теперь можно войти через Telegram.",
+		html.EscapeString(role),
+	))
+	if err := repository.ApproveJoinRequest(cfg, request, user, notifications); err != nil {
+		logger.Error.Printf("ApproveJoinRequest: failed to approve request %d: %v\n", requestID, err)
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func RejectJoinRequest(cfg *model.Config, requestID int, reason string, reviewer string) (*model.JoinRequest, error) {
+	request, err := pendingJoinRequest(cfg, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = model.JoinRequestRejected
+	request.RejectReason = strings.TrimSpace(reason)
+	request.ReviewedBy = reviewer
+
+	message := "😔 Ваша заявка на вступление отклонена."
+	if request.RejectReason != "" {
+		message += "\n\nПричина: " + html.EscapeString(request.RejectReason)
+	}
+	if err := repository.RejectJoinRequest(cfg, request, applicantNotifications(request, message)); err != nil {
+		logger.Error.Printf("RejectJoinRequest: failed to reject request %d: %v\n", requestID, err)
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func pendingJoinRequest(cfg *model.Config, requestID int) (*model.JoinRequest, error) {
+	request, err := repository.GetJoinRequestByID(cfg, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, fmt.Errorf("join request not found")
+	}
+	if request.Status != model.JoinRequestPending {
+		return nil, fmt.Errorf("join request is already %s", request.Status)
+	}
+	return request, nil
+}
+
+func applicantNotifications(request *model.JoinRequest, message string) []model.OutboxMessage {
+	telegramID, err := strconv.ParseInt(request.TelegramID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return []model.OutboxMessage{{ChatID: telegramID, EventType: model.NotifyJoinRequest, Text: message}}
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+func GetMilestones(cfg *model.Config, projectID int) ([]model.Milestone, error) {
+	milestones, err := repository.GetMilestonesByProjectID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := repository.GetTasksByProjectID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[int]string, len(tasks))
+	for _, task := range tasks {
+		statuses[task.ID] = task.Status
+	}
+	for i := range milestones {
+		rollupMilestone(&milestones[i], statuses)
+	}
+
+	return milestones, nil
+}
+
+func CreateMilestone(cfg *model.Config, milestone *model.Milestone) error {
+	if err := validateMilestone(cfg, milestone); err != nil {
+		return err
+	}
+
+	id, err := repository.CreateMilestone(cfg, milestone)
+	if err != nil {
+		return err
+	}
+
+	milestone.ID = id
+	return nil
+}
+
+func UpdateMilestone(cfg *model.Config, milestone *model.Milestone) error {
+	existing, err := repository.GetMilestoneByID(cfg, milestone.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.IdProject != milestone.IdProject {
+		return fmt.Errorf("milestone not found")
+	}
+
+	if err := validateMilestone(cfg, milestone); err != nil {
+		return err
+	}
+
+	return repository.UpdateMilestone(cfg, milestone)
+}
+
+func DeleteMilestone(cfg *model.Config, projectID int, milestoneID int) error {
+	existing, err := repository.GetMilestoneByID(cfg, milestoneID)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.IdProject != projectID {
+		return fmt.Errorf("milestone not found")
+	}
+
+	return repository.DeleteMilestone(cfg, milestoneID)
+}
+
+func validateMilestone(cfg *model.Config, milestone *model.Milestone) error {
+	milestone.Title = strings.TrimSpace(milestone.Title)
+	if milestone.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+
+	milestone.DueDate = strings.TrimSpace(milestone.DueDate)
+	if _, err := time.Parse("2006-01-02", milestone.DueDate); err != nil {
+		return fmt.Errorf("invalid due_date format: %v", err)
+	}
+
+	if milestone.TaskIDs == nil {
+		milestone.TaskIDs = []int{}
+	}
+	for _, taskID := range milestone.TaskIDs {
+		task, err := GetTaskByID(cfg, taskID)
+		if err != nil {
+			return err
+		}
+		if task == nil || task.IdProject != milestone.IdProject {
+			return fmt.Errorf("task %d does not belong to project", taskID)
+		}
+	}
+
+	return nil
+}
+
+// rollupMilestone считает прогресс вехи по связанным задачам: веха выполнена,
+// когда приняты все её задачи.
+func rollupMilestone(milestone *model.Milestone, statuses map[int]string) {
+	linked := 0
+	done := 0
+	for _, taskID := range milestone.TaskIDs {
+		status, ok := statuses[taskID]
+		if !ok {
+			continue
+		}
+		linked++
+		if strings.EqualFold(status, "Выполнена") {
+			done++
+		}
+	}
+
+	milestone.Progress = 0
+	if linked > 0 {
+		milestone.Progress = float64(done) * 100 / float64(linked)
+	}
+	milestone.Completed = linked > 0 && done == linked
+}
+
+func SetTaskDependencies(cfg *model.Config, taskID int, dependsOn []int) error {
+	task, err := GetTaskByID(cfg, taskID)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		return fmt.Errorf("task not found")
+	}
+
+	dependencies, err := repository.GetTaskDependenciesByProjectID(cfg, task.IdProject)
+	if err != nil {
+		return err
+	}
+
+	graph := make(map[int][]int)
+	for _, d := range dependencies {
+		if d.TaskID == taskID {
+			continue
+		}
+		graph[d.TaskID] = append(graph[d.TaskID], d.DependsOn)
+	}
+
+	for _, dependency := range dependsOn {
+		if dependency == taskID {
+			return fmt.Errorf("task cannot depend on itself")
+		}
+		other, err := GetTaskByID(cfg, dependency)
+		if err != nil {
+			return err
+		}
+		if other == nil || other.IdProject != task.IdProject {
+			return fmt.Errorf("task %d does not belong to project", dependency)
+		}
+		if dependsTransitively(graph, dependency, taskID, map[int]bool{}) {
+			return fmt.Errorf("dependency on task %d creates a cycle", dependency)
+		}
+	}
+
+	return repository.SetTaskDependencies(cfg, taskID, dependsOn)
+}
+
+func dependsTransitively(graph map[int][]int, from int, target int, visited map[int]bool) bool {
+	if from == target {
+		return true
+	}
+	if visited[from] {
+		return false
+	}
+	visited[from] = true
+
+	for _, next := range graph[from] {
+		if dependsTransitively(graph, next, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+func GetProjectTimeline(cfg *model.Config, projectID int) (*model.Timeline, error) {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	tasks, err := repository.GetTasksByProjectID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	milestones, err := GetMilestones(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := repository.GetTaskDependenciesByProjectID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &model.Timeline{
+		ProjectID: projectID,
+		Items:     make([]model.TimelineItem, 0, len(tasks)+len(milestones)),
+		Edges:     make([]model.TimelineEdge, 0, len(dependencies)),
+	}
+
+	// задачи приходят в порядке убывания id, на диаграмме удобнее по возрастанию
+	for i := len(tasks) - 1; i >= 0; i-- {
+		task := tasks[i]
+		start, end := taskTimelineRange(&task)
+		done := strings.EqualFold(task.Status, "Выполнена")
+
+		item := model.TimelineItem{
+			ID:        timelineTaskID(task.ID),
+			Type:      "task",
+			RefID:     task.ID,
+			Title:     task.Title,
+			Start:     start,
+			End:       end,
+			Status:    task.Status,
+			Assignee:  task.User,
+			Completed: done,
+		}
+		if done {
+			item.Progress = 100
+		}
+		timeline.Items = append(timeline.Items, item)
+	}
+
+	for _, milestone := range milestones {
+		timeline.Items = append(timeline.Items, model.TimelineItem{
+			ID:        fmt.Sprintf("milestone-%d", milestone.ID),
+			Type:      "milestone",
+			RefID:     milestone.ID,
+			Title:     milestone.Title,
+			Start:     milestone.DueDate,
+			End:       milestone.DueDate,
+			Progress:  milestone.Progress,
+			Completed: milestone.Completed,
+		})
+		for _, taskID := range milestone.TaskIDs {
+			timeline.Edges = append(timeline.Edges, model.TimelineEdge{
+				From: timelineTaskID(taskID),
+				To:   fmt.Sprintf("milestone-%d", milestone.ID),
+				Type: "milestone",
+			})
+		}
+	}
+
+	for _, d := range dependencies {
+		timeline.Edges = append(timeline.Edges, model.TimelineEdge{
+			From: timelineTaskID(d.DependsOn),
+			To:   timelineTaskID(d.TaskID),
+			Type: "dependency",
+		})
+	}
+
+	return timeline, nil
+}
+
+// taskTimelineRange возвращает даты начала и конца задачи в формате
+// "2006-01-02": без start_date началом считается дата создания.
+func taskTimelineRange(task *model.Task) (string, string) {
+	end := ""
+	if deadline, err := parseTaskDate(task.Deadline); err == nil {
+		end = deadline.Format("2006-01-02")
+	}
+
+	start := ""
+	if startDate, err := parseTaskDate(task.StartDate); err == nil {
+		start = startDate.Format("2006-01-02")
+	} else if createdAt, err := time.Parse(time.RFC3339, task.CreatedAt); err == nil {
+		start = createdAt.Format("2006-01-02")
+	}
+
+	if start == "" {
+		start = end
+	}
+	if end == "" {
+		end = start
+	}
+	if start > end {
+		start = end
+	}
+
+	return start, end
+}
+
+func timelineTaskID(taskID int) string {
+	return fmt.Sprintf("task-%d", taskID)
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// GetNotificationSettings возвращает настройки уведомлений пользователя;
+// не заданные типы получают канал telegram, часовой пояс — пояс сообщества.
+func GetNotificationSettings(cfg *model.Config, telegramID string) (*model.NotificationSettings, error) {
+	settings, err := repository.GetNotificationSettings(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &model.NotificationSettings{}
+	}
+
+	events := make(map[string]string, len(model.NotificationEvents))
+	for _, event := range model.NotificationEvents {
+		events[event] = model.ChannelTelegram
+		if channel, ok := settings.Events[event]; ok {
+			events[event] = channel
+		}
+		// сводка не покрывает этот тип — без немедленной отправки он бы потерялся
+		if events[event] == model.ChannelDigest && !isDigestEvent(event) {
+			events[event] = model.ChannelTelegram
+		}
+	}
+	// раньше запросы на проверку приходили как comment — сохраняем выбранный канал
+	if _, ok := settings.Events[model.NotifyReviewRequest]; !ok {
+		if channel, ok := settings.Events[model.NotifyComment]; ok {
+			events[model.NotifyReviewRequest] = channel
+		}
+	}
+	settings.Events = events
+	if settings.Digest == nil {
+		settings.Digest = &model.DigestSettings{Frequency: model.DigestOff, Hour: defaultDigestHour}
+	}
+	if settings.Timezone == "" {
+		settings.Timezone = config.Location(cfg).String()
+	}
+
+	return settings, nil
+}
+
+// UpdateNotificationSettings проверяет и сохраняет настройки. Типы, не
+// указанные в events, сводка и часовой пояс остаются как были; тихие часы
+// заменяются целиком — без quiet_hours они отключаются.
+func UpdateNotificationSettings(cfg *model.Config, telegramID string, update *model.NotificationSettings) (*model.NotificationSettings, error) {
+	settings, err := GetNotificationSettings(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	for event, channel := range update.Events {
+		if !isNotificationEvent(event) {
+			return nil, fmt.Errorf("unknown event type %q, expected one of %s", event, strings.Join(model.NotificationEvents, ", "))
+		}
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != model.ChannelTelegram && channel != model.ChannelDigest && channel != model.ChannelNone {
+			return nil, fmt.Errorf("invalid channel %q for %s, expected telegram, digest or none", channel, event)
+		}
+		if channel == model.ChannelDigest && !isDigestEvent(event) {
+			return nil, fmt.Errorf("%s cannot use the digest channel, expected one of %s", event, strings.Join(model.DigestEvents, ", "))
+		}
+		settings.Events[event] = channel
+	}
+
+	if update.Timezone != "" {
+		if _, err := time.LoadLocation(update.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown timezone %q", update.Timezone)
+		}
+		settings.Timezone = update.Timezone
+	}
+
+	settings.QuietHours = nil
+	if update.QuietHours != nil {
+		start, errStart := parseClock(update.QuietHours.Start)
+		end, errEnd := parseClock(update.QuietHours.End)
+		if errStart != nil || errEnd != nil {
+			return nil, fmt.Errorf("quiet_hours must be in HH:MM format")
+		}
+		if start == end {
+			return nil, fmt.Errorf("quiet_hours start and end must differ")
+		}
+		settings.QuietHours = &model.QuietHours{
+			Start: formatClock(start),
+			End:   formatClock(end),
+		}
+	}
+
+	if update.Digest != nil {
+		digest, err := normalizeDigest(update.Digest)
+		if err != nil {
+			return nil, err
+		}
+		settings.Digest = digest
+	}
+	if settings.Digest.Frequency == model.DigestOff {
+		for event, channel := range settings.Events {
+			if channel == model.ChannelDigest {
+				return nil, fmt.Errorf("%s uses the digest channel, but the digest is off", event)
+			}
+		}
+	}
+
+	if err := repository.SaveNotificationSettings(cfg, telegramID, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// notifyUser ставит в очередь уведомление типа event с учётом настроек
+// пользователя.
+func notifyUser(cfg *model.Config, telegramID int64, event string, text string) {
+	if message := userNotification(cfg, telegramID, event, text); message != nil {
+		enqueueNotifications(cfg, *message)
+	}
+}
+
+// userNotification собирает уведомление типа event по настройкам
+// пользователя: для отключённых типов и канала digest возвращает nil, в тихие
+// часы откладывает отправку до их окончания.
+func userNotification(cfg *model.Config, telegramID int64, event string, text string) *model.OutboxMessage {
+	if telegramID == 0 {
+		return nil
+	}
+	message := &model.OutboxMessage{ChatID: telegramID, EventType: event, Text: text}
+	id := strconv.FormatInt(telegramID, 10)
+
+	settings, err := GetNotificationSettings(cfg, id)
+	if err != nil {
+		// настройки недоступны — лучше доставить сообщение, чем потерять
+		logger.Error.Printf("userNotification: failed to load settings for %s: %v\n", id, err)
+		return message
+	}
+	// при канале digest событие попадёт в ближайшую сводку
+	if channel := settings.Events[event]; channel == model.ChannelNone || channel == model.ChannelDigest {
+		return nil
+	}
+
+	if deliverAt, quiet := quietHoursEnd(settings, time.Now()); quiet {
+		message.NextAttemptAt = deliverAt.UTC().Format(time.RFC3339)
+	}
+	return message
+}
+
+// quietHoursEnd сообщает, попадает ли now в тихие часы, и когда они
+// закончатся.
+func quietHoursEnd(settings *model.NotificationSettings, now time.Time) (time.Time, bool) {
+	if settings.QuietHours == nil {
+		return time.Time{}, false
+	}
+	start, errStart := parseClock(settings.QuietHours.Start)
+	end, errEnd := parseClock(settings.QuietHours.End)
+	if errStart != nil || errEnd != nil || start == end {
+		return time.Time{}, false
+	}
+
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	minute := now.Hour()*60 + now.Minute()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	endToday := midnight.Add(time.Duration(end) * time.Minute)
+
+	if start < end {
+		if minute >= start && minute < end {
+			return endToday, true
+		}
+		return time.Time{}, false
+	}
+
+	// интервал через полночь, например 22:00–08:00
+	switch {
+	case minute < end:
+		return endToday, true
+	case minute >= start:
+		return midnight.AddDate(0, 0, 1).Add(time.Duration(end) * time.Minute), true
+	}
+	return time.Time{}, false
+}
+
+// parseClock разбирает время "15:04" в минуты от полуночи.
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+func isNotificationEvent(event string) bool {
+	for _, known := range model.NotificationEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigestEvent(event string) bool {
+	for _, known := range model.DigestEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
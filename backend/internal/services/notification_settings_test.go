@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"backend/internal/model"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skip("timezone data is not available")
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 5, day, hour, minute, 0, 0, moscow)
+	}
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		now       time.Time
+		wantEnd   time.Time
+		wantQuiet bool
+	}{
+		{"overnight, before midnight", "22:00", "08:00", at(1, 23, 30), at(2, 8, 0), true},
+		{"overnight, at start", "22:00", "08:00", at(1, 22, 0), at(2, 8, 0), true},
+		{"overnight, after midnight", "22:00", "08:00", at(2, 3, 15), at(2, 8, 0), true},
+		{"overnight, at end", "22:00", "08:00", at(2, 8, 0), time.Time{}, false},
+		{"overnight, daytime", "22:00", "08:00", at(2, 12, 0), time.Time{}, false},
+		{"same day, inside", "13:00", "14:00", at(1, 13, 30), at(1, 14, 0), true},
+		{"same day, outside", "13:00", "14:00", at(1, 14, 30), time.Time{}, false},
+		{"empty interval", "10:00", "10:00", at(1, 10, 0), time.Time{}, false},
+		{"invalid clock", "25:00", "08:00", at(1, 23, 0), time.Time{}, false},
+		{"end of month", "22:00", "08:00", at(31, 23, 0), time.Date(2024, 6, 1, 8, 0, 0, 0, moscow), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := &model.NotificationSettings{
+				QuietHours: &model.QuietHours{Start: tt.start, End: tt.end},
+				Timezone:   "Europe/Moscow",
+			}
+			// момент может прийти в любом поясе, считается по поясу пользователя
+			end, quiet := quietHoursEnd(settings, tt.now.UTC())
+			if quiet != tt.wantQuiet || !end.Equal(tt.wantEnd) {
+				t.Errorf("quietHoursEnd() = (%v, %v), want (%v, %v)", end, quiet, tt.wantEnd, tt.wantQuiet)
+			}
+		})
+	}
+}
+
+func TestQuietHoursEndDisabled(t *testing.T) {
+	if _, quiet := quietHoursEnd(&model.NotificationSettings{}, time.Now()); quiet {
+		t.Error("quietHoursEnd() reported quiet hours without settings")
+	}
+}
@@ -0,0 +1,88 @@
+package services_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/services"
+)
+
+func TestGetEventsExpandsSeries(t *testing.T) {
+	cfg := newTestDB(t)
+	event := &model.Event{
+		Title:      "Планёрка",
+		StartAt:    "2024-05-06T18:00:00+03:00",
+		EndAt:      "2024-05-06T19:00:00+03:00",
+		Recurrence: &model.Recurrence{Frequency: model.RecurrenceWeekly, Count: 4},
+	}
+	if err := services.CreateEvent(cfg, event); err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	if err := services.CancelEventOccurrence(cfg, event.ID, "2024-05-13"); err != nil {
+		t.Fatalf("CancelEventOccurrence: %v", err)
+	}
+	if _, err := services.UpdateEventOccurrence(cfg, event.ID, "2024-05-20", &model.Event{
+		Title:   "Планёрка с гостями",
+		StartAt: "2024-05-21T12:00:00+03:00",
+	}); err != nil {
+		t.Fatalf("UpdateEventOccurrence: %v", err)
+	}
+
+	at := func(value string) time.Time {
+		parsed, _ := time.Parse(time.RFC3339, value)
+		return parsed
+	}
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want []string
+	}{
+		{
+			"whole series",
+			at("2024-05-01T00:00:00+03:00"),
+			at("2024-07-01T00:00:00+03:00"),
+			[]string{
+				"2024-05-06 Планёрка 2024-05-06T15:00:00Z",
+				"2024-05-20 Планёрка с гостями 2024-05-21T09:00:00Z",
+				"2024-05-27 Планёрка 2024-05-27T15:00:00Z",
+			},
+		},
+		{
+			"moved occurrence is found by its new time",
+			at("2024-05-21T00:00:00+03:00"),
+			at("2024-05-22T00:00:00+03:00"),
+			[]string{"2024-05-20 Планёрка с гостями 2024-05-21T09:00:00Z"},
+		},
+		{
+			"cancelled occurrence",
+			at("2024-05-13T00:00:00+03:00"),
+			at("2024-05-14T00:00:00+03:00"),
+			[]string{},
+		},
+		{
+			"after the last occurrence",
+			at("2024-06-01T00:00:00+03:00"),
+			at("2024-07-01T00:00:00+03:00"),
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := services.GetEvents(cfg, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("GetEvents: %v", err)
+			}
+			got := make([]string, 0, len(events))
+			for _, e := range events {
+				got = append(got, e.OccurrenceDate+" "+e.Title+" "+at(e.StartAt).UTC().Format(time.RFC3339))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetEvents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
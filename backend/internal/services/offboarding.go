@@ -0,0 +1,252 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+var ErrUserDeactivated = errors.New("user is deactivated")
+
+// ErrInvalidAssignee — получатель задач или проектов не может их принять.
+var ErrInvalidAssignee = errors.New("invalid assignee")
+
+// IsUserActive сообщает, может ли пользователь работать с API. Пользователи,
+// которых нет в таблице users, считаются активными: доступ для них решают
+// обработчики. Прочие ошибки возвращаются, чтобы вызывающий не пропустил
+// отключённый аккаунт.
+func IsUserActive(cfg *model.Config, telegramID string) (bool, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	return user.Active, nil
+}
+
+// OffboardUser отключает аккаунт, передаёт или снимает открытые задачи
+// участника, исключает его из проектов и уведомляет руководителей проектов.
+// Все изменения записываются одной транзакцией: при ошибке аккаунт остаётся
+// активным, а задачи и проекты — нетронутыми.
+func OffboardUser(cfg *model.Config, telegramID string, req *model.OffboardingRequest, by string) (*model.OffboardingReport, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrUserDeactivated
+	}
+
+	// все получатели проверяются до каких-либо изменений
+	assignees := make(map[string]*model.UserProfile)
+	targets := make([]string, 0, len(req.Tasks)+len(req.Projects))
+	for _, username := range req.Tasks {
+		targets = append(targets, username)
+	}
+	for _, username := range req.Projects {
+		targets = append(targets, username)
+	}
+	for _, username := range targets {
+		normalized := normalizeUsername(username)
+		if normalized == "" || assignees[normalized] != nil {
+			continue
+		}
+		assignee, err := GetUserByUsername(cfg, normalized)
+		if err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return nil, fmt.Errorf("%w: user @%s not found", ErrInvalidAssignee, normalized)
+			}
+			return nil, err
+		}
+		if assignee.TelegramID == user.TelegramID {
+			return nil, fmt.Errorf("%w: tasks cannot be reassigned to the offboarded user", ErrInvalidAssignee)
+		}
+		if !assignee.Active {
+			return nil, fmt.Errorf("%w: user @%s is deactivated", ErrInvalidAssignee, normalized)
+		}
+		assignees[normalized] = assignee
+	}
+
+	id, _ := strconv.ParseInt(user.TelegramID, 10, 64)
+	username := normalizeUsername(user.Username)
+	tasks, err := repository.GetTasksByAssignee(cfg, id, username)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := GetProjectsByUsername(cfg, username)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.OffboardingReport{
+		TelegramID:          user.TelegramID,
+		FullName:            user.FullName,
+		RemovedFromProjects: make([]int, 0, len(projects)),
+		Reassigned:          make([]model.TaskReassignment, 0),
+		Unassigned:          make([]model.TaskReassignment, 0),
+	}
+
+	changedTasks := make([]model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if strings.EqualFold(task.Status, "Выполнена") {
+			continue
+		}
+
+		target, ok := req.Tasks[task.ID]
+		if !ok {
+			target = req.Projects[task.IdProject]
+		}
+		assignee := assignees[normalizeUsername(target)]
+
+		task := task
+		entry := model.TaskReassignment{TaskID: task.ID, Title: task.Title, ProjectID: task.IdProject}
+		task.User = ""
+		task.IdUser = 0
+		if assignee != nil {
+			task.User = assignee.Username
+			task.IdUser, _ = strconv.ParseInt(assignee.TelegramID, 10, 64)
+			entry.To = assignee.Username
+		}
+
+		changedTasks = append(changedTasks, task)
+		if assignee == nil {
+			report.Unassigned = append(report.Unassigned, entry)
+			continue
+		}
+		report.Reassigned = append(report.Reassigned, entry)
+	}
+
+	members := make(map[int]string, len(projects))
+	for _, project := range projects {
+		filtered := make([]model.ProjectMember, 0, len(project.Members))
+		for _, member := range project.Members {
+			if normalizeUsername(member.Username) != username {
+				filtered = append(filtered, member)
+			}
+		}
+		payload, err := json.Marshal(filtered)
+		if err != nil {
+			return nil, err
+		}
+		members[project.ID] = string(payload)
+		report.RemovedFromProjects = append(report.RemovedFromProjects, project.ID)
+	}
+
+	notifications := make([]model.OutboxMessage, 0, len(changedTasks))
+	for _, task := range changedTasks {
+		if task.IdUser == 0 {
+			continue
+		}
+		notification := userNotification(cfg, task.IdUser, model.NotifyNewTask, fmt.Sprintf(
+			"📌 Вам передана задача\n\n"+
+				"Задача: %s\n"+
+				"Прежний исполнитель: %s\n"+
+				"🆔 ID задачи: %d",
+			html.EscapeString(task.Title),
+			html.EscapeString(user.FullName),
+			task.ID,
+		))
+		if notification != nil {
+			notifications = append(notifications, *notification)
+		}
+	}
+
+	// задачи могли быть и в проектах, где участник не состоял
+	affected := projects
+	known := make(map[int]bool, len(projects))
+	for _, project := range projects {
+		known[project.ID] = true
+	}
+	for _, entry := range append(report.Reassigned, report.Unassigned...) {
+		if entry.ProjectID == 0 || known[entry.ProjectID] {
+			continue
+		}
+		known[entry.ProjectID] = true
+		if project, err := GetProjectByID(cfg, entry.ProjectID); err == nil && project != nil {
+			affected = append(affected, *project)
+		}
+	}
+	notifications = append(notifications, offboardingLeaderNotifications(cfg, affected, user, report)...)
+
+	// уведомления пишутся в той же транзакции, что и изменения
+	if err := repository.OffboardUser(cfg, user.TelegramID, by, changedTasks, members, notifications); err != nil {
+		logger.Error.Printf("OffboardUser: failed to offboard %s: %v\n", user.TelegramID, err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// ReactivateUser снова разрешает вход. Задачи и участие в проектах не
+// восстанавливаются.
+func ReactivateUser(cfg *model.Config, telegramID string) error {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return err
+	}
+	if user.Active {
+		return nil
+	}
+	return repository.SetUserActive(cfg, user.TelegramID, true, "")
+}
+
+// offboardingLeaderNotifications собирает для руководителей каждого
+// затронутого проекта сводку по задачам этого проекта.
+func offboardingLeaderNotifications(cfg *model.Config, projects []model.Project, user *model.UserProfile, report *model.OffboardingReport) []model.OutboxMessage {
+	var notifications []model.OutboxMessage
+	for _, project := range projects {
+		var lines []string
+		for _, entry := range report.Reassigned {
+			if entry.ProjectID == project.ID {
+				lines = append(lines, fmt.Sprintf("• %s → @%s", html.EscapeString(entry.Title), html.EscapeString(entry.To)))
+			}
+		}
+		for _, entry := range report.Unassigned {
+			if entry.ProjectID == project.ID {
+				lines = append(lines, fmt.Sprintf("• %s → без исполнителя", html.EscapeString(entry.Title)))
+			}
+		}
+
+		tasksSummary := "открытых задач не было"
+		if len(lines) > 0 {
+			tasksSummary = strings.Join(lines, "\n")
+		}
+
+		message := fmt.Sprintf(
+			"👋 Участник покинул сообщество\n\n"+
+				"Участник: %s\n"+
+				"Проект: %s\n\n"+
+				"Задачи:\n%s",
+			html.EscapeString(user.FullName),
+			html.EscapeString(project.Title),
+			tasksSummary,
+		)
+
+		for _, member := range project.Members {
+			if normalizeUsername(member.Username) == normalizeUsername(user.Username) {
+				continue
+			}
+			if !permissions.IsLeader(member.Role) && !permissions.IsAdmin(member.Role) {
+				continue
+			}
+			leader, err := GetUserByUsername(cfg, member.Username)
+			if err != nil || !leader.Active {
+				continue
+			}
+			if telegramID, err := strconv.ParseInt(leader.TelegramID, 10, 64); err == nil {
+				notifications = append(notifications, model.OutboxMessage{ChatID: telegramID, EventType: model.NotifyOffboarding, Text: message})
+			}
+		}
+	}
+	return notifications
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/notifications"
+	"backend/internal/repository"
+)
+
+// Повторы: 30с, 1м, 2м… но не реже раза в час; после outboxMaxAttempts
+// неудач сообщение уходит в dead. Ответ 429 попыткой не считается —
+// повтор планируется через retry_after, который назвал Telegram, и до этого
+// момента отправка останавливается во все чаты. Отправленные сообщения
+// хранятся outboxRetention.
+const (
+	outboxBatchSize   = 100
+	outboxLease       = 2 * time.Minute
+	outboxMaxAttempts = 8
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+	outboxRetention   = 30 * 24 * time.Hour
+
+	defaultOutboxListLimit = 100
+	maxOutboxListLimit     = 1000
+)
+
+var ErrOutboxMessageNotFound = errors.New("dead outbox message not found")
+
+// outboxPause — до какого момента Telegram просил не отправлять сообщения.
+// Лимит действует на бота целиком, поэтому пауза общая для всех чатов.
+var outboxPause struct {
+	sync.Mutex
+	until time.Time
+}
+
+func pauseOutbox(until time.Time) {
+	outboxPause.Lock()
+	defer outboxPause.Unlock()
+	if until.After(outboxPause.until) {
+		outboxPause.until = until
+	}
+}
+
+func outboxPausedUntil(now time.Time) (time.Time, bool) {
+	outboxPause.Lock()
+	defer outboxPause.Unlock()
+	return outboxPause.until, now.Before(outboxPause.until)
+}
+
+// enqueueNotifications ставит сообщения в очередь отправки. Ошибка только
+// логируется: уведомления, которые пишутся вместе с изменением, передаются
+// в репозиторий напрямую.
+func enqueueNotifications(cfg *model.Config, messages ...model.OutboxMessage) {
+	if err := repository.EnqueueNotifications(cfg, messages); err != nil {
+		logger.Error.Printf("enqueueNotifications: failed to enqueue %d messages: %v\n", len(messages), err)
+	}
+}
+
+// queueNotification ставит в очередь служебное сообщение, не зависящее от
+// настроек пользователя.
+func queueNotification(cfg *model.Config, chatID int64, event string, text string) {
+	enqueueNotifications(cfg, model.OutboxMessage{ChatID: chatID, EventType: event, Text: text})
+}
+
+// DeliverOutbox отправляет сообщения, срок которых наступил. Чаты
+// обрабатываются параллельно, сообщения одного чата — по порядку: после
+// временной ошибки остальные сообщения чата откладываются вместе с ней. Во
+// время паузы после 429 сообщения не забираются из очереди.
+func DeliverOutbox(cfg *model.Config, now time.Time) {
+	if _, paused := outboxPausedUntil(now); paused {
+		return
+	}
+
+	claimed, err := repository.ClaimOutboxMessages(cfg, now, outboxLease, outboxBatchSize)
+	if err != nil {
+		logger.Error.Printf("DeliverOutbox: failed to claim messages: %v\n", err)
+		return
+	}
+	if len(claimed) == 0 {
+		return
+	}
+
+	chats := make([]int64, 0)
+	byChat := make(map[int64][]model.OutboxMessage)
+	for _, message := range claimed {
+		if _, ok := byChat[message.ChatID]; !ok {
+			chats = append(chats, message.ChatID)
+		}
+		byChat[message.ChatID] = append(byChat[message.ChatID], message)
+	}
+
+	workers := config.OutboxWorkerCount(cfg)
+	if workers > len(chats) {
+		workers = len(chats)
+	}
+	queue := make(chan []model.OutboxMessage)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for messages := range queue {
+				deliverChat(cfg, messages)
+			}
+		}()
+	}
+	for _, chatID := range chats {
+		queue <- byChat[chatID]
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func deliverChat(cfg *model.Config, messages []model.OutboxMessage) {
+	for i, message := range messages {
+		// пока действует пауза, остаток чата ждёт её окончания
+		if until, paused := outboxPausedUntil(time.Now()); paused {
+			postponeOutboxMessages(cfg, messages[i:], until)
+			return
+		}
+
+		err := notifications.SendTelegramNotification(cfg, message.ChatID, message.Text)
+		if err == nil {
+			if err := repository.MarkOutboxSent(cfg, message.ID, message.Attempts+1); err != nil {
+				logger.Error.Printf("deliverChat: failed to mark %d as sent: %v\n", message.ID, err)
+			}
+			continue
+		}
+
+		retryAt, attempts, dead := outboxRetry(message, err, time.Now())
+		if dead {
+			logger.Error.Printf("deliverChat: message %d to %d is dead after %d attempts: %v\n", message.ID, message.ChatID, attempts, err)
+			if err := repository.MarkOutboxDead(cfg, message.ID, attempts, err.Error()); err != nil {
+				logger.Error.Printf("deliverChat: failed to mark %d as dead: %v\n", message.ID, err)
+			}
+			continue
+		}
+
+		var apiErr *notifications.TelegramError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			pauseOutbox(retryAt)
+		}
+
+		if err := repository.RescheduleOutboxMessage(cfg, message.ID, attempts, retryAt, err.Error()); err != nil {
+			logger.Error.Printf("deliverChat: failed to reschedule %d: %v\n", message.ID, err)
+		}
+		postponeOutboxMessages(cfg, messages[i+1:], retryAt)
+		return
+	}
+}
+
+// postponeOutboxMessages откладывает сообщения до at, не считая это попыткой.
+func postponeOutboxMessages(cfg *model.Config, messages []model.OutboxMessage, at time.Time) {
+	for _, message := range messages {
+		if err := repository.RescheduleOutboxMessage(cfg, message.ID, message.Attempts, at, message.LastError); err != nil {
+			logger.Error.Printf("deliverChat: failed to reschedule %d: %v\n", message.ID, err)
+		}
+	}
+}
+
+// outboxRetry решает, когда повторить отправку после ошибки, или что
+// сообщение пора считать недоставляемым. Возвращает и новое число попыток.
+func outboxRetry(message model.OutboxMessage, err error, now time.Time) (time.Time, int, bool) {
+	attempts := message.Attempts + 1
+	var apiErr *notifications.TelegramError
+	if errors.As(err, &apiErr) {
+		if apiErr.RetryAfter > 0 {
+			return now.Add(apiErr.RetryAfter), message.Attempts, false
+		}
+		if apiErr.Permanent() {
+			return time.Time{}, attempts, true
+		}
+	}
+
+	if attempts >= outboxMaxAttempts {
+		return time.Time{}, attempts, true
+	}
+	backoff := outboxBaseBackoff << (attempts - 1)
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return now.Add(backoff), attempts, false
+}
+
+// PruneOutbox удаляет отправленные сообщения старше outboxRetention.
+func PruneOutbox(cfg *model.Config, now time.Time) {
+	pruned, err := repository.DeleteSentOutboxMessages(cfg, now.Add(-outboxRetention))
+	if err != nil {
+		logger.Error.Printf("PruneOutbox: failed to delete sent messages: %v\n", err)
+		return
+	}
+	if pruned > 0 {
+		logger.Info.Printf("PruneOutbox: deleted %d sent messages\n", pruned)
+	}
+}
+
+// GetOutboxMessages возвращает сообщения очереди в состоянии status
+// (пусто — в любом), последние — первыми.
+func GetOutboxMessages(cfg *model.Config, status string, limit int) ([]model.OutboxMessage, error) {
+	switch status {
+	case "", model.OutboxPending, model.OutboxSending, model.OutboxSent, model.OutboxDead:
+	default:
+		return nil, fmt.Errorf("invalid status %q, expected pending, sending, sent or dead", status)
+	}
+	if limit <= 0 {
+		limit = defaultOutboxListLimit
+	}
+	if limit > maxOutboxListLimit {
+		limit = maxOutboxListLimit
+	}
+	return repository.GetOutboxMessages(cfg, status, 0, limit)
+}
+
+// ReplayOutboxMessage заново ставит в очередь недоставленное сообщение.
+func ReplayOutboxMessage(cfg *model.Config, id int) (*model.OutboxReplay, error) {
+	if id <= 0 {
+		return nil, ErrOutboxMessageNotFound
+	}
+	replayed, err := repository.ReplayOutboxMessages(cfg, id)
+	if err != nil {
+		return nil, err
+	}
+	if replayed == 0 {
+		return nil, ErrOutboxMessageNotFound
+	}
+	return &model.OutboxReplay{Replayed: replayed}, nil
+}
+
+// ReplayDeadOutboxMessages заново ставит в очередь все недоставленные сообщения.
+func ReplayDeadOutboxMessages(cfg *model.Config) (*model.OutboxReplay, error) {
+	replayed, err := repository.ReplayOutboxMessages(cfg, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &model.OutboxReplay{Replayed: replayed}, nil
+}
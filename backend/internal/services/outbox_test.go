@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/notifications"
+)
+
+func TestOutboxRetry(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	network := errors.New("connection reset")
+
+	tests := []struct {
+		name         string
+		attempts     int
+		err          error
+		wantAt       time.Time
+		wantAttempts int
+		wantDead     bool
+	}{
+		{"first failure", 0, network, now.Add(30 * time.Second), 1, false},
+		{"backoff doubles", 2, network, now.Add(2 * time.Minute), 3, false},
+		{"last retry", outboxMaxAttempts - 2, network, now.Add(32 * time.Minute), outboxMaxAttempts - 1, false},
+		{"last attempt is dead", outboxMaxAttempts - 1, network, time.Time{}, outboxMaxAttempts, true},
+		{"server error retries", 0, &notifications.TelegramError{StatusCode: http.StatusBadGateway}, now.Add(30 * time.Second), 1, false},
+		{"bad request is dead", 0, &notifications.TelegramError{StatusCode: http.StatusBadRequest}, time.Time{}, 1, true},
+		{"blocked bot is dead", 3, &notifications.TelegramError{StatusCode: http.StatusForbidden}, time.Time{}, 4, true},
+		{
+			"429 waits retry_after without counting",
+			5,
+			&notifications.TelegramError{StatusCode: http.StatusTooManyRequests, RetryAfter: 45 * time.Second},
+			now.Add(45 * time.Second),
+			5,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at, attempts, dead := outboxRetry(model.OutboxMessage{Attempts: tt.attempts}, tt.err, now)
+			if !at.Equal(tt.wantAt) || attempts != tt.wantAttempts || dead != tt.wantDead {
+				t.Errorf("outboxRetry() = (%v, %d, %v), want (%v, %d, %v)", at, attempts, dead, tt.wantAt, tt.wantAttempts, tt.wantDead)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// exportSection — файл архива с личными данными.
+type exportSection struct {
+	name string
+	data interface{}
+}
+
+// ExportUserData пишет в w ZIP-архив со всеми данными, которые хранятся о
+// пользователе, по файлу JSON на раздел.
+func ExportUserData(cfg *model.Config, telegramID string, w io.Writer) error {
+	me, err := GetMe(cfg, telegramID)
+	if err != nil {
+		return err
+	}
+
+	id, _ := strconv.ParseInt(me.TelegramID, 10, 64)
+	username := normalizeUsername(me.Username)
+	assigned, err := repository.GetTasksByAssignee(cfg, id, username)
+	if err != nil {
+		return err
+	}
+	mentioned, err := repository.GetTasksMentioning(cfg, []string{username, me.FullName})
+	if err != nil {
+		return err
+	}
+
+	projects, err := GetProjectsByUsername(cfg, username)
+	if err != nil {
+		return err
+	}
+	memberships := make([]map[string]interface{}, 0, len(projects))
+	for _, project := range projects {
+		for _, member := range project.Members {
+			if normalizeUsername(member.Username) == username {
+				memberships = append(memberships, map[string]interface{}{
+					"project_id": project.ID,
+					"title":      project.Title,
+					"role":       member.Role,
+				})
+			}
+		}
+	}
+
+	attendance, err := repository.GetAttendanceByUser(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	changes, err := repository.GetProfileChanges(cfg, me.TelegramID, "")
+	if err != nil {
+		return err
+	}
+	joinRequest, err := repository.GetJoinRequestByTelegramID(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	notificationHistory, err := repository.GetBirthdayNotifications(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	notificationSettings, err := GetNotificationSettings(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	messages, err := repository.GetOutboxMessages(cfg, "", id, 0)
+	if err != nil {
+		return err
+	}
+	outbox := make([]model.OutboxExportRecord, 0, len(messages))
+	for _, m := range messages {
+		outbox = append(outbox, model.OutboxExportRecord{
+			EventType: m.EventType,
+			Text:      m.Text,
+			Status:    m.Status,
+			CreatedAt: m.CreatedAt,
+			SentAt:    m.SentAt,
+		})
+	}
+	digests, err := repository.GetDigestDeliveries(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+
+	skills, err := GetUserSkills(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	absence, err := GetAbsence(cfg, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	history, err := repository.GetTaskHistory(cfg, 0, me.TelegramID)
+	if err != nil {
+		return err
+	}
+	if history, err = withHistoryNames(cfg, history); err != nil {
+		return err
+	}
+
+	sections := []exportSection{
+		{"profile.json", me},
+		{"tasks.json", map[string]interface{}{"assigned": assigned, "authored_or_reviewed": mentioned}},
+		{"comments.json", taskComments(assigned, mentioned, []string{username, me.FullName})},
+		{"task_history.json", history},
+		{"projects.json", memberships},
+		{"skills.json", skills},
+		{"absence.json", absence},
+		{"events.json", attendance},
+		{"profile_changes.json", changes},
+		{"join_request.json", joinRequest},
+		{"notifications.json", map[string]interface{}{
+			"sent":     notificationHistory,
+			"outbox":   outbox,
+			"digests":  digests,
+			"settings": notificationSettings,
+		}},
+		// учёт времени по задачам не ведётся, раздел оставлен для полноты выгрузки
+		{"worklogs.json", []interface{}{}},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// taskComments собирает отчёты о выполнении из задач пользователя и его
+// комментарии к проверкам чужих задач.
+func taskComments(assigned []model.Task, mentioned []model.Task, names []string) []model.TaskComment {
+	comments := make([]model.TaskComment, 0)
+	for _, task := range assigned {
+		if task.CompletionMessage != "" {
+			comments = append(comments, model.TaskComment{
+				TaskID:    task.ID,
+				TaskTitle: task.Title,
+				Kind:      "completion",
+				Text:      task.CompletionMessage,
+			})
+		}
+	}
+	for _, task := range mentioned {
+		if task.ReviewMessage == "" {
+			continue
+		}
+		for _, name := range names {
+			if name != "" && strings.EqualFold(strings.TrimPrefix(task.ReviewedBy, "@"), name) {
+				comments = append(comments, model.TaskComment{
+					TaskID:    task.ID,
+					TaskTitle: task.Title,
+					Kind:      "review",
+					Text:      task.ReviewMessage,
+					At:        task.ReviewedAt,
+				})
+				break
+			}
+		}
+	}
+	return comments
+}
+
+// EraseUser обезличивает пользователя: личные поля очищаются, а в истории
+// задач и проектов он остаётся под псевдонимом. Аккаунт отключается.
+func EraseUser(cfg *model.Config, telegramID string) (*model.UserErasure, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	pseudonym := hex.EncodeToString(suffix)
+
+	erasure := &model.UserErasure{
+		TelegramID:  user.TelegramID,
+		OldUsername: normalizeUsername(user.Username),
+		OldFullName: user.FullName,
+		Username:    "deleted_" + pseudonym,
+		FullName:    "Удалённый участник " + pseudonym,
+	}
+	if err := repository.EraseUser(cfg, erasure); err != nil {
+		logger.Error.Printf("EraseUser: failed to erase %s: %v\n", user.TelegramID, err)
+		return nil, err
+	}
+
+	return erasure, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+// defaultPhoneVisibility — видимость телефона, если пользователь её не менял.
+const defaultPhoneVisibility = model.PrivacyProject
+
+// birthdayVisibility — кто видит полную дату рождения. День и месяц без
+// года доступны всем через календарь дней рождения.
+const birthdayVisibility = model.PrivacyProject
+
+// privacyViewer — кто запрашивает данные и в каких проектах он состоит.
+type privacyViewer struct {
+	telegramID string
+	admin      bool
+	projects   map[int]bool
+}
+
+// ApplyUserPrivacy скрывает в профилях поля, которые viewerID не должен
+// видеть. Сам пользователь и админы видят все поля; остальным телефон и дата
+// рождения показываются по правилам видимости.
+func ApplyUserPrivacy(cfg *model.Config, viewerID string, users []model.UserProfile) ([]model.UserProfile, error) {
+	viewer := privacyViewer{telegramID: strings.TrimSpace(viewerID)}
+	if user, err := GetUserByTelegramID(cfg, viewer.telegramID); err == nil {
+		viewer.admin = permissions.IsAdmin(user.Role)
+	} else if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+	if viewer.admin {
+		return users, nil
+	}
+
+	visibilities, err := repository.GetPhoneVisibilities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := projectMemberships(cfg)
+	if err != nil {
+		return nil, err
+	}
+	viewer.projects = memberships[viewer.telegramID]
+
+	visible := make([]model.UserProfile, 0, len(users))
+	for _, user := range users {
+		phoneVisibility := visibilities[user.TelegramID]
+		if phoneVisibility == "" {
+			phoneVisibility = defaultPhoneVisibility
+		}
+
+		if !viewer.canSee(user.TelegramID, memberships[user.TelegramID], phoneVisibility) {
+			user.NumberOfPhone = ""
+		}
+		if !viewer.canSee(user.TelegramID, memberships[user.TelegramID], birthdayVisibility) {
+			user.DateOfBirthday = ""
+		}
+		visible = append(visible, user)
+	}
+
+	return visible, nil
+}
+
+func (v privacyViewer) canSee(ownerID string, ownerProjects map[int]bool, visibility string) bool {
+	if v.admin || ownerID == v.telegramID {
+		return true
+	}
+
+	switch visibility {
+	case model.PrivacyEveryone:
+		return true
+	case model.PrivacyProject:
+		for projectID := range ownerProjects {
+			if v.projects[projectID] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// projectMemberships возвращает для каждого пользователя (по Telegram ID)
+// множество проектов, где он участник. Архивные проекты не учитываются.
+func projectMemberships(cfg *model.Config) (map[string]map[int]bool, error) {
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	byUsername := make(map[string]string, len(users))
+	for _, user := range users {
+		if user.Username != "" {
+			byUsername[normalizeUsername(user.Username)] = user.TelegramID
+		}
+	}
+
+	projects, err := GetProjects(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := make(map[string]map[int]bool)
+	for _, project := range projects {
+		if project.Archived {
+			continue
+		}
+		for _, member := range project.Members {
+			telegramID := member.TelegramID
+			if id, ok := byUsername[normalizeUsername(member.Username)]; ok {
+				telegramID = id
+			}
+			if telegramID == "" {
+				continue
+			}
+			if memberships[telegramID] == nil {
+				memberships[telegramID] = make(map[int]bool)
+			}
+			memberships[telegramID][project.ID] = true
+		}
+	}
+
+	return memberships, nil
+}
+
+func normalizePrivacy(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case model.PrivacyAdmins, model.PrivacyProject, model.PrivacyEveryone:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid visibility %q, expected admins, project or everyone", value)
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// identityFields — поля, изменение которых проходит через одобрение админа,
+// если оно включено в конфигурации.
+var identityFields = map[string]bool{
+	"full_name": true,
+}
+
+var profileFieldTitles = map[string]string{
+	"full_name":        "ФИО",
+	"date_of_birthday": "Дата рождения",
+	"number_of_phone":  "Телефон",
+}
+
+func GetMe(cfg *model.Config, telegramID string) (*model.MeProfile, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	pending, err := repository.GetProfileChanges(cfg, telegramID, model.ProfileChangePending)
+	if err != nil {
+		return nil, err
+	}
+
+	optOut, err := repository.GetBirthdayOptOut(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibilities, err := repository.GetPhoneVisibilities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	phoneVisibility := visibilities[telegramID]
+	if phoneVisibility == "" {
+		phoneVisibility = defaultPhoneVisibility
+	}
+
+	return &model.MeProfile{
+		UserProfile:     *user,
+		BirthdayOptOut:  optOut,
+		PhoneVisibility: phoneVisibility,
+		PendingChanges:  pending,
+	}, nil
+}
+
+// UpdateMe применяет изменения профиля пользователем. Поля из identityFields
+// при включённом одобрении не меняются сразу, а попадают в очередь заявок.
+func UpdateMe(cfg *model.Config, telegramID string, patch *model.ProfilePatch) (*model.MeProfile, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	if patch.FullName != nil {
+		fullName := strings.Join(strings.Fields(*patch.FullName), " ")
+		if fullName == "" {
+			return nil, fmt.Errorf("full_name must not be empty")
+		}
+		if fullName != user.FullName {
+			fields["full_name"] = fullName
+		}
+	}
+	if patch.DateOfBirthday != nil {
+		birthday, err := normalizeBirthday(*patch.DateOfBirthday)
+		if err != nil {
+			return nil, err
+		}
+		fields["date_of_birthday"] = birthday
+	}
+	if patch.NumberOfPhone != nil {
+		phone, err := normalizePhone(*patch.NumberOfPhone)
+		if err != nil {
+			return nil, err
+		}
+		fields["number_of_phone"] = phone
+	}
+	phoneVisibility := ""
+	if patch.PhoneVisibility != nil {
+		phoneVisibility, err = normalizePrivacy(*patch.PhoneVisibility)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	current := map[string]string{
+		"full_name":        user.FullName,
+		"date_of_birthday": user.DateOfBirthday,
+		"number_of_phone":  user.NumberOfPhone,
+	}
+
+	for field, value := range fields {
+		if cfg.ProfileApproval && identityFields[field] {
+			change := &model.ProfileChange{
+				TelegramID: telegramID,
+				Field:      field,
+				OldValue:   current[field],
+				Value:      value,
+				Status:     model.ProfileChangePending,
+				CreatedAt:  time.Now().Format(time.RFC3339),
+			}
+			id, err := repository.CreateProfileChange(cfg, change, func(id int) []model.OutboxMessage {
+				change.ID = id
+				return profileChangeRequestedNotifications(cfg, user, change)
+			})
+			if err != nil {
+				logger.Error.Printf("UpdateMe: failed to queue %s change for %s: %v\n", field, telegramID, err)
+				return nil, err
+			}
+			change.ID = id
+			continue
+		}
+
+		if err := repository.UpdateUserField(cfg, telegramID, field, value); err != nil {
+			logger.Error.Printf("UpdateMe: failed to update %s for %s: %v\n", field, telegramID, err)
+			return nil, err
+		}
+	}
+
+	if phoneVisibility != "" {
+		if err := repository.SetPhoneVisibility(cfg, telegramID, phoneVisibility); err != nil {
+			logger.Error.Printf("UpdateMe: failed to update phone visibility for %s: %v\n", telegramID, err)
+			return nil, err
+		}
+	}
+
+	if patch.BirthdayOptOut != nil {
+		if err := repository.SetBirthdayOptOut(cfg, telegramID, *patch.BirthdayOptOut); err != nil {
+			logger.Error.Printf("UpdateMe: failed to update birthday opt-out for %s: %v\n", telegramID, err)
+			return nil, err
+		}
+	}
+
+	return GetMe(cfg, telegramID)
+}
+
+func GetProfileChanges(cfg *model.Config, status string) ([]model.ProfileChange, error) {
+	return repository.GetProfileChanges(cfg, "", status)
+}
+
+func ReviewProfileChange(cfg *model.Config, changeID int, approve bool, reviewer string) (*model.ProfileChange, error) {
+	change, err := repository.GetProfileChangeByID(cfg, changeID)
+	if err != nil {
+		return nil, err
+	}
+	if change == nil {
+		return nil, fmt.Errorf("profile change not found")
+	}
+	if change.Status != model.ProfileChangePending {
+		return nil, fmt.Errorf("profile change is already %s", change.Status)
+	}
+
+	change.Status = model.ProfileChangeRejected
+	if approve {
+		change.Status = model.ProfileChangeApproved
+	}
+	change.ReviewedBy = reviewer
+
+	decision := "❌ отклонено"
+	if approve {
+		decision = "✅ одобрено"
+	}
+	message := fmt.Sprintf(
+		"👤 Изменение профиля %s\n\n"+
+			"Поле: %s\n"+
+			"Новое значение: %s",
+		decision,
+		profileFieldTitles[change.Field],
+		html.EscapeString(change.Value),
+	)
+	var notifications []model.OutboxMessage
+	if telegramID, err := strconv.ParseInt(change.TelegramID, 10, 64); err == nil {
+		notifications = append(notifications, model.OutboxMessage{ChatID: telegramID, EventType: model.NotifyProfileChange, Text: message})
+	}
+	if err := repository.ReviewProfileChange(cfg, change, notifications); err != nil {
+		logger.Error.Printf("ReviewProfileChange: failed to review change %d: %v\n", changeID, err)
+		return nil, err
+	}
+
+	updated, err := repository.GetProfileChangeByID(cfg, changeID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func profileChangeRequestedNotifications(cfg *model.Config, user *model.UserProfile, change *model.ProfileChange) []model.OutboxMessage {
+	message := fmt.Sprintf(
+		"📝 Заявка на изменение профиля\n\n"+
+			"Пользователь: %s\n"+
+			"Поле: %s\n"+
+			"Было: %s\n"+
+			"Стало: %s\n"+
+			"🆔 ID заявки: %d",
+		html.EscapeString(user.FullName),
+		profileFieldTitles[change.Field],
+		html.EscapeString(change.OldValue),
+		html.EscapeString(change.Value),
+		change.ID,
+	)
+	return adminNotifications(cfg, message)
+}
+
+// normalizeBirthday приводит дату рождения к формату профиля "02.01.2006".
+func normalizeBirthday(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	for _, layout := range []string{"02.01.2006", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if t.After(time.Now()) {
+				return "", fmt.Errorf("date_of_birthday must be in the past")
+			}
+			return t.Format("02.01.2006"), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid date_of_birthday format, expected DD.MM.YYYY")
+}
+
+// normalizePhone оставляет в номере только цифры (и ведущий +).
+func normalizePhone(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("invalid number_of_phone")
+		}
+	}
+
+	phone := b.String()
+	digits := len(strings.TrimPrefix(phone, "+"))
+	if digits < 10 || digits > 15 {
+		return "", fmt.Errorf("invalid number_of_phone")
+	}
+
+	return phone, nil
+}
@@ -3,8 +3,12 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"math"
+	"strconv"
 	"strings"
 
+	"backend/internal/logger"
 	"backend/internal/model"
 	"backend/internal/repository"
 )
@@ -18,10 +22,13 @@ func GetProjects(cfg *model.Config) ([]model.Project, error) {
 	var projects []model.Project
 	for _, row := range rows {
 		project := model.Project{
-			ID:          row.ID,
-			Title:       row.Title,
-			Description: row.Description,
-			Status:      row.Status,
+			ID:           row.ID,
+			Title:        row.Title,
+			Description:  row.Description,
+			Status:       row.Status,
+			StatusLocked: row.StatusLocked,
+			Archived:     row.Archived,
+			Visibility:   row.Visibility,
 		}
 
 		rawUsers := row.RawUsers
@@ -70,7 +77,108 @@ func UpdateProjectMembers(cfg *model.Config, projectID int, members []model.Proj
 }
 
 func UpdateProjectStatus(cfg *model.Config, projectID int, status string) error {
-	return repository.UpdateProjectStatus(cfg, projectID, status)
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("project not found")
+	}
+
+	return setProjectStatus(cfg, project, status)
+}
+
+func SetProjectArchived(cfg *model.Config, projectID int, archived bool) error {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("project not found")
+	}
+
+	return repository.UpdateProjectArchived(cfg, projectID, archived)
+}
+
+func SetProjectVisibility(cfg *model.Config, projectID int, visibility string) error {
+	switch visibility {
+	case model.VisibilityPublic, model.VisibilityMembers, model.VisibilityAdmins:
+	default:
+		return fmt.Errorf("invalid visibility")
+	}
+
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("project not found")
+	}
+
+	return repository.UpdateProjectVisibility(cfg, projectID, visibility)
+}
+
+func SetProjectStatusLock(cfg *model.Config, projectID int, locked bool) error {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return fmt.Errorf("project not found")
+	}
+
+	if err := repository.UpdateProjectStatusLock(cfg, projectID, locked); err != nil {
+		return err
+	}
+
+	if !locked {
+		return UpdateProjectStatusFromTasks(cfg, projectID)
+	}
+	return nil
+}
+
+func setProjectStatus(cfg *model.Config, project *model.Project, status string) error {
+	if project.Status == status {
+		return nil
+	}
+
+	message := fmt.Sprintf(
+		"📊 Статус проекта изменён\n\n"+
+			"Проект: %s\n"+
+			"Было: %s\n"+
+			"Стало: %s",
+		html.EscapeString(project.Title),
+		html.EscapeString(project.Status),
+		html.EscapeString(status),
+	)
+	members := projectMemberTelegramIDs(cfg, project)
+	notifications := make([]model.OutboxMessage, 0, len(members))
+	for _, telegramID := range members {
+		notifications = append(notifications, model.OutboxMessage{ChatID: telegramID, EventType: model.NotifyProjectStatus, Text: message})
+	}
+
+	return repository.UpdateProjectStatus(cfg, project.ID, status, notifications)
+}
+
+func projectMemberTelegramIDs(cfg *model.Config, project *model.Project) []int64 {
+	ids := make([]int64, 0, len(project.Members))
+	seen := make(map[int64]bool)
+	for _, member := range project.Members {
+		telegramID := member.TelegramID
+		if telegramID == "" {
+			if user, err := GetUserByUsername(cfg, member.Username); err == nil && user != nil {
+				telegramID = user.TelegramID
+			}
+		}
+
+		id, err := strconv.ParseInt(strings.TrimSpace(telegramID), 10, 64)
+		if err != nil || id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
 }
 
 func AddProjectMember(cfg *model.Config, projectID int, member model.ProjectMember) error {
@@ -158,20 +266,31 @@ func UpdateProjectStatusFromTasks(cfg *model.Config, projectID int) error {
 		return err
 	}
 
-	if hasTasks && allCompleted {
-		if project.Status != "Выполнен" {
-			return UpdateProjectStatus(cfg, projectID, "Выполнен")
-		}
+	// статус, выставленный администратором вручную, не пересчитываем
+	if project.StatusLocked || project.Archived {
 		return nil
 	}
 
+	if hasTasks && allCompleted {
+		return setProjectStatus(cfg, project, "Выполнен")
+	}
+
 	if project.Status == "Выполнен" {
-		return UpdateProjectStatus(cfg, projectID, "В работе")
+		return setProjectStatus(cfg, project, "В работе")
 	}
 
 	return nil
 }
 
+func refreshProjectStatus(cfg *model.Config, projectID int) {
+	if projectID == 0 {
+		return
+	}
+	if err := UpdateProjectStatusFromTasks(cfg, projectID); err != nil {
+		logger.Error.Printf("refreshProjectStatus: failed to update status of project %d: %v\n", projectID, err)
+	}
+}
+
 func GetProjectsByID(cfg *model.Config, id int) ([]model.Project, error) {
 	project, err := GetProjectByID(cfg, id)
 	if err != nil {
@@ -203,3 +322,55 @@ func normalizeUsername(username string) string {
 	username = strings.TrimPrefix(username, "@")
 	return strings.ToLower(username)
 }
+
+const (
+	healthOverdueWeight = 0.6
+	healthRecencyWeight = 0.4
+	healthFreshDays     = 3.0
+	healthStaleDays     = 30.0
+)
+
+func GetProjectStats(cfg *model.Config, projectID int) (*model.ProjectStats, error) {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	stats, err := repository.GetProjectStats(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.HealthScore = projectHealthScore(stats)
+	return stats, nil
+}
+
+// projectHealthScore возвращает оценку от 0 до 100: доля непросроченных
+// открытых задач и то, насколько давно задачи проекта обновлялись.
+func projectHealthScore(stats *model.ProjectStats) float64 {
+	if stats.Total == 0 {
+		return 0
+	}
+
+	open := stats.Total - stats.ByStatus["Выполнена"]
+	overdueRatio := 0.0
+	if open > 0 {
+		overdueRatio = float64(stats.Overdue) / float64(open)
+	}
+
+	// неизвестная давность обновления считается устаревшей
+	recency := 0.0
+	if days := stats.DaysSinceLastUpdate; days != nil {
+		switch {
+		case *days >= healthStaleDays:
+			recency = 0
+		case *days > healthFreshDays:
+			recency = 1 - (*days-healthFreshDays)/(healthStaleDays-healthFreshDays)
+		default:
+			recency = 1
+		}
+	}
+
+	score := 100 * (healthOverdueWeight*(1-overdueRatio) + healthRecencyWeight*recency)
+	return math.Round(score*10) / 10
+}
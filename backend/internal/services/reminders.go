@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/logger"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// SendEventReminders рассылает напоминания о событиях, начинающихся в
+// ближайшее время. Для каждого повторения отправляется только ближайшее
+// наступившее напоминание: если событие создано за 30 минут до начала,
+// участники получат одно сообщение, а не сразу за сутки и за час.
+func SendEventReminders(cfg *model.Config, now time.Time) {
+	offsets := config.EventReminderOffsets(cfg)
+	if len(offsets) == 0 {
+		return
+	}
+
+	events, err := GetEvents(cfg, now, now.Add(offsets[len(offsets)-1]))
+	if err != nil {
+		logger.Error.Printf("SendEventReminders: failed to load events: %v\n", err)
+		return
+	}
+
+	absences, err := activeAbsences(cfg, now)
+	if err != nil {
+		logger.Error.Printf("SendEventReminders: failed to load absences: %v\n", err)
+		absences = nil
+	}
+
+	for _, event := range events {
+		start, err := time.Parse(time.RFC3339, event.StartAt)
+		if err != nil || !start.After(now) {
+			continue
+		}
+
+		var due time.Duration
+		for _, offset := range offsets {
+			if !start.Add(-offset).After(now) {
+				due = offset
+				break
+			}
+		}
+		if due == 0 {
+			continue
+		}
+
+		recipients, err := eventReminderRecipients(cfg, &event)
+		if err != nil {
+			logger.Error.Printf("SendEventReminders: failed to resolve recipients for event %d: %v\n", event.ID, err)
+			continue
+		}
+
+		message := fmt.Sprintf(
+			"🔔 Напоминание о событии\n\n"+
+				"Событие: %s\n"+
+				"📅 Дата: %s\n"+
+				"⏰ Время: %s",
+			html.EscapeString(event.Title),
+			event.Date,
+			event.TimeRange,
+		)
+		notifications := make([]model.OutboxMessage, 0, len(recipients))
+		sent := make(map[int64]bool, len(recipients))
+		for _, telegramID := range recipients {
+			text := message
+			if absence, ok := absences[strconv.FormatInt(telegramID, 10)]; ok {
+				telegramID, text = delegateReminder(cfg, absence, telegramID, message)
+			}
+			// заместитель, приглашённый и сам, получает одно напоминание
+			if sent[telegramID] {
+				continue
+			}
+			sent[telegramID] = true
+			if notification := userNotification(cfg, telegramID, model.NotifyReminder, text); notification != nil {
+				notifications = append(notifications, *notification)
+			}
+		}
+
+		// напоминание отмечается отправленным вместе с постановкой в очередь
+		if _, err := repository.ClaimEventReminder(cfg, event.ID, start.UTC().Format(time.RFC3339), int(due/time.Minute), notifications); err != nil {
+			logger.Error.Printf("SendEventReminders: failed to claim reminder for event %d: %v\n", event.ID, err)
+		}
+	}
+}
+
+// delegateReminder перенаправляет напоминание отсутствующего участника его
+// заместителю.
+func delegateReminder(cfg *model.Config, absence model.Absence, telegramID int64, message string) (int64, string) {
+	delegateID, err := strconv.ParseInt(absence.DelegateID, 10, 64)
+	if err != nil {
+		return telegramID, message
+	}
+
+	name := absence.TelegramID
+	if user, err := GetUserByTelegramID(cfg, absence.TelegramID); err == nil {
+		name = html.EscapeString(user.FullName)
+	}
+	return delegateID, message + fmt.Sprintf("\n\n👤 Вы замещаете %s до %s", name, formatAbsenceDate(absence.Until))
+}
+
+// eventReminderRecipients возвращает приглашённых, кроме отказавшихся. Для
+// открытых событий напоминание получают только ответившие going или maybe.
+func eventReminderRecipients(cfg *model.Config, event *model.Event) ([]int64, error) {
+	invitations, err := eventInvitations(cfg, event)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := repository.GetEventAttendance(cfg, event.ID, event.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	rsvp := make(map[string]string, len(records))
+	for _, record := range records {
+		rsvp[record.TelegramID] = record.RSVP
+	}
+
+	candidates := make([]string, 0)
+	if len(invitations) == 0 {
+		for telegramID, status := range rsvp {
+			if status == model.RSVPGoing || status == model.RSVPMaybe {
+				candidates = append(candidates, telegramID)
+			}
+		}
+	} else {
+		users, err := GetUsers(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for telegramID := range resolveInvitees(cfg, invitations, users) {
+			if rsvp[telegramID] != model.RSVPNo {
+				candidates = append(candidates, telegramID)
+			}
+		}
+	}
+
+	recipients := make([]int64, 0, len(candidates))
+	for _, candidate := range candidates {
+		if telegramID, err := strconv.ParseInt(candidate, 10, 64); err == nil {
+			recipients = append(recipients, telegramID)
+		}
+	}
+
+	return recipients, nil
+}
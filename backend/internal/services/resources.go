@@ -0,0 +1,335 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+// availabilityWindow — период по умолчанию для просмотра занятости ресурса.
+const availabilityWindow = 7 * 24 * time.Hour
+
+// ResourceConflictError — ресурс уже забронирован другим событием на это время.
+type ResourceConflictError struct {
+	Resource model.Resource
+	Event    model.Event
+}
+
+func (e *ResourceConflictError) Error() string {
+	return fmt.Sprintf(
+		"resource '%s' is already booked by event '%s' (id %d) from %s to %s",
+		e.Resource.Name,
+		e.Event.Title,
+		e.Event.ID,
+		e.Event.StartAt,
+		e.Event.EndAt,
+	)
+}
+
+func GetResources(cfg *model.Config) ([]model.Resource, error) {
+	return repository.GetResources(cfg)
+}
+
+func GetResourceByID(cfg *model.Config, resourceID int) (*model.Resource, error) {
+	return repository.GetResourceByID(cfg, resourceID)
+}
+
+func CreateResource(cfg *model.Config, resource *model.Resource) error {
+	if err := validateResource(resource); err != nil {
+		return err
+	}
+
+	id, err := repository.CreateResource(cfg, resource)
+	if err != nil {
+		return err
+	}
+
+	resource.ID = id
+	return nil
+}
+
+func UpdateResource(cfg *model.Config, resource *model.Resource) error {
+	existing, err := repository.GetResourceByID(cfg, resource.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("resource not found")
+	}
+
+	if err := validateResource(resource); err != nil {
+		return err
+	}
+
+	return repository.UpdateResource(cfg, resource)
+}
+
+func DeleteResource(cfg *model.Config, resourceID int) error {
+	return repository.DeleteResource(cfg, resourceID)
+}
+
+func validateResource(resource *model.Resource) error {
+	resource.Name = strings.TrimSpace(resource.Name)
+	if resource.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	resource.Kind = strings.ToLower(strings.TrimSpace(resource.Kind))
+	switch resource.Kind {
+	case model.ResourceKindRoom, model.ResourceKindEquipment:
+	default:
+		return fmt.Errorf("invalid resource kind: %s", resource.Kind)
+	}
+
+	return nil
+}
+
+func GetEventResources(cfg *model.Config, eventID int) ([]model.Resource, error) {
+	ids, err := repository.GetEventResourceIDs(cfg, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]model.Resource, 0, len(ids))
+	for _, id := range ids {
+		resource, err := repository.GetResourceByID(cfg, id)
+		if err != nil {
+			return nil, err
+		}
+		if resource != nil {
+			resources = append(resources, *resource)
+		}
+	}
+
+	return resources, nil
+}
+
+// SetEventResources бронирует ресурсы на время события (на все его повторения).
+func SetEventResources(cfg *model.Config, eventID int, resourceIDs []int) error {
+	event, err := repository.GetEventByID(cfg, eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("event not found")
+	}
+
+	for _, resourceID := range resourceIDs {
+		resource, err := repository.GetResourceByID(cfg, resourceID)
+		if err != nil {
+			return err
+		}
+		if resource == nil {
+			return fmt.Errorf("resource %d not found", resourceID)
+		}
+	}
+
+	// проверка и запись идут под одной блокировкой, событие перечитывается
+	// внутри неё: его время могли изменить, пока мы ждали
+	return repository.SetEventResources(cfg, eventID, resourceIDs, func() error {
+		event, err := repository.GetEventByID(cfg, eventID)
+		if err != nil {
+			return err
+		}
+		if event == nil {
+			return fmt.Errorf("event not found")
+		}
+		return checkResourceConflicts(cfg, event, resourceIDs)
+	})
+}
+
+// checkResourceConflicts проверяет, что ресурсы свободны на всё время события.
+// event должен быть в том виде, в каком хранится: время в UTC.
+func checkResourceConflicts(cfg *model.Config, event *model.Event, resourceIDs []int) error {
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+
+	candidate, err := eventOccurrences(cfg, *event, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	if len(candidate) == 0 {
+		return nil
+	}
+
+	from, to := occurrencesSpan(candidate)
+	for _, resourceID := range resourceIDs {
+		resource, err := repository.GetResourceByID(cfg, resourceID)
+		if err != nil {
+			return err
+		}
+		if resource == nil {
+			continue
+		}
+
+		booked, err := resourceBookings(cfg, resourceID, from, to)
+		if err != nil {
+			return err
+		}
+
+		for _, other := range booked {
+			if other.ID == event.ID {
+				continue
+			}
+			for _, occurrence := range candidate {
+				if occurrencesOverlap(occurrence, other) {
+					return &ResourceConflictError{Resource: *resource, Event: other}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func GetResourceAvailability(cfg *model.Config, resourceID int, from time.Time, to time.Time) (*model.ResourceAvailability, error) {
+	resource, err := repository.GetResourceByID(cfg, resourceID)
+	if err != nil || resource == nil {
+		return nil, err
+	}
+
+	if from.IsZero() {
+		from = time.Now()
+	}
+	if to.IsZero() || !to.After(from) {
+		to = from.Add(availabilityWindow)
+	}
+
+	booked, err := resourceBookings(cfg, resourceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := config.Location(cfg)
+	availability := &model.ResourceAvailability{
+		Resource: *resource,
+		From:     from.In(loc).Format(time.RFC3339),
+		To:       to.In(loc).Format(time.RFC3339),
+		Busy:     make([]model.BusySlot, 0, len(booked)),
+		Free:     make([]model.FreeSlot, 0),
+	}
+
+	cursor := from
+	for _, event := range booked {
+		start, end := occurrenceBounds(event)
+		availability.Busy = append(availability.Busy, model.BusySlot{
+			EventID:        event.ID,
+			Title:          event.Title,
+			StartAt:        event.StartAt,
+			EndAt:          event.EndAt,
+			OccurrenceDate: event.OccurrenceDate,
+		})
+
+		if start.After(cursor) {
+			availability.Free = append(availability.Free, model.FreeSlot{
+				StartAt: cursor.In(loc).Format(time.RFC3339),
+				EndAt:   start.In(loc).Format(time.RFC3339),
+			})
+		}
+		if end.After(cursor) {
+			cursor = end
+		}
+	}
+	if to.After(cursor) {
+		availability.Free = append(availability.Free, model.FreeSlot{
+			StartAt: cursor.In(loc).Format(time.RFC3339),
+			EndAt:   to.In(loc).Format(time.RFC3339),
+		})
+	}
+
+	return availability, nil
+}
+
+// resourceBookings возвращает повторения событий с бронью ресурса,
+// пересекающиеся с [from, to), по возрастанию начала.
+func resourceBookings(cfg *model.Config, resourceID int, from time.Time, to time.Time) ([]model.Event, error) {
+	eventIDs, err := repository.GetResourceEventIDs(cfg, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	booked := make([]model.Event, 0)
+	for _, eventID := range eventIDs {
+		event, err := repository.GetEventByID(cfg, eventID)
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			continue
+		}
+
+		occurrences, err := eventOccurrences(cfg, *event, from, to)
+		if err != nil {
+			return nil, err
+		}
+		booked = append(booked, occurrences...)
+	}
+
+	sort.SliceStable(booked, func(i, j int) bool {
+		si, _ := occurrenceBounds(booked[i])
+		sj, _ := occurrenceBounds(booked[j])
+		return si.Before(sj)
+	})
+
+	return booked, nil
+}
+
+// eventOccurrences возвращает событие или повторения серии, пересекающиеся
+// с [from, to), в локализованном виде.
+func eventOccurrences(cfg *model.Config, event model.Event, from time.Time, to time.Time) ([]model.Event, error) {
+	if event.Recurrence != nil {
+		return expandEvent(cfg, event, from, to)
+	}
+
+	start, end := occurrenceBounds(event)
+	if (!from.IsZero() && end.Before(from)) || (!to.IsZero() && !start.Before(to)) {
+		return nil, nil
+	}
+
+	localizeEvent(&event, config.Location(cfg))
+	return []model.Event{event}, nil
+}
+
+func occurrenceBounds(event model.Event) (time.Time, time.Time) {
+	start, _ := time.Parse(time.RFC3339, event.StartAt)
+	end, err := time.Parse(time.RFC3339, event.EndAt)
+	if err != nil || end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+func occurrencesSpan(occurrences []model.Event) (time.Time, time.Time) {
+	from, to := occurrenceBounds(occurrences[0])
+	for _, occurrence := range occurrences[1:] {
+		start, end := occurrenceBounds(occurrence)
+		if start.Before(from) {
+			from = start
+		}
+		if end.After(to) {
+			to = end
+		}
+	}
+	// граница to исключается, а событие может быть нулевой длины
+	return from, to.Add(time.Second)
+}
+
+// occurrencesOverlap сравнивает полуинтервалы [start, end); событие нулевой
+// длины занимает момент своего начала.
+func occurrencesOverlap(a model.Event, b model.Event) bool {
+	aStart, aEnd := occurrenceBounds(a)
+	bStart, bEnd := occurrenceBounds(b)
+	if aEnd.Equal(aStart) {
+		aEnd = aStart.Add(time.Second)
+	}
+	if bEnd.Equal(bStart) {
+		bEnd = bStart.Add(time.Second)
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
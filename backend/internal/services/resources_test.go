@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"backend/internal/model"
+)
+
+func TestOccurrencesOverlap(t *testing.T) {
+	event := func(start, end string) model.Event {
+		return model.Event{StartAt: start, EndAt: end}
+	}
+
+	tests := []struct {
+		name string
+		a    model.Event
+		b    model.Event
+		want bool
+	}{
+		{
+			"partial overlap",
+			event("2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"),
+			event("2024-05-01T11:00:00Z", "2024-05-01T13:00:00Z"),
+			true,
+		},
+		{
+			"one inside another",
+			event("2024-05-01T10:00:00Z", "2024-05-01T18:00:00Z"),
+			event("2024-05-01T12:00:00Z", "2024-05-01T13:00:00Z"),
+			true,
+		},
+		{
+			"back to back",
+			event("2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"),
+			event("2024-05-01T12:00:00Z", "2024-05-01T14:00:00Z"),
+			false,
+		},
+		{
+			"disjoint",
+			event("2024-05-01T10:00:00Z", "2024-05-01T11:00:00Z"),
+			event("2024-05-02T10:00:00Z", "2024-05-02T11:00:00Z"),
+			false,
+		},
+		{
+			"different offsets of the same moment",
+			event("2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"),
+			event("2024-05-01T14:30:00+03:00", "2024-05-01T15:00:00+03:00"),
+			true,
+		},
+		{
+			"zero length inside",
+			event("2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"),
+			event("2024-05-01T11:00:00Z", "2024-05-01T11:00:00Z"),
+			true,
+		},
+		{
+			"zero length at the end",
+			event("2024-05-01T10:00:00Z", "2024-05-01T12:00:00Z"),
+			event("2024-05-01T12:00:00Z", ""),
+			false,
+		},
+		{
+			"zero length at the same moment",
+			event("2024-05-01T10:00:00Z", ""),
+			event("2024-05-01T10:00:00Z", "2024-05-01T10:00:00Z"),
+			true,
+		},
+		{
+			"end before start counts as zero length",
+			event("2024-05-01T10:00:00Z", "2024-05-01T09:00:00Z"),
+			event("2024-05-01T09:30:00Z", "2024-05-01T10:30:00Z"),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := occurrencesOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("occurrencesOverlap() = %v, want %v", got, tt.want)
+			}
+			if got := occurrencesOverlap(tt.b, tt.a); got != tt.want {
+				t.Errorf("occurrencesOverlap() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
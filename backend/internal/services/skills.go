@@ -0,0 +1,364 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+const (
+	maxSkillsPerUser = 20
+	maxSkillLength   = 32
+)
+
+// Оценка кандидата: 0.7 за совпадение навыков и 0.3 за свободность.
+// Каждый совпавший навык весит 1 плюс 0.5 за подтверждение (не больше двух);
+// сумма весов 2 и выше даёт полный балл за навыки. Загрузка — 1/(1+открытые задачи).
+// Итоговая оценка, как и её составляющие, лежит в диапазоне от 0 до 1.
+const (
+	suggestSkillWeight      = 0.7
+	suggestLoadWeight       = 0.3
+	suggestEndorsementBonus = 0.5
+	suggestMaxEndorsements  = 2
+	suggestSkillSaturation  = 2.0
+)
+
+// minSubstringSkill — с какой длины навык ищется в тексте как подстрока:
+// так «дизайн» находится в «дизайна», а короткие теги вроде «go» — только
+// целым словом.
+const minSubstringSkill = 4
+
+// normalizeSkill приводит тег навыка к нижнему регистру и одиночным пробелам.
+func normalizeSkill(value string) (string, error) {
+	skill := strings.ToLower(strings.Join(strings.Fields(value), " "))
+	skill = strings.TrimPrefix(skill, "#")
+	if skill == "" {
+		return "", fmt.Errorf("skill must not be empty")
+	}
+	if utf8.RuneCountInString(skill) > maxSkillLength {
+		return "", fmt.Errorf("skill must not exceed %d characters", maxSkillLength)
+	}
+	return skill, nil
+}
+
+// GetUserSkills объединяет заявленные навыки пользователя и подтверждения
+// руководителей.
+func GetUserSkills(cfg *model.Config, telegramID string) ([]model.UserSkill, error) {
+	all, err := userSkills(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if skills, ok := all[telegramID]; ok {
+		return skills, nil
+	}
+	return []model.UserSkill{}, nil
+}
+
+// userSkills возвращает навыки по Telegram ID; пустой telegramID — всех
+// пользователей.
+func userSkills(cfg *model.Config, telegramID string) (map[string][]model.UserSkill, error) {
+	declared, err := repository.GetSelfDeclaredSkills(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	endorsements, err := repository.GetSkillEndorsements(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string)
+	if len(endorsements) > 0 {
+		users, err := GetUsers(cfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			names[user.TelegramID] = user.FullName
+		}
+	}
+
+	bySkill := make(map[string]map[string]*model.UserSkill)
+	skillFor := func(id string, skill string) *model.UserSkill {
+		if bySkill[id] == nil {
+			bySkill[id] = make(map[string]*model.UserSkill)
+		}
+		if bySkill[id][skill] == nil {
+			bySkill[id][skill] = &model.UserSkill{Skill: skill, EndorsedBy: make([]string, 0)}
+		}
+		return bySkill[id][skill]
+	}
+
+	for id, skills := range declared {
+		for _, skill := range skills {
+			skillFor(id, skill).SelfDeclared = true
+		}
+	}
+	for _, endorsement := range endorsements {
+		skill := skillFor(endorsement.TelegramID, endorsement.Skill)
+		skill.Endorsements++
+		name := names[endorsement.EndorsedBy]
+		if name == "" {
+			name = endorsement.EndorsedBy
+		}
+		skill.EndorsedBy = append(skill.EndorsedBy, name)
+	}
+
+	result := make(map[string][]model.UserSkill, len(bySkill))
+	for id, skills := range bySkill {
+		list := make([]model.UserSkill, 0, len(skills))
+		for _, skill := range skills {
+			list = append(list, *skill)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Endorsements != list[j].Endorsements {
+				return list[i].Endorsements > list[j].Endorsements
+			}
+			return list[i].Skill < list[j].Skill
+		})
+		result[id] = list
+	}
+
+	return result, nil
+}
+
+// GetSkillCatalog возвращает все навыки активных участников с числом
+// владельцев — для подсказок при вводе тегов.
+func GetSkillCatalog(cfg *model.Config) ([]model.SkillSummary, error) {
+	all, err := userSkills(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+	users, err := GetUsers(cfg)
+	if err != nil {
+		return nil, err
+	}
+	active := make(map[string]bool, len(users))
+	for _, user := range users {
+		active[user.TelegramID] = user.Active
+	}
+
+	counts := make(map[string]int)
+	for id, skills := range all {
+		if !active[id] {
+			continue
+		}
+		for _, skill := range skills {
+			counts[skill.Skill]++
+		}
+	}
+
+	catalog := make([]model.SkillSummary, 0, len(counts))
+	for skill, count := range counts {
+		catalog = append(catalog, model.SkillSummary{Skill: skill, Users: count})
+	}
+	sort.Slice(catalog, func(i, j int) bool {
+		if catalog[i].Users != catalog[j].Users {
+			return catalog[i].Users > catalog[j].Users
+		}
+		return catalog[i].Skill < catalog[j].Skill
+	})
+
+	return catalog, nil
+}
+
+// SetSelfDeclaredSkills заменяет навыки, которые пользователь указал сам.
+func SetSelfDeclaredSkills(cfg *model.Config, telegramID string, skills []string) ([]model.UserSkill, error) {
+	normalized := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, value := range skills {
+		skill, err := normalizeSkill(value)
+		if err != nil {
+			return nil, err
+		}
+		if seen[skill] {
+			continue
+		}
+		seen[skill] = true
+		normalized = append(normalized, skill)
+	}
+	if len(normalized) > maxSkillsPerUser {
+		return nil, fmt.Errorf("no more than %d skills allowed", maxSkillsPerUser)
+	}
+
+	if err := repository.ReplaceSelfDeclaredSkills(cfg, telegramID, normalized); err != nil {
+		return nil, err
+	}
+	return GetUserSkills(cfg, telegramID)
+}
+
+// EndorseSkill подтверждает навык участника от имени руководителя. Навык
+// добавляется участнику, даже если он его не указывал.
+func EndorseSkill(cfg *model.Config, telegramID string, skill string, endorsedBy string) ([]model.UserSkill, error) {
+	if telegramID == endorsedBy {
+		return nil, fmt.Errorf("you cannot endorse your own skills")
+	}
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrUserDeactivated
+	}
+
+	skill, err = normalizeSkill(skill)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.AddSkillEndorsement(cfg, telegramID, skill, endorsedBy); err != nil {
+		return nil, err
+	}
+	return GetUserSkills(cfg, telegramID)
+}
+
+func WithdrawSkillEndorsement(cfg *model.Config, telegramID string, skill string, endorsedBy string) ([]model.UserSkill, error) {
+	skill, err := normalizeSkill(skill)
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.DeleteSkillEndorsement(cfg, telegramID, skill, endorsedBy); err != nil {
+		return nil, err
+	}
+	return GetUserSkills(cfg, telegramID)
+}
+
+// SuggestAssignees ранжирует участников проекта по совпадению навыков с
+// текстом задачи и по текущей загрузке. task — ID задачи проекта или
+// произвольный текст (название и описание будущей задачи).
+func SuggestAssignees(cfg *model.Config, projectID int, task string) (*model.AssigneeSuggestions, error) {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil || project == nil {
+		return nil, err
+	}
+
+	result := &model.AssigneeSuggestions{
+		ProjectID: projectID,
+		Text:      strings.TrimSpace(task),
+		Weights: model.SuggestionWeights{
+			Skill:            suggestSkillWeight,
+			Load:             suggestLoadWeight,
+			EndorsementBonus: suggestEndorsementBonus,
+			MaxEndorsements:  suggestMaxEndorsements,
+			SkillSaturation:  suggestSkillSaturation,
+		},
+		Suggestions: make([]model.AssigneeSuggestion, 0, len(project.Members)),
+	}
+	if taskID, err := strconv.Atoi(result.Text); err == nil {
+		existing, err := GetTaskByID(cfg, taskID)
+		if err != nil || existing == nil || existing.IdProject != projectID {
+			return nil, fmt.Errorf("task %d not found in project", taskID)
+		}
+		result.TaskID = taskID
+		result.Text = strings.TrimSpace(existing.Title + "\n" + existing.Description)
+	}
+
+	text := strings.ToLower(result.Text)
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#' && r != '.'
+	}) {
+		words[strings.Trim(word, ".")] = true
+	}
+
+	skills, err := userSkills(cfg, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range project.Members {
+		suggestion := model.AssigneeSuggestion{
+			TelegramID:    member.TelegramID,
+			Username:      member.Username,
+			FullName:      member.FullName,
+			ProjectRole:   member.Role,
+			MatchedSkills: make([]model.SkillMatch, 0),
+		}
+
+		var telegramID int64
+		if user, err := GetUserByUsername(cfg, member.Username); err == nil {
+			if !user.Active {
+				continue
+			}
+			suggestion.TelegramID = user.TelegramID
+			suggestion.FullName = user.FullName
+			telegramID, _ = strconv.ParseInt(user.TelegramID, 10, 64)
+		}
+
+		tasks, err := repository.GetTasksByAssignee(cfg, telegramID, normalizeUsername(member.Username))
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tasks {
+			if !strings.EqualFold(t.Status, "Выполнена") {
+				suggestion.OpenTasks++
+			}
+		}
+
+		weight := 0.0
+		for _, skill := range skills[suggestion.TelegramID] {
+			if !skillMatches(skill.Skill, text, words) {
+				continue
+			}
+			endorsements := skill.Endorsements
+			if endorsements > suggestMaxEndorsements {
+				endorsements = suggestMaxEndorsements
+			}
+			match := model.SkillMatch{
+				Skill:        skill.Skill,
+				Endorsements: skill.Endorsements,
+				Weight:       1 + suggestEndorsementBonus*float64(endorsements),
+			}
+			weight += match.Weight
+			suggestion.MatchedSkills = append(suggestion.MatchedSkills, match)
+		}
+
+		suggestion.SkillScore = roundScore(math.Min(1, weight/suggestSkillSaturation))
+		suggestion.LoadScore = roundScore(1 / float64(1+suggestion.OpenTasks))
+		suggestion.Score = roundScore(suggestSkillWeight*suggestion.SkillScore + suggestLoadWeight*suggestion.LoadScore)
+
+		result.Suggestions = append(result.Suggestions, suggestion)
+	}
+
+	sort.SliceStable(result.Suggestions, func(i, j int) bool {
+		a, b := result.Suggestions[i], result.Suggestions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.OpenTasks != b.OpenTasks {
+			return a.OpenTasks < b.OpenTasks
+		}
+		return a.FullName < b.FullName
+	})
+
+	return result, nil
+}
+
+func skillMatches(skill string, text string, words map[string]bool) bool {
+	if words[skill] {
+		return true
+	}
+	if utf8.RuneCountInString(skill) >= minSubstringSkill && strings.Contains(text, skill) {
+		return true
+	}
+	// многословный навык совпадает, если в тексте есть все его слова
+	parts := strings.Fields(skill)
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !words[part] {
+			return false
+		}
+	}
+	return true
+}
+
+func roundScore(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
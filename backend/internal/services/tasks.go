@@ -2,19 +2,75 @@ package services
 
 import (
 	"fmt"
+	"html"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"backend/internal/config"
 	"backend/internal/model"
-	"backend/internal/notifications"
 	"backend/internal/repository"
 )
 
+// maxEstimateHours — верхняя граница оценки задачи в часах.
+const maxEstimateHours = 1000.0
+
 func CreateTask(task *model.Task) error {
 	cfg := config.LoadConfig()
 
+	projectTitle := ""
+	if project, err := GetProjectByID(cfg, task.IdProject); err == nil && project != nil {
+		projectTitle = project.Title
+	}
+
+	deadlineStr, err := prepareTask(cfg, task)
+	if err != nil {
+		return err
+	}
+
+	// текст зависит от ID задачи, поэтому собирается внутри транзакции
+	notification := userNotification(cfg, task.IdUser, model.NotifyNewTask, "")
+	id, err := repository.CreateTask(cfg, task, func(id int) []model.OutboxMessage {
+		return newTaskNotification(notification, task, projectTitle, deadlineStr, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	task.ID = id
+	refreshProjectStatus(cfg, task.IdProject)
+
+	return nil
+}
+
+// ValidateTask проверяет формат дат задачи, то, что начало не позже дедлайна,
+// и диапазон оценки.
+func ValidateTask(task *model.Task) error {
+	var start, deadline time.Time
+	var err error
+	if task.StartDate != "" {
+		if start, err = parseTaskDate(task.StartDate); err != nil {
+			return fmt.Errorf("invalid start_date format: %v", err)
+		}
+	}
+	if task.Deadline != "" {
+		if deadline, err = parseTaskDate(task.Deadline); err != nil {
+			return fmt.Errorf("invalid deadline format: %v", err)
+		}
+	}
+	if !start.IsZero() && !deadline.IsZero() && start.After(deadline) {
+		return fmt.Errorf("start_date must not be after deadline")
+	}
+	if task.EstimateHours < 0 || task.EstimateHours > maxEstimateHours {
+		return fmt.Errorf("estimate_hours must be between 0 and %.0f", maxEstimateHours)
+	}
+	return nil
+}
+
+// prepareTask заполняет статус и Telegram ID исполнителя и проверяет
+// задачу; возвращает дедлайн в формате для уведомления.
+func prepareTask(cfg *model.Config, task *model.Task) (string, error) {
 	if task.Status == "" {
 		task.Status = "Новая"
 	}
@@ -29,30 +85,25 @@ func CreateTask(task *model.Task) error {
 		}
 	}
 
-	var message string
-	id, err := repository.CreateTask(cfg, task)
-	if err != nil {
-		return err
+	if err := ValidateTask(task); err != nil {
+		return "", err
 	}
-
-	task.ID = id
-	task.IdUser = int64(task.IdUser)
-	project, err := GetProjectByID(cfg, task.IdProject)
-	projectTitle := ""
-	if err == nil && project != nil {
-		projectTitle = project.Title
+	if task.Deadline == "" {
+		return "", nil
 	}
-
-	deadlineStr := ""
-	if task.Deadline != "" {
-		deadlineTime, err := time.Parse("2006-01-02", task.Deadline)
-		if err != nil {
-			return fmt.Errorf("invalid deadline format: %v", err)
-		}
-		deadlineStr = deadlineTime.Format("02.01.2006")
+	deadlineTime, err := parseTaskDate(task.Deadline)
+	if err != nil {
+		return "", fmt.Errorf("invalid deadline format: %v", err)
 	}
+	return deadlineTime.Format("02.01.2006"), nil
+}
 
-	message = fmt.Sprintf(
+func newTaskNotification(notification *model.OutboxMessage, task *model.Task, projectTitle, deadline string, id int) []model.OutboxMessage {
+	if notification == nil {
+		return nil
+	}
+	message := *notification
+	message.Text = fmt.Sprintf(
 		"📌 Вам пришла новая задача:\n\n"+
 			"Проект: %s\n"+
 			"Задача: %s\n"+
@@ -61,20 +112,15 @@ func CreateTask(task *model.Task) error {
 			"✍️ Автор: %s\n"+
 			"⏰ Дедлайн: %s\n"+
 			"🆔 ID задачи: %d",
-		projectTitle,
-		task.Title,
-		task.Description,
-		task.User,
-		task.Author,
-		deadlineStr,
-		task.ID,
+		html.EscapeString(projectTitle),
+		html.EscapeString(task.Title),
+		html.EscapeString(task.Description),
+		html.EscapeString(task.User),
+		html.EscapeString(task.Author),
+		deadline,
+		id,
 	)
-
-	if task.IdUser != 0 {
-		notifications.SendTelegramNotification(cfg, task.IdUser, message)
-	}
-
-	return nil
+	return []model.OutboxMessage{message}
 }
 
 func GetTasksByProjectID(projectID int) ([]model.Task, error) {
@@ -87,15 +133,55 @@ func GetTasksByProjectID(projectID int) ([]model.Task, error) {
 	return tasks, err
 }
 
+// SearchTasks ищет задачи по названию и описанию; видимость проектов
+// проверяет вызывающий.
+func SearchTasks(cfg *model.Config, term string) ([]model.Task, error) {
+	return repository.SearchTasks(cfg, strings.TrimSpace(term))
+}
+
 func UpdateTask(cfg *model.Config, task *model.Task) error {
-	return repository.UpdateTask(cfg, task)
+	existing, err := GetTaskByID(cfg, task.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if task.IdProject == 0 {
+			task.IdProject = existing.IdProject
+		}
+	}
+
+	if err := repository.UpdateTask(cfg, task); err != nil {
+		return err
+	}
+
+	refreshProjectStatus(cfg, task.IdProject)
+	// задача ушла в другой проект — прежнему тоже нужен пересчёт
+	if existing != nil && existing.IdProject != task.IdProject {
+		refreshProjectStatus(cfg, existing.IdProject)
+	}
+	return nil
 }
 
 func DeleteTask(cfg *model.Config, taskID int) error {
-	return repository.DeleteTask(cfg, taskID)
+	task, err := GetTaskByID(cfg, taskID)
+	if err != nil {
+		return err
+	}
+
+	if err := repository.DeleteTask(cfg, taskID); err != nil {
+		return err
+	}
+
+	if task != nil {
+		refreshProjectStatus(cfg, task.IdProject)
+	}
+	return nil
 }
 
-func SubmitTaskCompletion(cfg *model.Config, taskID int, message string) error {
+// SubmitTaskCompletion сохраняет решение исполнителя и отправляет автору
+// задачи запрос на проверку. Если автор отсутствует, запрос уходит его
+// заместителю, а передача фиксируется в истории задачи.
+func SubmitTaskCompletion(cfg *model.Config, taskID int, message string, submitterID string) error {
 	task, err := GetTaskByID(cfg, taskID)
 	if err != nil {
 		return err
@@ -107,8 +193,14 @@ func SubmitTaskCompletion(cfg *model.Config, taskID int, message string) error {
 		projectTitle = project.Title
 	}
 
+	var (
+		delegation    *model.Absence
+		notifications []model.OutboxMessage
+	)
 	author, err := repository.GetUserByFullName(cfg, task.Author)
 	if err == nil && author != nil && author.TelegramID != "" {
+		recipient := author.TelegramID
+		delegation = delegateFor(cfg, author.TelegramID, time.Now())
 
 		notifyMsg := fmt.Sprintf(
 			"✅ Исполнитель отправил решение по задаче\n\n"+
@@ -117,22 +209,49 @@ func SubmitTaskCompletion(cfg *model.Config, taskID int, message string) error {
 				"Исполнитель: %s\n"+
 				"Сообщение:\n%s\n\n"+
 				"🆔 ID задачи: %d",
-			projectTitle,
-			task.Title,
-			task.User,
-			message,
+			html.EscapeString(projectTitle),
+			html.EscapeString(task.Title),
+			html.EscapeString(task.User),
+			html.EscapeString(message),
 			task.ID,
 		)
 
-		telegramID, _ := strconv.ParseInt(author.TelegramID, 10, 64)
-		notifications.SendTelegramNotification(cfg, telegramID, notifyMsg)
+		if delegation != nil {
+			recipient = delegation.DelegateID
+			notifyMsg += fmt.Sprintf("\n\n👤 Вы замещаете %s до %s", html.EscapeString(author.FullName), formatAbsenceDate(delegation.Until))
+		}
+
+		telegramID, _ := strconv.ParseInt(recipient, 10, 64)
+		if notification := userNotification(cfg, telegramID, model.NotifyReviewRequest, notifyMsg); notification != nil {
+			notifications = append(notifications, *notification)
+		}
 	}
 
 	// 💾 сохраняем решение
-	return repository.SubmitTaskCompletion(cfg, taskID, message)
+	if err := repository.SubmitTaskCompletion(cfg, taskID, message, notifications); err != nil {
+		return err
+	}
+
+	recordTaskHistory(cfg, model.TaskHistoryEntry{
+		TaskID:  taskID,
+		Action:  model.TaskHistorySubmitted,
+		ActorID: submitterID,
+	})
+	if delegation != nil {
+		recordTaskHistory(cfg, model.TaskHistoryEntry{
+			TaskID:       taskID,
+			Action:       model.TaskHistoryReviewRouted,
+			ActorID:      delegation.DelegateID,
+			OnBehalfOfID: delegation.TelegramID,
+			Details:      "away until " + delegation.Until,
+		})
+	}
+	return nil
 }
 
-func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer string, message string) error {
+// ReviewTaskCompletion принимает или отклоняет решение. onBehalfOf — Telegram ID
+// отсутствующего руководителя, если проверяет его заместитель.
+func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer string, message string, reviewerID string, onBehalfOf string) error {
 	task, err := GetTaskByID(cfg, taskID)
 	if err != nil {
 		return err
@@ -145,11 +264,7 @@ func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer
 	}
 
 	deadlineStr := "—"
-	if task.Deadline != "" {
-		deadlineTime, err := time.Parse("2006-01-02", task.Deadline)
-		if err != nil {
-			return fmt.Errorf("invalid deadline format: %v", err)
-		}
+	if deadlineTime, err := parseTaskDate(task.Deadline); err == nil {
 		deadlineStr = deadlineTime.Format("02.01.2006")
 	}
 
@@ -168,27 +283,64 @@ func ReviewTaskCompletion(cfg *model.Config, taskID int, approved bool, reviewer
 			"💬 Комментарий:\n%s\n\n"+
 			"🆔 ID задачи: %d",
 		statusText,
-		projectTitle,
-		task.Title,
-		task.User,
-		reviewer,
+		html.EscapeString(projectTitle),
+		html.EscapeString(task.Title),
+		html.EscapeString(task.User),
+		html.EscapeString(reviewer),
 		deadlineStr,
-		message,
+		html.EscapeString(message),
 		task.ID,
 	)
 
+	var notifications []model.OutboxMessage
 	if task.User != "" {
 		user, err := GetUserByUsername(cfg, task.User)
 		if err == nil && user != nil && user.TelegramID != "" {
 			if telegramID, err := strconv.ParseInt(user.TelegramID, 10, 64); err == nil {
-				notifications.SendTelegramNotification(cfg, telegramID, notificationMessage)
+				if notification := userNotification(cfg, telegramID, model.NotifyReviewVerdict, notificationMessage); notification != nil {
+					notifications = append(notifications, *notification)
+				}
 			}
 		}
 	}
 
-	return repository.ReviewTaskCompletion(cfg, taskID, approved, reviewer, message)
+	if err := repository.ReviewTaskCompletion(cfg, taskID, approved, reviewer, message, notifications); err != nil {
+		return err
+	}
+
+	action := model.TaskHistoryRejected
+	if approved {
+		action = model.TaskHistoryApproved
+	}
+	recordTaskHistory(cfg, model.TaskHistoryEntry{
+		TaskID:       taskID,
+		Action:       action,
+		ActorID:      reviewerID,
+		OnBehalfOfID: onBehalfOf,
+		Details:      message,
+	})
+
+	refreshProjectStatus(cfg, task.IdProject)
+	return nil
 }
 
 func GetTaskByID(cfg *model.Config, taskID int) (*model.Task, error) {
 	return repository.GetTaskByID(cfg, taskID)
 }
+
+// parseTaskDate разбирает дату задачи: с клиента она приходит как "2006-01-02",
+// а колонка deadline типа DATE читается драйвером в RFC3339. Пустой дедлайн
+// драйвер отдаёт как нулевую дату — такое значение считаем отсутствующим.
+func parseTaskDate(value string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	if t.Year() <= 1 {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	return t, nil
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/model"
+	"backend/internal/permissions"
+	"backend/internal/repository"
+)
+
+func GetTemplates(cfg *model.Config) ([]model.ProjectTemplate, error) {
+	rows, err := repository.GetTemplates(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]model.ProjectTemplate, 0, len(rows))
+	for _, row := range rows {
+		template, err := templateFromRow(&row)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+
+	return templates, nil
+}
+
+func GetTemplateByID(cfg *model.Config, templateID int) (*model.ProjectTemplate, error) {
+	row, err := repository.GetTemplateByID(cfg, templateID)
+	if err != nil || row == nil {
+		return nil, err
+	}
+	return templateFromRow(row)
+}
+
+func CreateTemplate(cfg *model.Config, template *model.ProjectTemplate) error {
+	template.Title = strings.TrimSpace(template.Title)
+	if template.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if template.Tasks == nil {
+		template.Tasks = []model.TemplateTask{}
+	}
+
+	payload, err := json.Marshal(template.Tasks)
+	if err != nil {
+		return err
+	}
+
+	template.CreatedAt = time.Now().Format(time.RFC3339)
+	id, err := repository.CreateTemplate(cfg, &repository.TemplateRow{
+		Title:       template.Title,
+		Description: template.Description,
+		RawTasks:    string(payload),
+		CreatedBy:   template.CreatedBy,
+		CreatedAt:   template.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	template.ID = id
+	return nil
+}
+
+func DeleteTemplate(cfg *model.Config, templateID int) error {
+	return repository.DeleteTemplate(cfg, templateID)
+}
+
+// SaveProjectAsTemplate сохраняет задачи проекта как шаблон. Дедлайны
+// пересчитываются в смещения от startDate, исполнители — в роли участников.
+// Без startDate отсчёт идёт от начала проекта (см. templateAnchor).
+func SaveProjectAsTemplate(cfg *model.Config, projectID int, title string, startDate string, createdBy string) (*model.ProjectTemplate, error) {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, fmt.Errorf("project not found")
+	}
+
+	tasks, err := repository.GetTasksByProjectID(cfg, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := templateAnchor(tasks, time.Now())
+	if startDate != "" {
+		start, err = time.Parse("2006-01-02", startDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_date format: %v", err)
+		}
+	}
+	start = truncateToDay(start)
+
+	rolesByUser := make(map[string]string, len(project.Members))
+	for _, member := range project.Members {
+		rolesByUser[normalizeUsername(member.Username)] = member.Role
+	}
+
+	templateTasks := make([]model.TemplateTask, 0, len(tasks))
+	// задачи приходят в порядке убывания id, шаблон храним в порядке создания
+	for i := len(tasks) - 1; i >= 0; i-- {
+		task := tasks[i]
+		templateTask := model.TemplateTask{
+			Title:           task.Title,
+			Description:     task.Description,
+			RolePlaceholder: rolesByUser[normalizeUsername(task.User)],
+		}
+		if task.Deadline != "" {
+			if deadline, err := parseTaskDate(task.Deadline); err == nil {
+				offset := int(deadline.Sub(start).Hours() / 24)
+				templateTask.DeadlineOffsetDays = &offset
+			}
+		}
+		templateTasks = append(templateTasks, templateTask)
+	}
+
+	if strings.TrimSpace(title) == "" {
+		title = project.Title
+	}
+
+	template := &model.ProjectTemplate{
+		Title:       title,
+		Description: project.Description,
+		Tasks:       templateTasks,
+		CreatedBy:   createdBy,
+	}
+	if err := CreateTemplate(cfg, template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func CreateProjectFromTemplate(cfg *model.Config, req *model.ProjectFromTemplateRequest, author string) (*model.Project, error) {
+	template, err := GetTemplateByID(cfg, req.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, fmt.Errorf("template not found")
+	}
+
+	start := time.Now()
+	if req.StartDate != "" {
+		start, err = time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_date format: %v", err)
+		}
+	}
+	start = truncateToDay(start)
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = template.Title
+	}
+	description := req.Description
+	if description == "" {
+		description = template.Description
+	}
+
+	members := make([]model.ProjectMember, 0, len(req.Members))
+	seen := make(map[string]bool)
+	for _, member := range req.Members {
+		member.Username = strings.TrimSpace(strings.TrimPrefix(member.Username, "@"))
+		if member.Username == "" || seen[normalizeUsername(member.Username)] {
+			continue
+		}
+		seen[normalizeUsername(member.Username)] = true
+		member.Role = permissions.NormalizeMemberRole(member.Role)
+		members = append(members, fillMemberProfile(cfg, member))
+	}
+	for placeholder, username := range req.RoleMapping {
+		username = strings.TrimSpace(strings.TrimPrefix(username, "@"))
+		if username == "" || seen[normalizeUsername(username)] {
+			continue
+		}
+		seen[normalizeUsername(username)] = true
+		members = append(members, fillMemberProfile(cfg, model.ProjectMember{
+			Username: username,
+			Role:     permissions.NormalizeMemberRole(placeholder),
+		}))
+	}
+
+	payload, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]model.Task, 0, len(template.Tasks))
+	deadlines := make([]string, 0, len(template.Tasks))
+	notifications := make([]*model.OutboxMessage, 0, len(template.Tasks))
+	for _, templateTask := range template.Tasks {
+		task := model.Task{
+			Title:         templateTask.Title,
+			Description:   templateTask.Description,
+			Author:        author,
+			User:          resolveTemplateAssignee(templateTask.RolePlaceholder, req.RoleMapping, members),
+			EstimateHours: templateTask.EstimateHours,
+		}
+		if templateTask.DeadlineOffsetDays != nil {
+			task.Deadline = start.AddDate(0, 0, *templateTask.DeadlineOffsetDays).Format("2006-01-02")
+		}
+		deadline, err := prepareTask(cfg, &task)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+		deadlines = append(deadlines, deadline)
+		notifications = append(notifications, userNotification(cfg, task.IdUser, model.NotifyNewTask, ""))
+	}
+
+	// проект и задачи создаются вместе: сбой на середине не оставит
+	// проект с частью задач из шаблона
+	projectID, err := repository.CreateProjectWithTasks(cfg, &repository.ProjectRow{
+		Title:       title,
+		Description: description,
+		Status:      "В работе",
+		RawUsers:    string(payload),
+		Visibility:  model.VisibilityPublic,
+	}, tasks, func(i int, task *model.Task) []model.OutboxMessage {
+		return newTaskNotification(notifications[i], task, title, deadlines[i], task.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshProjectStatus(cfg, projectID)
+
+	return GetProjectByID(cfg, projectID)
+}
+
+func resolveTemplateAssignee(placeholder string, mapping map[string]string, members []model.ProjectMember) string {
+	placeholder = strings.TrimSpace(placeholder)
+	if placeholder == "" {
+		return ""
+	}
+
+	for role, username := range mapping {
+		if strings.EqualFold(strings.TrimSpace(role), placeholder) {
+			return strings.TrimSpace(strings.TrimPrefix(username, "@"))
+		}
+	}
+
+	// роли участников нормализованы, плейсхолдер сравниваем в том же виде
+	normalized := permissions.NormalizeMemberRole(placeholder)
+	for _, member := range members {
+		if strings.EqualFold(strings.TrimSpace(member.Role), normalized) {
+			return member.Username
+		}
+	}
+
+	return ""
+}
+
+func fillMemberProfile(cfg *model.Config, member model.ProjectMember) model.ProjectMember {
+	if member.FullName != "" && member.TelegramID != "" {
+		return member
+	}
+	if user, err := GetUserByUsername(cfg, member.Username); err == nil && user != nil {
+		if member.FullName == "" {
+			member.FullName = user.FullName
+		}
+		if member.TelegramID == "" {
+			member.TelegramID = user.TelegramID
+		}
+	}
+	return member
+}
+
+func templateFromRow(row *repository.TemplateRow) (*model.ProjectTemplate, error) {
+	template := &model.ProjectTemplate{
+		ID:          row.ID,
+		Title:       row.Title,
+		Description: row.Description,
+		Tasks:       []model.TemplateTask{},
+		CreatedBy:   row.CreatedBy,
+		CreatedAt:   row.CreatedAt,
+	}
+
+	if row.RawTasks != "" {
+		if err := json.Unmarshal([]byte(row.RawTasks), &template.Tasks); err != nil {
+			return nil, fmt.Errorf("invalid template tasks: %w", err)
+		}
+	}
+
+	return template, nil
+}
+
+// templateAnchor возвращает начало проекта: самую раннюю дату начала или
+// создания задачи, а если их нет — самый ранний дедлайн. Без дат — fallback.
+func templateAnchor(tasks []model.Task, fallback time.Time) time.Time {
+	var earliest, earliestDeadline time.Time
+	for _, task := range tasks {
+		for _, value := range []string{task.StartDate, task.CreatedAt} {
+			if value == "" {
+				continue
+			}
+			if t, err := parseTaskDate(value); err == nil && (earliest.IsZero() || t.Before(earliest)) {
+				earliest = t
+			}
+		}
+		if task.Deadline != "" {
+			if t, err := parseTaskDate(task.Deadline); err == nil && (earliestDeadline.IsZero() || t.Before(earliestDeadline)) {
+				earliestDeadline = t
+			}
+		}
+	}
+	switch {
+	case !earliest.IsZero():
+		return earliest
+	case !earliestDeadline.IsZero():
+		return earliestDeadline
+	default:
+		return fallback
+	}
+}
+
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
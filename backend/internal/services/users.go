@@ -3,9 +3,12 @@ package services
 import (
 	"database/sql"
 	"errors"
+	"strconv"
 	"strings"
 
+	"backend/internal/logger"
 	"backend/internal/model"
+	"backend/internal/permissions"
 	"backend/internal/repository"
 )
 
@@ -53,3 +56,29 @@ func SearchUsersByFullName(cfg *model.Config, fullName string) ([]model.UserProf
 func UpdateUser(cfg *model.Config, telegramID string, updates *model.UserProfile) error {
 	return repository.UpdateUser(cfg, telegramID, updates)
 }
+
+// notifyAdmins отправляет сообщение всем администраторам сообщества.
+func notifyAdmins(cfg *model.Config, message string) {
+	enqueueNotifications(cfg, adminNotifications(cfg, message)...)
+}
+
+// adminNotifications собирает сообщение для каждого администратора
+// сообщества, чтобы его можно было записать вместе с изменением.
+func adminNotifications(cfg *model.Config, message string) []model.OutboxMessage {
+	users, err := GetUsers(cfg)
+	if err != nil {
+		logger.Error.Printf("adminNotifications: failed to load users: %v\n", err)
+		return nil
+	}
+
+	notifications := make([]model.OutboxMessage, 0)
+	for _, user := range users {
+		if !user.Active || !permissions.IsAdmin(user.Role) {
+			continue
+		}
+		if telegramID, err := strconv.ParseInt(user.TelegramID, 10, 64); err == nil {
+			notifications = append(notifications, model.OutboxMessage{ChatID: telegramID, EventType: model.NotifyAdmin, Text: message})
+		}
+	}
+	return notifications
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+	"backend/internal/model"
+	"backend/internal/repository"
+)
+
+const (
+	// defaultCapacityHours — сколько часов открытой работы участник может
+	// держать, если ёмкость не задана.
+	defaultCapacityHours = 40.0
+	maxCapacityHours     = 168.0
+	// unestimatedTaskHours — во сколько часов считается задача без оценки.
+	unestimatedTaskHours = 4.0
+	// upcomingDeadlineWindow — какие дедлайны попадают в ближайшие.
+	upcomingDeadlineWindow = 14 * 24 * time.Hour
+)
+
+// GetUserWorkload возвращает загрузку участника по всем проектам.
+func GetUserWorkload(cfg *model.Config, telegramID string) (*model.Workload, error) {
+	user, err := GetUserByTelegramID(cfg, telegramID)
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := repository.GetUserCapacities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	projectTitles, err := workloadProjectTitles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	workload, _, err := memberWorkload(cfg, user.TelegramID, user.Username, user.FullName, capacities, projectTitles, time.Now())
+	return workload, err
+}
+
+// GetProjectWorkload возвращает загрузку каждого участника проекта по всем
+// его проектам, самые загруженные — первыми.
+func GetProjectWorkload(cfg *model.Config, projectID int) (*model.ProjectWorkload, error) {
+	project, err := GetProjectByID(cfg, projectID)
+	if err != nil || project == nil {
+		return nil, err
+	}
+	capacities, err := repository.GetUserCapacities(cfg)
+	if err != nil {
+		return nil, err
+	}
+	projectTitles, err := workloadProjectTitles(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ProjectWorkload{
+		ProjectID: project.ID,
+		Title:     project.Title,
+		Members:   make([]model.Workload, 0, len(project.Members)),
+	}
+	now := time.Now()
+	for _, member := range project.Members {
+		telegramID, fullName := member.TelegramID, member.FullName
+		if user, err := GetUserByUsername(cfg, member.Username); err == nil {
+			if !user.Active {
+				continue
+			}
+			telegramID, fullName = user.TelegramID, user.FullName
+		}
+
+		workload, open, err := memberWorkload(cfg, telegramID, member.Username, fullName, capacities, projectTitles, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range open {
+			if task.IdProject == projectID {
+				workload.ProjectOpenTasks++
+			}
+		}
+		result.Members = append(result.Members, *workload)
+	}
+
+	sort.SliceStable(result.Members, func(i, j int) bool {
+		return result.Members[i].Utilization > result.Members[j].Utilization
+	})
+
+	return result, nil
+}
+
+// SetUserCapacity задаёт ёмкость участника в часах; 0 — значение по умолчанию.
+func SetUserCapacity(cfg *model.Config, telegramID string, hours float64) (*model.Workload, error) {
+	if hours < 0 || hours > maxCapacityHours {
+		return nil, fmt.Errorf("capacity_hours must be between 0 and %.0f", maxCapacityHours)
+	}
+	if _, err := GetUserByTelegramID(cfg, telegramID); err != nil {
+		return nil, err
+	}
+	if err := repository.SetUserCapacity(cfg, telegramID, hours); err != nil {
+		return nil, err
+	}
+	return GetUserWorkload(cfg, telegramID)
+}
+
+// CapacityWarning возвращает предупреждение, если исполнитель задачи
+// загружен сверх своей ёмкости. Ошибки подсчёта не мешают созданию задачи.
+func CapacityWarning(cfg *model.Config, task *model.Task) string {
+	if task.IdUser == 0 {
+		return ""
+	}
+	workload, err := GetUserWorkload(cfg, strconv.FormatInt(task.IdUser, 10))
+	if err != nil || !workload.OverCapacity {
+		return ""
+	}
+
+	name := workload.FullName
+	if workload.Username != "" {
+		name = "@" + strings.TrimPrefix(workload.Username, "@")
+	}
+	return fmt.Sprintf(
+		"%s is over capacity: %.1f of %.1f hours across %d open tasks",
+		name,
+		workload.EffortHours,
+		workload.CapacityHours,
+		workload.OpenTasks,
+	)
+}
+
+// memberWorkload считает загрузку участника и возвращает её вместе с его
+// открытыми задачами.
+func memberWorkload(cfg *model.Config, telegramID string, username string, fullName string, capacities map[string]float64, projectTitles map[int]string, now time.Time) (*model.Workload, []model.Task, error) {
+	workload := &model.Workload{
+		TelegramID:        telegramID,
+		Username:          username,
+		FullName:          fullName,
+		CapacityHours:     defaultCapacityHours,
+		DefaultCapacity:   true,
+		UpcomingDeadlines: make([]model.WorkloadTask, 0),
+	}
+	if hours, ok := capacities[telegramID]; ok {
+		workload.CapacityHours = hours
+		workload.DefaultCapacity = false
+	}
+
+	id, _ := strconv.ParseInt(telegramID, 10, 64)
+	tasks, err := repository.GetTasksByAssignee(cfg, id, normalizeUsername(username))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loc := config.Location(cfg)
+	now = now.In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	horizon := today.Add(upcomingDeadlineWindow)
+
+	open := make([]model.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if strings.EqualFold(task.Status, "Выполнена") {
+			continue
+		}
+		open = append(open, task)
+
+		if task.EstimateHours > 0 {
+			workload.EstimatedHours += task.EstimateHours
+		} else {
+			workload.UnestimatedTasks++
+		}
+
+		deadline, err := parseTaskDate(task.Deadline)
+		if err != nil {
+			continue
+		}
+		day := time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, loc)
+		overdue := day.Before(today)
+		if overdue {
+			workload.OverdueTasks++
+		}
+		if day.After(horizon) {
+			continue
+		}
+		workload.UpcomingDeadlines = append(workload.UpcomingDeadlines, model.WorkloadTask{
+			ID:            task.ID,
+			Title:         task.Title,
+			ProjectID:     task.IdProject,
+			ProjectTitle:  projectTitles[task.IdProject],
+			Status:        task.Status,
+			Deadline:      day.Format("2006-01-02"),
+			EstimateHours: task.EstimateHours,
+			Overdue:       overdue,
+		})
+	}
+
+	sort.SliceStable(workload.UpcomingDeadlines, func(i, j int) bool {
+		return workload.UpcomingDeadlines[i].Deadline < workload.UpcomingDeadlines[j].Deadline
+	})
+
+	workload.OpenTasks = len(open)
+	workload.UnestimatedTaskHours = unestimatedTaskHours
+	workload.EffortHours = workload.EstimatedHours + float64(workload.UnestimatedTasks)*unestimatedTaskHours
+	workload.Utilization = math.Round(workload.EffortHours/workload.CapacityHours*100) / 100
+	workload.OverCapacity = workload.EffortHours > workload.CapacityHours
+
+	return workload, open, nil
+}
+
+func workloadProjectTitles(cfg *model.Config) (map[int]string, error) {
+	projects, err := GetProjects(cfg)
+	if err != nil {
+		return nil, err
+	}
+	titles := make(map[int]string, len(projects))
+	for _, project := range projects {
+		titles[project.ID] = project.Title
+	}
+	return titles, nil
+}